@@ -0,0 +1,260 @@
+package cfnresource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Patch is a set of modifications to resources already existing in a CloudFormation stack template
+type Patch struct {
+	// Source describes where the patch comes from in error messages e.g. `plugin my-plugin`
+	Source string
+	// Merge is a JSON Merge Patch(RFC 7386) keyed by logical IDs of the resources to be modified
+	Merge map[string]interface{}
+	// JSONPatch is a JSON Patch(RFC 6902) whose paths begin with logical IDs of the resources to be modified
+	JSONPatch []PatchOperation
+}
+
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ApplyPatches applies the patches to resources in the rendered stack template in order
+func ApplyPatches(template []byte, patches []Patch, prettyPrint bool) ([]byte, error) {
+	if len(patches) == 0 {
+		return template, nil
+	}
+
+	var doc map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(template))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse stack template: %v", err)
+	}
+
+	resources, ok := doc["Resources"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("stack template has no resources to be patched")
+	}
+
+	for _, p := range patches {
+		for _, id := range sortedKeys(p.Merge) {
+			r, ok := resources[id]
+			if !ok {
+				return nil, fmt.Errorf("%s: failed to patch resource %s: no such resource in the stack template", p.Source, id)
+			}
+			resources[id] = mergePatch(r, normalize(p.Merge[id]))
+		}
+
+		for i, op := range p.JSONPatch {
+			if err := applyOperation(resources, op); err != nil {
+				return nil, fmt.Errorf("%s: failed to apply json patch operation #%d (%s %s): %v", p.Source, i, op.Op, op.Path, err)
+			}
+		}
+	}
+
+	if prettyPrint {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return json.Marshal(doc)
+}
+
+// mergePatch implements JSON Merge Patch, in which objects are merged recursively, nulls remove keys and any other values replace existing ones
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+func applyOperation(resources map[string]interface{}, op PatchOperation) error {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+	if len(tokens) < 2 {
+		return fmt.Errorf("path must point to a property of an existing resource like /<logical id>/Properties/<name>")
+	}
+	if _, ok := resources[tokens[0]]; !ok {
+		return fmt.Errorf("no such resource %s in the stack template", tokens[0])
+	}
+
+	parent, err := lookup(resources, tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	value := normalize(op.Value)
+
+	switch op.Op {
+	case "add", "replace":
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			if _, exists := c[last]; op.Op == "replace" && !exists {
+				return fmt.Errorf("no such key %s to replace", last)
+			}
+			c[last] = value
+			return nil
+		case []interface{}:
+			updated, err := updateArray(c, last, value, op.Op)
+			if err != nil {
+				return err
+			}
+			return setParent(resources, tokens[:len(tokens)-1], updated)
+		}
+	case "remove":
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			if _, exists := c[last]; !exists {
+				return fmt.Errorf("no such key %s to remove", last)
+			}
+			delete(c, last)
+			return nil
+		case []interface{}:
+			i, err := arrayIndex(last, len(c))
+			if err != nil {
+				return err
+			}
+			return setParent(resources, tokens[:len(tokens)-1], append(c[:i:i], c[i+1:]...))
+		}
+	case "test":
+		actual, err := lookup(resources, tokens)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(normalize(actual), value) {
+			return fmt.Errorf("test failed: expected %v but was %v", value, actual)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported operation \"%s\". It must be one of add, remove, replace or test", op.Op)
+	}
+	return fmt.Errorf("%s is neither an object nor an array", strings.Join(tokens[:len(tokens)-1], "/"))
+}
+
+func updateArray(a []interface{}, token string, value interface{}, op string) ([]interface{}, error) {
+	if token == "-" && op == "add" {
+		return append(a, value), nil
+	}
+	if op == "add" {
+		i, err := arrayIndex(token, len(a)+1)
+		if err != nil {
+			return nil, err
+		}
+		r := append([]interface{}{}, a[:i]...)
+		r = append(r, value)
+		return append(r, a[i:]...), nil
+	}
+	i, err := arrayIndex(token, len(a))
+	if err != nil {
+		return nil, err
+	}
+	a[i] = value
+	return a, nil
+}
+
+// setParent replaces the array at the path, as appending to or removing from a slice results in a new slice
+func setParent(root map[string]interface{}, tokens []string, value interface{}) error {
+	parent, err := lookup(root, tokens[:len(tokens)-1])
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	switch c := parent.(type) {
+	case map[string]interface{}:
+		c[last] = value
+	case []interface{}:
+		i, err := arrayIndex(last, len(c))
+		if err != nil {
+			return err
+		}
+		c[i] = value
+	}
+	return nil
+}
+
+func lookup(root map[string]interface{}, tokens []string) (interface{}, error) {
+	var current interface{} = root
+	for i, t := range tokens {
+		switch c := current.(type) {
+		case map[string]interface{}:
+			v, ok := c[t]
+			if !ok {
+				return nil, fmt.Errorf("no such key /%s", strings.Join(tokens[:i+1], "/"))
+			}
+			current = v
+		case []interface{}:
+			idx, err := arrayIndex(t, len(c))
+			if err != nil {
+				return nil, err
+			}
+			current = c[idx]
+		default:
+			return nil, fmt.Errorf("/%s is neither an object nor an array", strings.Join(tokens[:i], "/"))
+		}
+	}
+	return current, nil
+}
+
+func arrayIndex(token string, length int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= length {
+		return 0, fmt.Errorf("invalid array index %s", token)
+	}
+	return i, nil
+}
+
+// parsePointer splits the JSON Pointer(RFC 6901) into unescaped reference tokens
+func parsePointer(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path must start with /")
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// normalize converts the value to the form produced by decoding json so that it can be compared with and embedded into the template
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var r interface{}
+	if err := d.Decode(&r); err != nil {
+		return v
+	}
+	return r
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
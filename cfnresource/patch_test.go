@@ -0,0 +1,81 @@
+package cfnresource
+
+import (
+	"strings"
+	"testing"
+)
+
+const template = `{"Resources":{"Workers":{"Type":"AWS::AutoScaling::AutoScalingGroup","Properties":{"MinSize":"1","Tags":[{"Key":"a","Value":"1"}]}}}}`
+
+func TestApplyPatches(t *testing.T) {
+	t.Run("Merge", func(t *testing.T) {
+		patched, err := ApplyPatches([]byte(template), []Patch{{
+			Source: "plugin my-plugin",
+			Merge: map[string]interface{}{
+				"Workers": map[string]interface{}{
+					"Properties": map[string]interface{}{
+						"MinSize":  nil,
+						"Cooldown": "300",
+					},
+				},
+			},
+		}}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `{"Resources":{"Workers":{"Properties":{"Cooldown":"300","Tags":[{"Key":"a","Value":"1"}]},"Type":"AWS::AutoScaling::AutoScalingGroup"}}}`
+		if string(patched) != expected {
+			t.Errorf("unexpected template: expected=%s, got=%s", expected, patched)
+		}
+	})
+
+	t.Run("JSONPatch", func(t *testing.T) {
+		patched, err := ApplyPatches([]byte(template), []Patch{{
+			Source: "plugin my-plugin",
+			JSONPatch: []PatchOperation{
+				{Op: "test", Path: "/Workers/Properties/Tags/0/Key", Value: "a"},
+				{Op: "add", Path: "/Workers/Properties/Tags/-", Value: map[string]interface{}{"Key": "b", "Value": "2"}},
+				{Op: "replace", Path: "/Workers/Properties/MinSize", Value: "2"},
+				{Op: "remove", Path: "/Workers/Properties/Tags/0"},
+			},
+		}}, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := `{"Resources":{"Workers":{"Properties":{"MinSize":"2","Tags":[{"Key":"b","Value":"2"}]},"Type":"AWS::AutoScaling::AutoScalingGroup"}}}`
+		if string(patched) != expected {
+			t.Errorf("unexpected template: expected=%s, got=%s", expected, patched)
+		}
+	})
+
+	errorCases := []struct {
+		context  string
+		patch    Patch
+		expected string
+	}{
+		{
+			context:  "MergeIntoMissingResource",
+			patch:    Patch{Source: "plugin my-plugin", Merge: map[string]interface{}{"Controllers": map[string]interface{}{}}},
+			expected: "plugin my-plugin: failed to patch resource Controllers: no such resource in the stack template",
+		},
+		{
+			context:  "JSONPatchToMissingResource",
+			patch:    Patch{Source: "plugin my-plugin", JSONPatch: []PatchOperation{{Op: "add", Path: "/Controllers/Properties/Cooldown", Value: "300"}}},
+			expected: "no such resource Controllers in the stack template",
+		},
+		{
+			context:  "FailedTest",
+			patch:    Patch{Source: "plugin my-plugin", JSONPatch: []PatchOperation{{Op: "test", Path: "/Workers/Properties/MinSize", Value: "3"}}},
+			expected: "test failed",
+		},
+	}
+
+	for _, c := range errorCases {
+		t.Run(c.context, func(t *testing.T) {
+			_, err := ApplyPatches([]byte(template), []Patch{c.patch}, false)
+			if err == nil || !strings.Contains(err.Error(), c.expected) {
+				t.Errorf("expected error containing \"%s\" but got: %v", c.expected, err)
+			}
+		})
+	}
+}
@@ -52,6 +52,7 @@ func (a assetsImpl) FindAssetByStackAndFileName(stack string, file string) (mode
 type AssetsBuilder interface {
 	Add(filename string, content string) (model.Asset, error)
 	AddUserDataPart(userdata model.UserData, part string, assetName string) error
+	AddUserDataPartPerInstance(userdata model.UserData, part string, assetName string, extras []map[string]interface{}) error
 	Build() Assets
 }
 
@@ -92,6 +93,27 @@ func (b *assetsBuilderImpl) AddUserDataPart(userdata model.UserData, part string
 	return nil // it is not an error if part is not found
 }
 
+// AddUserDataPartPerInstance renders the part once for each extra context so that each instance fetches its own asset
+func (b *assetsBuilderImpl) AddUserDataPartPerInstance(userdata model.UserData, part string, assetName string, extras []map[string]interface{}) error {
+	if p, ok := userdata.Parts[part]; ok {
+		p.PerInstanceAssets = make([]model.Asset, len(extras))
+		for i, extra := range extras {
+			content, err := p.Template(extra)
+			if err != nil {
+				return err
+			}
+
+			filename := fmt.Sprintf("%s-%d-%s", assetName, i, fingerprint.SHA256(content))
+			asset, err := b.Add(filename, content)
+			if err != nil {
+				return err
+			}
+			p.PerInstanceAssets[i] = asset
+		}
+	}
+	return nil // it is not an error if part is not found
+}
+
 func (b *assetsBuilderImpl) Build() Assets {
 	return assetsImpl{
 		underlying: b.assets,
@@ -50,3 +50,8 @@ func StackEventErrMsgs(events []*cloudformation.StackEvent) []string {
 
 	return errMsgs
 }
+
+// NoUpdatesToBePerformed returns true when the error resulted from updating a stack with the same template and parameters as the deployed ones
+func NoUpdatesToBePerformed(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "No updates are to be performed")
+}
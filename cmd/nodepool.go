@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdNodePool = &cobra.Command{
+		Use:   "nodepool",
+		Short: "Manage node pools",
+		Long:  ``,
+	}
+
+	cmdNodePoolReplace = &cobra.Command{
+		Use:   "replace <name>",
+		Short: "Replace all the nodes in a node pool without an in-place rolling update",
+		Long: `Replace all the nodes in a node pool with ones in the new configuration in cluster.yaml in a blue/green manner.
+A temporary node pool named "<name>-replacement" is created with the new configuration and pods are moved onto it by draining the old nodes, honoring PodDisruptionBudgets.
+The node pool is then updated in place, and pods are moved back to its new nodes before the temporary node pool is removed.
+Pods are evicted twice so that the node pool keeps its name in the root stack.
+Steps already done are skipped, so run the command again to resume a failed replacement, or after reverting the node pool in cluster.yaml to roll it back.
+Requires kubectl and the kubeconfig for the cluster`,
+		RunE:         runCmdNodePoolReplace,
+		SilenceUsage: true,
+	}
+
+	nodePoolReplaceOpts = struct {
+		awsDebug, prettyPrint      bool
+		s3URI, kubeconfigPath      string
+		readyTimeout, drainTimeout time.Duration
+	}{}
+)
+
+func init() {
+	RootCmd.AddCommand(cmdNodePool)
+
+	cmdNodePool.AddCommand(cmdNodePoolReplace)
+	cmdNodePoolReplace.Flags().BoolVar(&nodePoolReplaceOpts.awsDebug, "aws-debug", false, "Log debug information from aws-sdk-go library")
+	cmdNodePoolReplace.Flags().BoolVar(&nodePoolReplaceOpts.prettyPrint, "pretty-print", false, "Pretty print the resulting CloudFormation")
+	cmdNodePoolReplace.Flags().StringVar(&nodePoolReplaceOpts.s3URI, "s3-uri", "", "When your template is bigger than the cloudformation limit of 51200 bytes, upload the template to the specified location in S3. S3 location expressed as s3://<bucket>/path/to/dir")
+	cmdNodePoolReplace.Flags().StringVar(&nodePoolReplaceOpts.kubeconfigPath, "kubeconfig", "kubeconfig", "Path to the kubeconfig used to drain nodes")
+	cmdNodePoolReplace.Flags().DurationVar(&nodePoolReplaceOpts.readyTimeout, "ready-timeout", 15*time.Minute, "How long to wait for new nodes to become ready")
+	cmdNodePoolReplace.Flags().DurationVar(&nodePoolReplaceOpts.drainTimeout, "drain-timeout", 10*time.Minute, "How long to wait for pods to be evicted from each node")
+}
+
+func runCmdNodePoolReplace(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("nodepool replace takes exactly one argument: the name of a node pool\n")
+	}
+	if err := validateRequired(flag{"--s3-uri", nodePoolReplaceOpts.s3URI}); err != nil {
+		return err
+	}
+
+	opts := root.NewOptions(nodePoolReplaceOpts.s3URI, nodePoolReplaceOpts.prettyPrint, false)
+	replaceOpts := root.NodePoolReplaceOptions{
+		KubeconfigPath: nodePoolReplaceOpts.kubeconfigPath,
+		ReadyTimeout:   nodePoolReplaceOpts.readyTimeout,
+		DrainTimeout:   nodePoolReplaceOpts.drainTimeout,
+	}
+
+	r, err := root.NodePoolReplacerFromFile(configPath, args[0], opts, replaceOpts, nodePoolReplaceOpts.awsDebug)
+	if err != nil {
+		return fmt.Errorf("Failed to read cluster config: %v", err)
+	}
+
+	if err := r.Replace(); err != nil {
+		return fmt.Errorf("Failed to replace node pool %s: %v", args[0], err)
+	}
+
+	fmt.Printf("Success! All the nodes in node pool %s have been replaced\n", args[0])
+	return nil
+}
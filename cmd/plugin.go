@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/plugin"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginhook"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginrender"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	cmdPlugin = &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage kube-aws plugins",
+		Long:  ``,
+	}
+
+	cmdPluginInstall = &cobra.Command{
+		Use:          "install",
+		Short:        "Fetch plugins from the sources declared in cluster.yaml",
+		Long:         `Fetch every enabled plugin having "kubeAwsPlugins.<name>.source" in cluster.yaml and cache it under ~/.kube-aws/cache/plugins`,
+		RunE:         runCmdPluginInstall,
+		SilenceUsage: true,
+	}
+
+	cmdPluginRender = &cobra.Command{
+		Use:          "render <name>",
+		Short:        "Print what a plugin contributes to the cluster",
+		Long:         `Render the plugin with values from cluster.yaml and print the cloudformation resources, files, systemd units, kubernetes manifests, helm releases and IAM policy statements it contributes. The plugin is rendered as if it were enabled even if it isn't in cluster.yaml`,
+		RunE:         runCmdPluginRender,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(cmdPlugin)
+
+	cmdPlugin.AddCommand(cmdPluginInstall)
+	cmdPlugin.AddCommand(cmdPluginRender)
+}
+
+func runCmdPluginInstall(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("plugin install takes no arguments\n")
+	}
+
+	configs, err := config.PluginConfigsFromFile(configPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read cluster config: %v", err)
+	}
+
+	dirs, err := plugin.NewInstaller().InstallAll(configs)
+	if err != nil {
+		return fmt.Errorf("Failed to install plugins: %v", err)
+	}
+
+	for _, dir := range dirs {
+		fmt.Printf("Installed a plugin into %s\n", dir)
+	}
+	return nil
+}
+
+func runCmdPluginRender(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("plugin render takes exactly one argument: the name of a plugin\n")
+	}
+
+	cfg, err := config.ConfigFromFile(configPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read cluster config: %v", err)
+	}
+
+	out, err := pluginrender.Render(args[0], cfg)
+	if err != nil {
+		return fmt.Errorf("Failed to render plugin: %v", err)
+	}
+
+	bytes, err := yaml.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal rendered plugin: %v", err)
+	}
+
+	fmt.Print(string(bytes))
+	return nil
+}
+
+// AddPluginCommands adds `kube-aws <plugin name> <command name>` for each command of the plugins enabled in cluster.yaml.
+// Plugins are loaded in the same way as other commands load them so that the subcommands are available only where the plugins are.
+// Nothing is loaded when `args`, the arguments given to kube-aws, run a builtin command or start with a flag, so that e.g. `kube-aws init`
+// never fails because of plugins
+func AddPluginCommands(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(args[0]) {
+		return nil
+	}
+
+	configs, err := config.PluginConfigsFromFile(configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read cluster config: %v", err)
+	}
+
+	plugins, err := plugin.LoadAll(configs)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range plugins {
+		if enabled, _ := p.EnabledIn(configs); !enabled || len(p.Spec.Commands) == 0 {
+			continue
+		}
+
+		for _, c := range RootCmd.Commands() {
+			if c.Name() == p.Name {
+				return fmt.Errorf("commands of plugin %s conflict with the builtin command %s", p.Name, c.Name())
+			}
+		}
+
+		cmdPluginCommands := &cobra.Command{
+			Use:   p.Name,
+			Short: fmt.Sprintf("Run commands provided by the plugin %s", p.Name),
+			Long:  ``,
+		}
+		for _, c := range p.Spec.Commands {
+			cmdPluginCommands.AddCommand(newCmdPluginCommand(p, c))
+		}
+		RootCmd.AddCommand(cmdPluginCommands)
+	}
+	return nil
+}
+
+// isBuiltinCommand returns true when `name` runs one of the commands of kube-aws itself, including `help` added by cobra on execution
+func isBuiltinCommand(name string) bool {
+	if name == "help" {
+		return true
+	}
+	for _, c := range RootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func newCmdPluginCommand(p *pluginmodel.Plugin, c pluginmodel.PluginCommand) *cobra.Command {
+	pluginName := p.Name
+	commandName := c.Name
+	return &cobra.Command{
+		Use:   commandName,
+		Short: c.Short,
+		Long:  fmt.Sprintf(`Run %s provided by the plugin %s. Arguments are passed through to it along with the cluster and values of the plugin in the environment variables %s and %s`, c.Path, pluginName, pluginhook.ClusterEnvVar, pluginhook.ValuesEnvVar),
+		// Flags are passed through to the command contributed by the plugin
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return root.RunPluginCommand(configPath, pluginName, commandName, args)
+		},
+	}
+}
@@ -46,6 +46,7 @@ func init() {
 	cmdRenderCredentials.Flags().BoolVar(&renderCredentialsOpts.GenerateCA, "generate-ca", false, "if generating credentials, generate root CA key and cert. NOT RECOMMENDED FOR PRODUCTION USE- use '-ca-key-path' and '-ca-cert-path' options to provide your own certificate authority assets")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CaKeyPath, "ca-key-path", "./credentials/ca-key.pem", "path to pem-encoded CA RSA key")
 	cmdRenderCredentials.Flags().StringVar(&renderCredentialsOpts.CaCertPath, "ca-cert-path", "./credentials/ca.pem", "path to pem-encoded CA x509 certificate")
+	cmdRenderCredentials.Flags().BoolVar(&renderCredentialsOpts.RotateServiceAccountKey, "rotate-service-account-key", false, "if generating credentials, replace the existing service account signing key. Tokens signed with the previous key remain valid as its public key is kept in 'service-account-pub.pem' until you remove it")
 }
 func runCmdRender(cmd *cobra.Command, args []string) error {
 	fmt.Println("WARNING: 'kube-aws render' is deprecated. See 'kube-aws render --help' for usage")
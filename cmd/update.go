@@ -2,11 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
 
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
 	"github.com/kubernetes-incubator/kube-aws/core/root"
+	"github.com/kubernetes-incubator/kube-aws/kubenode"
+	"github.com/kubernetes-incubator/kube-aws/noderoller"
 	"github.com/spf13/cobra"
 )
 
+const rolledNodePoolPrefix = "nodepool:"
+
 var (
 	cmdUpdate = &cobra.Command{
 		Use:          "update",
@@ -19,6 +28,11 @@ var (
 	updateOpts = struct {
 		awsDebug, prettyPrint, skipWait bool
 		s3URI                           string
+		roll                            []string
+		kubeconfigPath                  string
+		rollMaxUnavailable              int
+		rollReadyTimeout                time.Duration
+		rollDrainTimeout                time.Duration
 	}{}
 )
 
@@ -28,6 +42,11 @@ func init() {
 	cmdUpdate.Flags().BoolVar(&updateOpts.prettyPrint, "pretty-print", false, "Pretty print the resulting CloudFormation")
 	cmdUpdate.Flags().StringVar(&updateOpts.s3URI, "s3-uri", "", "When your template is bigger than the cloudformation limit of 51200 bytes, upload the template to the specified location in S3. S3 location expressed as s3://<bucket>/path/to/dir")
 	cmdUpdate.Flags().BoolVar(&updateOpts.skipWait, "skip-wait", false, "Don't wait the resources finish")
+	cmdUpdate.Flags().StringSliceVar(&updateOpts.roll, "roll", []string{}, "Replace instances of the node pools in the form of nodepool:<name> by draining and terminating them batch by batch after the stack update, instead of CloudFormation rolling updates")
+	cmdUpdate.Flags().StringVar(&updateOpts.kubeconfigPath, "kubeconfig", "kubeconfig", "Path to the kubeconfig used to drain nodes while rolling node pools")
+	cmdUpdate.Flags().IntVar(&updateOpts.rollMaxUnavailable, "roll-max-unavailable", 1, "The maximum number of nodes drained and terminated at once while rolling node pools")
+	cmdUpdate.Flags().DurationVar(&updateOpts.rollReadyTimeout, "roll-ready-timeout", 15*time.Minute, "How long to wait for replacement nodes to become ready while rolling node pools")
+	cmdUpdate.Flags().DurationVar(&updateOpts.rollDrainTimeout, "roll-drain-timeout", 10*time.Minute, "How long to wait for pods to be evicted from each node while rolling node pools")
 }
 
 func runCmdUpdate(cmd *cobra.Command, args []string) error {
@@ -35,7 +54,19 @@ func runCmdUpdate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	rolled := []string{}
+	for _, r := range updateOpts.roll {
+		if !strings.HasPrefix(r, rolledNodePoolPrefix) || r == rolledNodePoolPrefix {
+			return fmt.Errorf("invalid --roll %s: it must be in the form of %s<name>", r, rolledNodePoolPrefix)
+		}
+		rolled = append(rolled, strings.TrimPrefix(r, rolledNodePoolPrefix))
+	}
+	if len(rolled) > 0 && updateOpts.skipWait {
+		return fmt.Errorf("--roll can't be used with --skip-wait")
+	}
+
 	opts := root.NewOptions(updateOpts.s3URI, updateOpts.prettyPrint, updateOpts.skipWait)
+	opts.RolledNodePools = rolled
 
 	cluster, err := root.ClusterFromFile(configPath, opts, updateOpts.awsDebug)
 	if err != nil {
@@ -48,12 +79,19 @@ func runCmdUpdate(cmd *cobra.Command, args []string) error {
 
 	report, err := cluster.Update()
 	if err != nil {
-		return fmt.Errorf("Error updating cluster: %v", err)
+		// Allows resuming a paused roll without changes to the stacks
+		if len(rolled) == 0 || !cfnstack.NoUpdatesToBePerformed(err) {
+			return fmt.Errorf("Error updating cluster: %v", err)
+		}
 	}
 	if report != "" {
 		fmt.Printf("Update stack: %s\n", report)
 	}
 
+	if err := rollNodePools(cluster, rolled); err != nil {
+		return err
+	}
+
 	info, err := cluster.Info()
 	if err != nil {
 		return fmt.Errorf("Failed fetching cluster info: %v", err)
@@ -67,3 +105,40 @@ func runCmdUpdate(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func rollNodePools(cluster root.Cluster, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	pause := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	go func() {
+		if _, ok := <-sig; ok {
+			fmt.Println("Pausing the roll once the batch in progress is done. Interrupt again to abort")
+			signal.Stop(sig)
+			close(pause)
+		}
+	}()
+
+	nodes := kubenode.NewKubectl(updateOpts.kubeconfigPath)
+	rollOpts := noderoller.Options{
+		MaxUnavailable: updateOpts.rollMaxUnavailable,
+		ReadyTimeout:   updateOpts.rollReadyTimeout,
+		DrainTimeout:   updateOpts.rollDrainTimeout,
+		Pause:          pause,
+	}
+	for _, name := range names {
+		fmt.Printf("Rolling node pool %s...\n", name)
+		err := cluster.RollNodePool(name, nodes, rollOpts)
+		if err == noderoller.ErrPaused {
+			return fmt.Errorf("Paused rolling node pool %s. Run `kube-aws update` with the same --roll flags to resume", name)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to roll node pool %s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -146,6 +146,8 @@ func NewCluster(cfg *config.Cluster, opts config.StackTemplateOptions, plugins [
 		return nil, fmt.Errorf("failed to load control-plane stack extras from plugins: %v", err)
 	}
 	c.StackConfig.ExtraCfnResources = extraStack.Resources
+	c.StackConfig.ExtraCfnOutputs = extraStack.Outputs
+	c.StackConfig.CfnResourcePatches = extraStack.ResourcePatches
 
 	extraController, err := extras.Controller()
 	if err != nil {
@@ -153,6 +155,11 @@ func NewCluster(cfg *config.Cluster, opts config.StackTemplateOptions, plugins [
 	}
 	c.StackConfig.Config.APIServerFlags = append(c.StackConfig.Config.APIServerFlags, extraController.APIServerFlags...)
 	c.StackConfig.Config.APIServerVolumes = append(c.StackConfig.Config.APIServerVolumes, extraController.APIServerVolumes...)
+	c.StackConfig.Config.APIServerEnv = append(c.StackConfig.Config.APIServerEnv, extraController.APIServerEnv...)
+	c.StackConfig.Config.ControllerManager = extraController.ControllerManager
+	c.StackConfig.Config.Scheduler = extraController.Scheduler
+	c.StackConfig.Config.KubeProxy = extraController.KubeProxy
+	c.StackConfig.Config.Kubelet = extraController.Kubelet
 	c.StackConfig.Controller.CustomSystemdUnits = append(c.StackConfig.Controller.CustomSystemdUnits, extraController.SystemdUnits...)
 	c.StackConfig.Controller.CustomFiles = append(c.StackConfig.Controller.CustomFiles, extraController.Files...)
 	c.StackConfig.Controller.IAMConfig.Policy.Statements = append(c.StackConfig.Controller.IAMConfig.Policy.Statements, extraController.IAMPolicyStatements...)
@@ -168,6 +175,18 @@ func NewCluster(cfg *config.Cluster, opts config.StackTemplateOptions, plugins [
 	c.StackConfig.Etcd.CustomSystemdUnits = append(c.StackConfig.Etcd.CustomSystemdUnits, extraEtcd.SystemdUnits...)
 	c.StackConfig.Etcd.CustomFiles = append(c.StackConfig.Etcd.CustomFiles, extraEtcd.Files...)
 	c.StackConfig.Etcd.IAMConfig.Policy.Statements = append(c.StackConfig.Etcd.IAMConfig.Policy.Statements, extraEtcd.IAMPolicyStatements...)
+	if len(extraEtcd.Etcd.Volumes) > 0 && !c.StackConfig.Etcd.Version().Is3() {
+		return nil, fmt.Errorf("volumes for etcd contributed by plugins require etcd3 but etcd version is %s", c.StackConfig.Etcd.Version())
+	}
+	c.StackConfig.Config.EtcdServer = extraEtcd.Etcd
+
+	manifests, err := extras.KubernetesManifests()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.StackConfig.Config.SetKubernetesManifests(manifests); err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes manifests from plugins: %v", err)
+	}
 
 	c.assets, err = c.buildAssets()
 
@@ -194,7 +213,16 @@ func (c *Cluster) buildAssets() (cfnstack.Assets, error) {
 		return nil, fmt.Errorf("failed to render controller cloud config: %v", err)
 	}
 
-	if err = assets.AddUserDataPart(c.UserDataEtcd, model.USERDATA_S3, "userdata-etcd"); err != nil {
+	if c.StackConfig.Etcd.Cluster.PerNodeCertificates {
+		// Each etcd node fetches its own cloud-config so that it never sees credentials of other nodes
+		extras := make([]map[string]interface{}, len(c.StackConfig.EtcdNodes))
+		for i := range extras {
+			extras[i] = map[string]interface{}{"etcdIndex": i}
+		}
+		if err = assets.AddUserDataPartPerInstance(c.UserDataEtcd, model.USERDATA_S3, "userdata-etcd", extras); err != nil {
+			return nil, fmt.Errorf("failed to render etcd cloud config: %v", err)
+		}
+	} else if err = assets.AddUserDataPart(c.UserDataEtcd, model.USERDATA_S3, "userdata-etcd"); err != nil {
 		return nil, fmt.Errorf("failed to render etcd cloud config: %v", err)
 	}
 
@@ -24,6 +24,7 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/model/derived"
 	"github.com/kubernetes-incubator/kube-aws/netutil"
 	"github.com/kubernetes-incubator/kube-aws/node"
+	"github.com/kubernetes-incubator/kube-aws/plugin/clusterextension"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -114,6 +115,8 @@ func NewDefaultCluster() *Cluster {
 			MapPublicIPs:       true,
 			Experimental:       experimental,
 			ManageCertificates: true,
+			SecretBackend:      model.NewDefaultSecretBackend(),
+			KubeletIdentity:    model.KubeletIdentityShared,
 			AmazonSsmAgent: AmazonSsmAgent{
 				Enabled:     false,
 				DownloadUrl: "",
@@ -152,6 +155,7 @@ func NewDefaultCluster() *Cluster {
 			PauseImage:                         model.Image{Repo: "gcr.io/google_containers/pause-amd64", Tag: "3.0", RktPullDocker: false},
 			FlannelImage:                       model.Image{Repo: "quay.io/coreos/flannel", Tag: "v0.7.1", RktPullDocker: false},
 			JournaldCloudWatchLogsImage:        model.Image{Repo: "jollinshead/journald-cloudwatch-logs", Tag: "0.1", RktPullDocker: true},
+			ContainerLinuxUpdateOperatorImage:  model.Image{Repo: "quay.io/coreos/container-linux-update-operator", Tag: "v0.4.1", RktPullDocker: false},
 		},
 		KubeClusterSettings: KubeClusterSettings{
 			DNSServiceIP: "10.3.0.10",
@@ -180,6 +184,7 @@ func NewDefaultCluster() *Cluster {
 		// for base cloudformation stack
 		TLSCADurationDays:           365 * 10,
 		TLSCertDurationDays:         365,
+		CertificateIssuer:           model.NewDefaultCertificateIssuer(),
 		CreateRecordSet:             false,
 		RecordSetTTL:                300,
 		SSHAccessAllowedSourceCIDRs: model.DefaultCIDRRanges(),
@@ -288,6 +293,8 @@ func (c *Cluster) ConsumeDeprecatedKeys() {
 }
 
 func (c *Cluster) SetDefaults() {
+	c.SecretBackend.SetDefaults(c.ClusterName)
+
 	// For backward-compatibility
 	if len(c.Subnets) == 0 {
 		c.Subnets = []model.Subnet{
@@ -420,26 +427,29 @@ type DeploymentSettings struct {
 	InternetGateway             model.InternetGateway `yaml:"internetGateway,omitempty"`
 	RouteTableID                string                `yaml:"routeTableId,omitempty"`
 	// Required for validations like e.g. if instance cidr is contained in vpc cidr
-	VPCCIDR                 string            `yaml:"vpcCIDR,omitempty"`
-	InstanceCIDR            string            `yaml:"instanceCIDR,omitempty"`
-	K8sVer                  string            `yaml:"kubernetesVersion,omitempty"`
-	ContainerRuntime        string            `yaml:"containerRuntime,omitempty"`
-	KMSKeyARN               string            `yaml:"kmsKeyArn,omitempty"`
-	StackTags               map[string]string `yaml:"stackTags,omitempty"`
-	Subnets                 []model.Subnet    `yaml:"subnets,omitempty"`
-	EIPAllocationIDs        []string          `yaml:"eipAllocationIDs,omitempty"`
-	MapPublicIPs            bool              `yaml:"mapPublicIPs,omitempty"`
-	ElasticFileSystemID     string            `yaml:"elasticFileSystemId,omitempty"`
-	SharedPersistentVolume  bool              `yaml:"sharedPersistentVolume,omitempty"`
-	SSHAuthorizedKeys       []string          `yaml:"sshAuthorizedKeys,omitempty"`
-	Addons                  model.Addons      `yaml:"addons"`
-	Experimental            Experimental      `yaml:"experimental"`
-	ManageCertificates      bool              `yaml:"manageCertificates,omitempty"`
-	WaitSignal              WaitSignal        `yaml:"waitSignal"`
+	VPCCIDR                 string                `yaml:"vpcCIDR,omitempty"`
+	InstanceCIDR            string                `yaml:"instanceCIDR,omitempty"`
+	K8sVer                  string                `yaml:"kubernetesVersion,omitempty"`
+	ContainerRuntime        string                `yaml:"containerRuntime,omitempty"`
+	KMSKeyARN               string                `yaml:"kmsKeyArn,omitempty"`
+	SecretBackend           model.SecretBackend   `yaml:"secretBackend,omitempty"`
+	KubeletIdentity         model.KubeletIdentity `yaml:"kubeletIdentity,omitempty"`
+	StackTags               map[string]string     `yaml:"stackTags,omitempty"`
+	Subnets                 []model.Subnet        `yaml:"subnets,omitempty"`
+	EIPAllocationIDs        []string              `yaml:"eipAllocationIDs,omitempty"`
+	MapPublicIPs            bool                  `yaml:"mapPublicIPs,omitempty"`
+	ElasticFileSystemID     string                `yaml:"elasticFileSystemId,omitempty"`
+	SharedPersistentVolume  bool                  `yaml:"sharedPersistentVolume,omitempty"`
+	SSHAuthorizedKeys       []string              `yaml:"sshAuthorizedKeys,omitempty"`
+	Addons                  model.Addons          `yaml:"addons"`
+	Experimental            Experimental          `yaml:"experimental"`
+	ManageCertificates      bool                  `yaml:"manageCertificates,omitempty"`
+	WaitSignal              WaitSignal            `yaml:"waitSignal"`
 	CloudWatchLogging       `yaml:"cloudWatchLogging,omitempty"`
 	AmazonSsmAgent          `yaml:"amazonSsmAgent,omitempty"`
 	CloudFormationStreaming bool `yaml:"cloudFormationStreaming,omitempty"`
 	KubeDns                 `yaml:"kubeDns,omitempty"`
+	OSUpdates               model.OSUpdates `yaml:"osUpdates,omitempty"`
 
 	// Images repository
 	HyperkubeImage                     model.Image `yaml:"hyperkubeImage,omitempty"`
@@ -462,6 +472,7 @@ type DeploymentSettings struct {
 	PauseImage                         model.Image `yaml:"pauseImage,omitempty"`
 	FlannelImage                       model.Image `yaml:"flannelImage,omitempty"`
 	JournaldCloudWatchLogsImage        model.Image `yaml:"journaldCloudWatchLogsImage,omitempty"`
+	ContainerLinuxUpdateOperatorImage  model.Image `yaml:"containerLinuxUpdateOperatorImage,omitempty"`
 }
 
 // Part of configuration which is specific to worker nodes
@@ -501,19 +512,30 @@ type Cluster struct {
 	ControllerSettings     `yaml:",inline"`
 	EtcdSettings           `yaml:",inline"`
 	FlannelSettings        `yaml:",inline"`
-	AdminAPIEndpointName   string              `yaml:"adminAPIEndpointName,omitempty"`
-	ServiceCIDR            string              `yaml:"serviceCIDR,omitempty"`
-	CreateRecordSet        bool                `yaml:"createRecordSet,omitempty"`
-	RecordSetTTL           int                 `yaml:"recordSetTTL,omitempty"`
-	TLSCADurationDays      int                 `yaml:"tlsCADurationDays,omitempty"`
-	TLSCertDurationDays    int                 `yaml:"tlsCertDurationDays,omitempty"`
-	HostedZoneID           string              `yaml:"hostedZoneId,omitempty"`
-	PluginConfigs          model.PluginConfigs `yaml:"kubeAwsPlugins,omitempty"`
+	AdminAPIEndpointName   string                  `yaml:"adminAPIEndpointName,omitempty"`
+	ServiceCIDR            string                  `yaml:"serviceCIDR,omitempty"`
+	CreateRecordSet        bool                    `yaml:"createRecordSet,omitempty"`
+	RecordSetTTL           int                     `yaml:"recordSetTTL,omitempty"`
+	TLSCADurationDays      int                     `yaml:"tlsCADurationDays,omitempty"`
+	TLSCertDurationDays    int                     `yaml:"tlsCertDurationDays,omitempty"`
+	CertificateIssuer      model.CertificateIssuer `yaml:"certificateIssuer,omitempty"`
+	HostedZoneID           string                  `yaml:"hostedZoneId,omitempty"`
+	PluginConfigs          model.PluginConfigs     `yaml:"kubeAwsPlugins,omitempty"`
 	ProvidedEncryptService EncryptService
 	// SSHAccessAllowedSourceCIDRs is network ranges of sources you'd like SSH accesses to be allowed from, in CIDR notation
 	SSHAccessAllowedSourceCIDRs model.CIDRRanges       `yaml:"sshAccessAllowedSourceCIDRs,omitempty"`
 	CustomSettings              map[string]interface{} `yaml:"customSettings,omitempty"`
 	KubeResourcesAutosave       `yaml:"kubeResourcesAutosave,omitempty"`
+	// NodePoolNames are names of node pools defined under `worker.nodePools`, populated while loading the whole cluster.yaml
+	NodePoolNames []string `yaml:"-"`
+	// SystemNodePoolEnabled is true when any of node pools has the "system" role, populated while loading the whole cluster.yaml.
+	// Addons managed by kube-aws are scheduled onto such node pools when it is true
+	SystemNodePoolEnabled bool `yaml:"-"`
+	// NodePoolArchitectures are CPU architectures of node pools, populated while loading the whole cluster.yaml
+	NodePoolArchitectures []string `yaml:"-"`
+	// NodePoolOSUpdatesCoordinatedArchitectures are CPU architectures of node pools with OS updates coordinated by Kubernetes,
+	// populated while loading the whole cluster.yaml
+	NodePoolOSUpdatesCoordinatedArchitectures []string `yaml:"-"`
 }
 
 type Experimental struct {
@@ -734,6 +756,7 @@ func (c Cluster) Config(extra ...[]*pluginmodel.Plugin) (*Config, error) {
 		KubeAwsPlugins:   pluginMap,
 		APIServerFlags:   pluginmodel.APIServerFlags{},
 		APIServerVolumes: pluginmodel.APIServerVolumes{},
+		APIServerEnv:     pluginmodel.EnvVars{},
 	}
 
 	if c.AmiId == "" {
@@ -791,6 +814,93 @@ func (c Cluster) Config(extra ...[]*pluginmodel.Plugin) (*Config, error) {
 	return &config, nil
 }
 
+// KubeletUserNames returns names of the users kubelets on worker nodes authenticate as with client certificates issued by kube-aws
+func (c Cluster) KubeletUserNames() []string {
+	names := []string{}
+	if c.KubeletIdentity.PerNodePool() {
+		for _, pool := range c.NodePoolNames {
+			names = append(names, model.NodePoolKubeletUserName(pool))
+		}
+	} else if c.KubeletIdentity.SharedCertificateAuthorized() {
+		names = append(names, "kube-worker")
+	}
+	return names
+}
+
+// Architectures returns the CPU architectures of all the nodes in the cluster.
+// amd64 of controller and etcd nodes comes first
+func (c Cluster) Architectures() []string {
+	return model.SortedArchitectures(c.NodePoolArchitectures)
+}
+
+// MultiArch returns true when nodes of two or more CPU architectures are in the cluster.
+// DaemonSets managed by kube-aws are then rendered per architecture while Deployments are run on amd64 nodes
+func (c Cluster) MultiArch() bool {
+	return len(c.Architectures()) > 1
+}
+
+// AddonNodeSelector returns the node selector of addons managed by kube-aws e.g. kube-dns and heapster
+func (c Cluster) AddonNodeSelector() map[string]string {
+	selector := map[string]string{}
+	if c.SystemNodePoolEnabled {
+		selector[model.SystemRoleKey] = model.NodePoolRoleSystem
+	}
+	if c.MultiArch() {
+		selector["beta.kubernetes.io/arch"] = model.ArchitectureAMD64
+	}
+	return selector
+}
+
+// UpdateOperatorEnabled returns true when container-linux-update-operator is deployed to coordinate reboots of controller and/or worker nodes
+func (c Cluster) UpdateOperatorEnabled() bool {
+	return len(c.UpdateAgentArchitectures()) > 0
+}
+
+// UpdateAgentArchitectures returns the CPU architectures of nodes with OS updates coordinated by Kubernetes, on which the update-agent is run.
+// amd64 of controller nodes comes first
+func (c Cluster) UpdateAgentArchitectures() []string {
+	archs := c.NodePoolOSUpdatesCoordinatedArchitectures
+	if c.OSUpdates.CoordinatedByKubernetes() {
+		archs = append([]string{model.ArchitectureAMD64}, archs...)
+	}
+	return model.UniqueArchitectures(archs)
+}
+
+// validateImagesForArchitectures validates that every DaemonSet managed by kube-aws has images for all the architectures in use
+func (c Cluster) validateImagesForArchitectures() error {
+	images := map[string]model.Image{}
+	if c.UseCalico {
+		images["calicoNodeImage"] = c.CalicoNodeImage
+	}
+	if c.Experimental.NodeDrainer.Enabled {
+		images["hyperkubeImage"] = c.HyperkubeImage
+		images["awsCliImage"] = c.AWSCliImage
+	}
+	if c.KubeDns.NodeLocalResolver {
+		images["kubeDnsMasqImage"] = c.KubeDnsMasqImage
+		images["dnsMasqMetricsImage"] = c.DnsMasqMetricsImage
+	}
+	for _, arch := range c.Architectures() {
+		if arch == model.ArchitectureAMD64 {
+			continue
+		}
+		if c.Experimental.Kube2IamSupport.Enabled {
+			return fmt.Errorf("kube2IamSupport can't be enabled for a cluster with node pools of the architecture %s because kube2iam is run only on amd64 nodes", arch)
+		}
+		if err := model.ValidateImagesForArchitecture(images, arch); err != nil {
+			return err
+		}
+	}
+	// The update-agent is run only on nodes with OS updates coordinated by Kubernetes
+	for _, arch := range c.UpdateAgentArchitectures() {
+		images := map[string]model.Image{"containerLinuxUpdateOperatorImage": c.ContainerLinuxUpdateOperatorImage}
+		if err := model.ValidateImagesForArchitecture(images, arch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *Cluster) EtcdCluster() derived.EtcdCluster {
 	etcdNetwork := derived.NewNetwork(c.Etcd.Subnets, c.NATGateways())
 	return derived.NewEtcdCluster(c.Etcd.Cluster, c.Region, etcdNetwork, c.Etcd.Count)
@@ -815,6 +925,7 @@ func (c Cluster) StackConfig(opts StackTemplateOptions, extra ...[]*pluginmodel.
 	var err error
 	stackConfig := StackConfig{
 		ExtraCfnResources: map[string]interface{}{},
+		ExtraCfnOutputs:   map[string]interface{}{},
 	}
 
 	if stackConfig.Config, err = c.Config(plugins); err != nil {
@@ -824,10 +935,11 @@ func (c Cluster) StackConfig(opts StackTemplateOptions, extra ...[]*pluginmodel.
 	var compactAssets *CompactAssets
 
 	if c.AssetsEncryptionEnabled() {
-		compactAssets, err = ReadOrCreateCompactAssets(opts.AssetsDir, c.ManageCertificates, KMSConfig{
+		compactAssets, err = ReadOrCreateCompactAssets(opts.AssetsDir, c.ManageCertificates, EncryptionConfig{
 			Region:         stackConfig.Config.Region,
 			KMSKeyARN:      c.KMSKeyARN,
 			EncryptService: c.ProvidedEncryptService,
+			SecretBackend:  c.SecretBackend,
 		})
 		if err != nil {
 			return nil, err
@@ -843,6 +955,10 @@ func (c Cluster) StackConfig(opts StackTemplateOptions, extra ...[]*pluginmodel.
 		stackConfig.Config.AssetsConfig = rawAssets
 	}
 
+	if c.ManageCertificates && c.Etcd.Cluster.PerNodeCertificates && len(stackConfig.Config.AssetsConfig.EtcdNodeCerts) != c.Etcd.Count {
+		return nil, fmt.Errorf("`etcd.perNodeCertificates` is enabled but %d etcd node certificate(s) found in %s for %d etcd node(s). Run `kube-aws render credentials` to issue them", len(stackConfig.Config.AssetsConfig.EtcdNodeCerts), opts.AssetsDir, c.Etcd.Count)
+	}
+
 	if c.Experimental.TLSBootstrap.Enabled && !c.Experimental.Plugins.Rbac.Enabled {
 		fmt.Println(`WARNING: enabling cluster-level TLS bootstrapping without RBAC is not recommended. See https://kubernetes.io/docs/admin/kubelet-tls-bootstrapping/ for more information`)
 	}
@@ -875,6 +991,16 @@ type Config struct {
 
 	APIServerVolumes pluginmodel.APIServerVolumes
 	APIServerFlags   pluginmodel.APIServerFlags
+	APIServerEnv     pluginmodel.EnvVars
+
+	// ControllerManager, Scheduler, KubeProxy, Kubelet and EtcdServer are customizations to the components contributed by plugins
+	ControllerManager pluginmodel.Component
+	Scheduler         pluginmodel.Component
+	KubeProxy         pluginmodel.Component
+	Kubelet           pluginmodel.Component
+	EtcdServer        pluginmodel.Component
+
+	kubernetesManifests kubernetesManifestPlugin
 }
 
 // StackName returns the logical name of a CloudFormation stack resource in a root stack template
@@ -897,6 +1023,16 @@ func (c Cluster) EtcdIndexEnvVarName() string {
 	return "KUBE_AWS_ETCD_INDEX"
 }
 
+// EtcdSharedCredentialFileNames returns the names of the credentials every etcd node is allowed to fetch when `etcd.perNodeCertificates` is enabled
+func (c Cluster) EtcdSharedCredentialFileNames() []string {
+	return []string{"ca.pem", "etcd-client.pem", "etcd-client-key.pem"}
+}
+
+// EtcdNodeCredentialFileNames returns the names of the credentials only the etcd node at the index is allowed to fetch
+func (c Cluster) EtcdNodeCredentialFileNames(index int) []string {
+	return []string{etcdNodeCertFileName(index), etcdNodeKeyFileName(index)}
+}
+
 func (c Config) VPCLogicalName() (string, error) {
 	if c.VPC.HasIdentifier() {
 		return "", fmt.Errorf("[BUG] .VPCLogicalName should not be called in stack template when vpc id is specified")
@@ -957,6 +1093,9 @@ func (c Cluster) NodeLabels() model.NodeLabels {
 	if c.Addons.ClusterAutoscaler.Enabled {
 		labels["kube-aws.coreos.com/cluster-autoscaler-supported"] = "true"
 	}
+	if c.OSUpdates.CoordinatedByKubernetes() {
+		labels[model.OSUpdatesAgentLabelKey] = model.OSUpdatesStrategyKubernetes
+	}
 	return labels
 }
 
@@ -971,6 +1110,14 @@ func (c Cluster) validate() error {
 		return fmt.Errorf("clusterName(=%s) is malformed. It must consist only of alphanumeric characters, colons, or hyphens", c.ClusterName)
 	}
 
+	if err := c.validateImagesForArchitectures(); err != nil {
+		return err
+	}
+
+	if err := c.OSUpdates.Validate(); err != nil {
+		return fmt.Errorf("invalid osUpdates: %v", err)
+	}
+
 	if c.CreateRecordSet {
 		if c.HostedZoneID == "" {
 			return errors.New("hostedZoneID must be specified when createRecordSet is true")
@@ -1053,6 +1200,30 @@ func (c Cluster) validate() error {
 		return err
 	}
 
+	if err := c.CertificateIssuer.Validate(); err != nil {
+		return err
+	}
+
+	if c.CertificateIssuer.IsExternal() && c.Experimental.TLSBootstrap.Enabled {
+		return errors.New("experimental.tlsBootstrap can't be enabled with an external certificateIssuer because controllers need the CA key to sign kubelet certificates")
+	}
+
+	if err := c.KubeletIdentity.Validate(); err != nil {
+		return err
+	}
+
+	if !c.KubeletIdentity.SharedCertificateAuthorized() && !c.Experimental.Plugins.Rbac.Enabled {
+		return fmt.Errorf("kubeletIdentity \"%s\" requires experimental.plugins.rbac.enabled to be true so that kubelets are authorized only via their own identities", c.KubeletIdentity)
+	}
+
+	if c.KubeletIdentity.PerNodePool() && c.Experimental.TLSBootstrap.Enabled {
+		return errors.New("kubeletIdentity \"nodePool\" can't be used with experimental.tlsBootstrap because kubelets obtain their own certificates via TLS bootstrapping. Use kubeletIdentity \"tlsBootstrap\" instead")
+	}
+
+	if c.KubeletIdentity.TLSBootstrapRequired() && !c.Experimental.TLSBootstrap.Enabled {
+		return errors.New("kubeletIdentity \"tlsBootstrap\" requires experimental.tlsBootstrap.enabled to be true")
+	}
+
 	if c.WorkerTenancy != "default" && c.WorkerSpotPrice != "" {
 		return fmt.Errorf("selected worker tenancy (%s) is incompatible with spot instances", c.WorkerTenancy)
 	}
@@ -1120,7 +1291,10 @@ func (c DeploymentSettings) Validate() (*DeploymentValidationResult, error) {
 	if c.ClusterName == "" {
 		return nil, errors.New("clusterName must be set")
 	}
-	if c.KMSKeyARN == "" && c.AssetsEncryptionEnabled() {
+	if err := c.SecretBackend.Validate(); err != nil {
+		return nil, err
+	}
+	if c.KMSKeyARN == "" && c.AssetsEncryptionEnabled() && c.SecretBackend.UsesKMSKey() {
 		return nil, errors.New("kmsKeyArn must be set")
 	}
 
@@ -1242,7 +1416,7 @@ func (c DeploymentSettings) Validate() (*DeploymentValidationResult, error) {
 }
 
 func (c DeploymentSettings) AssetsEncryptionEnabled() bool {
-	return c.ManageCertificates && c.Region.SupportsKMS()
+	return c.ManageCertificates && (c.Region.SupportsKMS() || !c.SecretBackend.UsesKMSKey())
 }
 
 func (s DeploymentSettings) AllSubnets() []model.Subnet {
@@ -1385,6 +1559,14 @@ func (e EtcdSettings) Validate() error {
 		return fmt.Errorf("invalid etcd settings: %v", err)
 	}
 
+	if e.Etcd.Cluster.PerNodeCertificates && (!e.Etcd.NodeShouldHaveSecondaryENI() || e.Etcd.Cluster.EC2InternalDomainUsed()) {
+		return errors.New("`etcd.perNodeCertificates` requires `etcd.memberIdentityProvider` to be \"eni\" and `etcd.internalDomainName` to be set so that the FQDN of each etcd node is known before it is created")
+	}
+
+	if e.Etcd.Cluster.PerNodeCertificates && e.IAMConfig.InstanceProfile.Arn != "" {
+		return errors.New("`etcd.perNodeCertificates` can't be used with `etcd.iam.instanceProfile.arn` because kube-aws creates an instance profile for each etcd node which is allowed to fetch only its own credentials")
+	}
+
 	if e.Etcd.Version().Is3() {
 		if e.Etcd.DisasterRecovery.Automated && !e.Etcd.Snapshot.Automated {
 			return errors.New("`etcd.disasterRecovery.automated` is set to true but `etcd.snapshot.automated` is not - automated disaster recovery requires snapshot to be also automated")
@@ -1497,34 +1679,6 @@ func (c *Config) ManagedELBLogicalNames() []string {
 	return c.APIEndpoints.ManagedELBLogicalNames()
 }
 
-type kubernetesManifestPlugin struct {
-	Manifests []pluggedInKubernetesManifest
-}
-
-func (p kubernetesManifestPlugin) ManifestListFile() node.UploadedFile {
-	paths := []string{}
-	for _, m := range p.Manifests {
-		paths = append(paths, m.ManifestFile.Path)
-	}
-	bytes := []byte(strings.Join(paths, "\n"))
-	return node.UploadedFile{
-		Path:    p.listFilePath(),
-		Content: node.NewUploadedFileContent(bytes),
-	}
-}
-
-func (p kubernetesManifestPlugin) listFilePath() string {
-	return "/srv/kube-aws/plugins/kubernetes-manifests"
-}
-
-func (p kubernetesManifestPlugin) Directory() string {
-	return filepath.Dir(p.listFilePath())
-}
-
-type pluggedInKubernetesManifest struct {
-	ManifestFile node.UploadedFile
-}
-
 type helmReleasePlugin struct {
 	Releases []pluggedInHelmRelease
 }
@@ -1554,28 +1708,20 @@ type pluggedInHelmRelease struct {
 	ReleaseFile node.UploadedFile
 }
 
+// KubernetesManifestPlugin returns the kubernetes manifests contributed by plugins, sorted by their weights
 func (c *Config) KubernetesManifestPlugin() kubernetesManifestPlugin {
-	manifests := []pluggedInKubernetesManifest{}
-	for pluginName, _ := range c.PluginConfigs {
-		plugin, ok := c.KubeAwsPlugins[pluginName]
-		if !ok {
-			panic(fmt.Errorf("Plugin %s is requested but not loaded. Probably a typo in the plugin name inside cluster.yaml?", pluginName))
-		}
-		for _, manifestConfig := range plugin.Configuration.Kubernetes.Manifests {
-			bytes := []byte(manifestConfig.Contents.Inline)
-			m := pluggedInKubernetesManifest{
-				ManifestFile: node.UploadedFile{
-					Path:    filepath.Join("/srv/kube-aws/plugins", plugin.Metadata.Name, manifestConfig.Name),
-					Content: node.NewUploadedFileContent(bytes),
-				},
-			}
-			manifests = append(manifests, m)
-		}
-	}
-	p := kubernetesManifestPlugin{
-		Manifests: manifests,
+	return c.kubernetesManifests
+}
+
+// SetKubernetesManifests labels and orders the kubernetes manifests contributed by plugins so that they can be applied by controller nodes
+func (c *Config) SetKubernetesManifests(manifests []clusterextension.KubernetesManifest) error {
+	p, err := newKubernetesManifestPlugin(manifests)
+	if err != nil {
+		return err
 	}
-	return p
+	p.podSecurityPoliciesServed = c.Experimental.Admission.PodSecurityPolicy.Enabled
+	c.kubernetesManifests = p
+	return nil
 }
 
 func (c *Config) HelmReleasePlugin() helmReleasePlugin {
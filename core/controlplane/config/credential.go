@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 const CacheFileExtension = "enc"
@@ -26,14 +27,18 @@ type EncryptedCredentialOnDisk struct {
 }
 
 type CachedEncryptor struct {
-	bytesEncryptionService bytesEncryptionService
+	bytesEncryptionService BytesEncryptionService
 }
 
-func (e CachedEncryptor) EncryptedBytes(raw []byte) ([]byte, error) {
-	return e.bytesEncryptionService.Encrypt(raw)
+func (e CachedEncryptor) EncryptedBytes(name string, raw []byte) ([]byte, error) {
+	return e.bytesEncryptionService.Encrypt(name, raw)
 }
 
-func (e CachedEncryptor) EncryptedCredentialFromPath(filePath string, defaultValue *string) (*EncryptedCredentialOnDisk, error) {
+// EncryptedCredentialFromPath returns the encrypted credential read from `name` in `dirname`.
+// `name` is the path relative to the assets directory, which identifies the credential in secret backends storing credentials outside of userdata
+func (e CachedEncryptor) EncryptedCredentialFromPath(dirname string, name string, defaultValue *string) (*EncryptedCredentialOnDisk, error) {
+	filePath := filepath.Join(dirname, name)
+
 	raw, err := RawCredentialFileFromPath(filePath, defaultValue)
 	if err != nil {
 		return nil, err
@@ -41,14 +46,14 @@ func (e CachedEncryptor) EncryptedCredentialFromPath(filePath string, defaultVal
 
 	cache, err := EncryptedCredentialCacheFromPath(filePath)
 	if err != nil {
-		cache, err = EncryptedCredentialCacheFromRawCredential(raw, e.bytesEncryptionService)
+		cache, err = EncryptedCredentialCacheFromRawCredential(raw, name, e.bytesEncryptionService)
 		if err != nil {
 			return nil, err
 		}
 		fmt.Printf("INFO: generated \"%s\" by encrypting \"%s\"\n", cache.filePath, raw.filePath)
 	} else if raw.Fingerprint() != cache.Fingerprint() {
 		fmt.Printf("INFO: \"%s\" is not up-to-date. kube-aws is regenerating it from \"%s\"\n", cache.filePath, raw.filePath)
-		cache, err = EncryptedCredentialCacheFromRawCredential(raw, e.bytesEncryptionService)
+		cache, err = EncryptedCredentialCacheFromRawCredential(raw, name, e.bytesEncryptionService)
 		if err != nil {
 			return nil, err
 		}
@@ -57,8 +62,8 @@ func (e CachedEncryptor) EncryptedCredentialFromPath(filePath string, defaultVal
 	return cache, nil
 }
 
-func EncryptedCredentialCacheFromRawCredential(raw *RawCredentialOnDisk, bytesEncryptionService bytesEncryptionService) (*EncryptedCredentialOnDisk, error) {
-	encrypted, err := bytesEncryptionService.Encrypt(raw.content)
+func EncryptedCredentialCacheFromRawCredential(raw *RawCredentialOnDisk, name string, bytesEncryptionService BytesEncryptionService) (*EncryptedCredentialOnDisk, error) {
+	encrypted, err := bytesEncryptionService.Encrypt(filepath.ToSlash(name), raw.content)
 	if err != nil {
 		return nil, err
 	}
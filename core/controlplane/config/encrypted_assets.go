@@ -5,18 +5,19 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
 	"time"
 
 	"io/ioutil"
 	"path/filepath"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/kubernetes-incubator/kube-aws/gzipcompressor"
 	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/model/derived"
 	"github.com/kubernetes-incubator/kube-aws/netutil"
 	"github.com/kubernetes-incubator/kube-aws/tlsutil"
 )
@@ -36,6 +37,21 @@ type RawAssetsOnMemory struct {
 	EtcdKey        []byte
 	EtcdClientKey  []byte
 
+	// PEM encoded key used by controller-manager to sign service account tokens, and
+	// public keys of previous signing keys apiserver keeps accepting until tokens signed by them are rotated
+	ServiceAccountKey        []byte
+	ServiceAccountPublicKeys []byte
+
+	// PEM encoded TLS assets issued for each etcd node, indexed by the etcd node index.
+	// Empty unless `etcd.perNodeCertificates` is enabled
+	EtcdNodeCerts [][]byte
+	EtcdNodeKeys  [][]byte
+
+	// PEM encoded TLS assets issued for each node pool, keyed by the node pool name.
+	// Empty unless `kubeletIdentity` is `nodePool`
+	WorkerNodePoolCerts map[string][]byte
+	WorkerNodePoolKeys  map[string][]byte
+
 	// Other assets.
 	AuthTokens        []byte
 	TLSBootstrapToken []byte
@@ -56,6 +72,15 @@ type RawAssetsOnDisk struct {
 	EtcdKey        RawCredentialOnDisk
 	EtcdClientKey  RawCredentialOnDisk
 
+	ServiceAccountKey        RawCredentialOnDisk
+	ServiceAccountPublicKeys RawCredentialOnDisk
+
+	EtcdNodeCerts []RawCredentialOnDisk
+	EtcdNodeKeys  []RawCredentialOnDisk
+
+	WorkerNodePoolCerts map[string]RawCredentialOnDisk
+	WorkerNodePoolKeys  map[string]RawCredentialOnDisk
+
 	// Other assets.
 	AuthTokens        RawCredentialOnDisk
 	TLSBootstrapToken RawCredentialOnDisk
@@ -76,6 +101,15 @@ type EncryptedAssetsOnDisk struct {
 	EtcdKey        EncryptedCredentialOnDisk
 	EtcdClientKey  EncryptedCredentialOnDisk
 
+	ServiceAccountKey        EncryptedCredentialOnDisk
+	ServiceAccountPublicKeys EncryptedCredentialOnDisk
+
+	EtcdNodeCerts []EncryptedCredentialOnDisk
+	EtcdNodeKeys  []EncryptedCredentialOnDisk
+
+	WorkerNodePoolCerts map[string]EncryptedCredentialOnDisk
+	WorkerNodePoolKeys  map[string]EncryptedCredentialOnDisk
+
 	// Other encrypted assets.
 	AuthTokens        EncryptedCredentialOnDisk
 	TLSBootstrapToken EncryptedCredentialOnDisk
@@ -96,6 +130,17 @@ type CompactAssets struct {
 	EtcdClientKey  string
 	EtcdKey        string
 
+	ServiceAccountKey        string
+	ServiceAccountPublicKeys string
+
+	// Indexed by the etcd node index so that each etcd node is provisioned only with its own credentials
+	EtcdNodeCerts []string
+	EtcdNodeKeys  []string
+
+	// Keyed by the node pool name so that each node pool is provisioned only with its own credentials
+	WorkerNodePoolCerts map[string]string
+	WorkerNodePoolKeys  map[string]string
+
 	// Encrypted -> gzip -> base64 encoded assets.
 	AuthTokens        string
 	TLSBootstrapToken string
@@ -127,32 +172,101 @@ type CredentialsOptions struct {
 	GenerateCA bool
 	CaKeyPath  string
 	CaCertPath string
+	// RotateServiceAccountKey replaces the service account signing key while keeping its public key trusted by apiserver
+	RotateServiceAccountKey bool
 }
 
-func (c *Cluster) NewAssetsOnDisk(dir string, renderCredentialsOpts CredentialsOptions, caKey *rsa.PrivateKey, caCert *x509.Certificate) (*RawAssetsOnDisk, error) {
-	assets, err := c.NewAssetsOnMemory(caKey, caCert)
+// NewCertificateIssuer returns the issuer of certificates backed by the external PKI configured in cluster.yaml
+func (c *Cluster) NewCertificateIssuer() (tlsutil.Issuer, error) {
+	switch c.CertificateIssuer.Type {
+	case model.CertificateIssuerVault:
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			return nil, errors.New("VAULT_TOKEN must be set to issue certificates with vault")
+		}
+		return &tlsutil.VaultPKIIssuer{
+			Address:    c.CertificateIssuer.Vault.Address,
+			Mount:      c.CertificateIssuer.Vault.Mount,
+			Role:       c.CertificateIssuer.Vault.Role,
+			Token:      token,
+			HTTPClient: http.DefaultClient,
+		}, nil
+	case model.CertificateIssuerWebhook:
+		return &tlsutil.WebhookIssuer{
+			URL:        c.CertificateIssuer.Webhook.URL,
+			HTTPClient: http.DefaultClient,
+		}, nil
+	}
+	return nil, fmt.Errorf("certificate issuer \"%s\" isn't an external one", c.CertificateIssuer.Type)
+}
+
+func (c *Cluster) NewAssetsOnDisk(dir string, renderCredentialsOpts CredentialsOptions, issuer tlsutil.Issuer) (*RawAssetsOnDisk, error) {
+	assets, err := c.NewAssetsOnMemory(issuer)
 	if err != nil {
 		return nil, fmt.Errorf("Error generating default assets: %v", err)
 	}
+	if err := assets.inheritServiceAccountKeys(dir, renderCredentialsOpts.RotateServiceAccountKey); err != nil {
+		return nil, fmt.Errorf("Error reading existing service account keys: %v", err)
+	}
 	if err := assets.WriteToDir(dir, renderCredentialsOpts.GenerateCA); err != nil {
 		return nil, fmt.Errorf("Error create assets: %v", err)
 	}
 	return ReadRawAssets(dir, true)
 }
 
-func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certificate) (*RawAssetsOnMemory, error) {
+// inheritServiceAccountKeys keeps the service account signing key already rendered into the directory so that
+// re-rendering credentials doesn't invalidate every service account token at once.
+// When rotating, the previous key is replaced with the newly generated one and its public key is appended to
+// the public keys apiserver keeps accepting tokens signed with
+func (r *RawAssetsOnMemory) inheritServiceAccountKeys(dirname string, rotate bool) error {
+	pubKeys, err := ioutil.ReadFile(filepath.Join(dirname, serviceAccountPublicKeysFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	r.ServiceAccountPublicKeys = pubKeys
+
+	prevKey, err := ioutil.ReadFile(filepath.Join(dirname, serviceAccountKeyFileName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !rotate {
+		r.ServiceAccountKey = prevKey
+		return nil
+	}
+
+	key, err := tlsutil.DecodePrivateKeyPEM(prevKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %v", serviceAccountKeyFileName, err)
+	}
+	pubKey, err := tlsutil.EncodePublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	r.ServiceAccountPublicKeys = append(r.ServiceAccountPublicKeys, pubKey...)
+	return nil
+}
+
+const (
+	serviceAccountKeyFileName        = "service-account-key.pem"
+	serviceAccountPublicKeysFileName = "service-account-pub.pem"
+)
+
+func (c *Cluster) NewAssetsOnMemory(issuer tlsutil.Issuer) (*RawAssetsOnMemory, error) {
 	// Convert from days to time.Duration
 	certDuration := time.Duration(c.TLSCertDurationDays) * 24 * time.Hour
 
 	// Generate keys for the various components.
-	keys := make([]*rsa.PrivateKey, 5)
+	keys := make([]*rsa.PrivateKey, 6)
 	var err error
 	for i := range keys {
 		if keys[i], err = tlsutil.NewPrivateKey(); err != nil {
 			return nil, err
 		}
 	}
-	apiServerKey, workerKey, adminKey, etcdKey, etcdClientKey := keys[0], keys[1], keys[2], keys[3], keys[4]
+	apiServerKey, workerKey, adminKey, etcdKey, etcdClientKey, serviceAccountKey := keys[0], keys[1], keys[2], keys[3], keys[4], keys[5]
 
 	//Compute kubernetesServiceIP from serviceCIDR
 	_, serviceNet, err := net.ParseCIDR(c.ServiceCIDR)
@@ -177,7 +291,7 @@ func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certific
 		},
 		Duration: certDuration,
 	}
-	apiServerCert, err := tlsutil.NewSignedServerCertificate(apiServerConfig, apiServerKey, caCert, caKey)
+	apiServerCert, err := issuer.IssueServerCertificate(apiServerConfig, apiServerKey)
 	if err != nil {
 		return nil, err
 	}
@@ -190,11 +304,18 @@ func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certific
 		Duration: tlsutil.Duration365d,
 	}
 
-	etcdCert, err := tlsutil.NewSignedServerCertificate(etcdConfig, etcdKey, caCert, caKey)
+	etcdCert, err := issuer.IssueServerCertificate(etcdConfig, etcdKey)
 	if err != nil {
 		return nil, err
 	}
 
+	var etcdNodeCerts, etcdNodeKeys [][]byte
+	if c.Etcd.Cluster.PerNodeCertificates {
+		if etcdNodeCerts, etcdNodeKeys, err = c.newEtcdNodeCredentials(issuer); err != nil {
+			return nil, err
+		}
+	}
+
 	workerConfig := tlsutil.ClientCertConfig{
 		CommonName: "kube-worker",
 		DNSNames: []string{
@@ -203,17 +324,39 @@ func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certific
 		},
 		Duration: certDuration,
 	}
-	workerCert, err := tlsutil.NewSignedClientCertificate(workerConfig, workerKey, caCert, caKey)
+	workerCert, err := issuer.IssueClientCertificate(workerConfig, workerKey)
 	if err != nil {
 		return nil, err
 	}
 
+	var workerNodePoolCerts, workerNodePoolKeys map[string][]byte
+	if c.KubeletIdentity.PerNodePool() {
+		workerNodePoolCerts = map[string][]byte{}
+		workerNodePoolKeys = map[string][]byte{}
+		for _, pool := range c.NodePoolNames {
+			key, err := tlsutil.NewPrivateKey()
+			if err != nil {
+				return nil, err
+			}
+			cert, err := issuer.IssueClientCertificate(tlsutil.ClientCertConfig{
+				CommonName: model.NodePoolKubeletUserName(pool),
+				DNSNames:   workerConfig.DNSNames,
+				Duration:   certDuration,
+			}, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to issue certificate for node pool %s: %v", pool, err)
+			}
+			workerNodePoolCerts[pool] = tlsutil.EncodeCertificatePEM(cert)
+			workerNodePoolKeys[pool] = tlsutil.EncodePrivateKeyPEM(key)
+		}
+	}
+
 	etcdClientConfig := tlsutil.ClientCertConfig{
 		CommonName: "kube-etcd-client",
 		Duration:   certDuration,
 	}
 
-	etcdClientCert, err := tlsutil.NewSignedClientCertificate(etcdClientConfig, etcdClientKey, caCert, caKey)
+	etcdClientCert, err := issuer.IssueClientCertificate(etcdClientConfig, etcdClientKey)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +366,7 @@ func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certific
 		Organization: []string{"system:masters"},
 		Duration:     certDuration,
 	}
-	adminCert, err := tlsutil.NewSignedClientCertificate(adminConfig, adminKey, caCert, caKey)
+	adminCert, err := issuer.IssueClientCertificate(adminConfig, adminKey)
 	if err != nil {
 		return nil, err
 	}
@@ -235,6 +378,17 @@ func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certific
 		return nil, err
 	}
 
+	caCert, err := issuer.CACertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	// The CA key is available only when certificates are signed locally
+	var caKey []byte
+	if local, ok := issuer.(*tlsutil.LocalIssuer); ok {
+		caKey = tlsutil.EncodePrivateKeyPEM(local.CAKey)
+	}
+
 	return &RawAssetsOnMemory{
 		CACert:         tlsutil.EncodeCertificatePEM(caCert),
 		APIServerCert:  tlsutil.EncodeCertificatePEM(apiServerCert),
@@ -242,18 +396,102 @@ func (c *Cluster) NewAssetsOnMemory(caKey *rsa.PrivateKey, caCert *x509.Certific
 		AdminCert:      tlsutil.EncodeCertificatePEM(adminCert),
 		EtcdCert:       tlsutil.EncodeCertificatePEM(etcdCert),
 		EtcdClientCert: tlsutil.EncodeCertificatePEM(etcdClientCert),
-		CAKey:          tlsutil.EncodePrivateKeyPEM(caKey),
+		CAKey:          caKey,
 		APIServerKey:   tlsutil.EncodePrivateKeyPEM(apiServerKey),
 		WorkerKey:      tlsutil.EncodePrivateKeyPEM(workerKey),
 		AdminKey:       tlsutil.EncodePrivateKeyPEM(adminKey),
 		EtcdKey:        tlsutil.EncodePrivateKeyPEM(etcdKey),
 		EtcdClientKey:  tlsutil.EncodePrivateKeyPEM(etcdClientKey),
+		EtcdNodeCerts:  etcdNodeCerts,
+		EtcdNodeKeys:   etcdNodeKeys,
+
+		ServiceAccountKey: tlsutil.EncodePrivateKeyPEM(serviceAccountKey),
+
+		WorkerNodePoolCerts: workerNodePoolCerts,
+		WorkerNodePoolKeys:  workerNodePoolKeys,
 
 		AuthTokens:        []byte(authTokens),
 		TLSBootstrapToken: []byte(tlsBootstrapToken),
 	}, nil
 }
 
+// newEtcdNodeCredentials issues a server/peer certificate for each etcd node whose SAN is the advertised FQDN of the node
+func (c *Cluster) newEtcdNodeCredentials(issuer tlsutil.Issuer) ([][]byte, [][]byte, error) {
+	nodes, err := derived.NewEtcdNodes(c.Etcd.Nodes, c.EtcdCluster())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certs := make([][]byte, len(nodes))
+	keys := make([][]byte, len(nodes))
+	for i, node := range nodes {
+		fqdn, err := node.AdvertisedFQDN()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := tlsutil.NewPrivateKey()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cert, err := issuer.IssueServerCertificate(tlsutil.ServerCertConfig{
+			CommonName: "kube-etcd",
+			DNSNames:   []string{fqdn},
+			Duration:   tlsutil.Duration365d,
+		}, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to issue certificate for etcd node %s: %v", node.Name(), err)
+		}
+
+		certs[i] = tlsutil.EncodeCertificatePEM(cert)
+		keys[i] = tlsutil.EncodePrivateKeyPEM(key)
+	}
+	return certs, keys, nil
+}
+
+func etcdNodeCertFileName(index int) string {
+	return fmt.Sprintf("etcd-node-%d.pem", index)
+}
+
+func etcdNodeKeyFileName(index int) string {
+	return fmt.Sprintf("etcd-node-%d-key.pem", index)
+}
+
+// countEtcdNodeCredentials returns the number of etcd nodes which have their own credentials in the directory
+func countEtcdNodeCredentials(dirname string) int {
+	n := 0
+	for {
+		if _, err := os.Stat(filepath.Join(dirname, etcdNodeCertFileName(n))); err != nil {
+			return n
+		}
+		n++
+	}
+}
+
+func nodePoolWorkerCertFileName(nodePoolName string) string {
+	return filepath.Join("nodepools", nodePoolName, "worker.pem")
+}
+
+func nodePoolWorkerKeyFileName(nodePoolName string) string {
+	return filepath.Join("nodepools", nodePoolName, "worker-key.pem")
+}
+
+// nodePoolsWithCredentials returns names of node pools which have their own credentials in the directory
+func nodePoolsWithCredentials(dirname string) []string {
+	infos, err := ioutil.ReadDir(filepath.Join(dirname, "nodepools"))
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, info := range infos {
+		if _, err := os.Stat(filepath.Join(dirname, nodePoolWorkerCertFileName(info.Name()))); info.IsDir() && err == nil {
+			names = append(names, info.Name())
+		}
+	}
+	return names
+}
+
 func ReadRawAssets(dirname string, manageCertificates bool) (*RawAssetsOnDisk, error) {
 	defaultTokensFile := ""
 	defaultTLSBootstrapToken, err := RandomTLSBootstrapTokenString()
@@ -263,38 +501,69 @@ func ReadRawAssets(dirname string, manageCertificates bool) (*RawAssetsOnDisk, e
 
 	r := new(RawAssetsOnDisk)
 
+	var nodePools []string
+	var nodePoolCerts, nodePoolKeys []RawCredentialOnDisk
+
 	type entry struct {
 		name         string
 		data         *RawCredentialOnDisk
 		defaultValue *string
+		optional     bool
 	}
 
 	// Uses a random token as default value
 	files := []entry{
-		{"tokens.csv", &r.AuthTokens, &defaultTokensFile},
-		{"kubelet-tls-bootstrap-token", &r.TLSBootstrapToken, &defaultTLSBootstrapToken},
+		{"tokens.csv", &r.AuthTokens, &defaultTokensFile, false},
+		{"kubelet-tls-bootstrap-token", &r.TLSBootstrapToken, &defaultTLSBootstrapToken, false},
 	}
 
 	if manageCertificates {
-		// Assumes no default values for any cert
+		// Assumes no default values for any cert.
+		// ca-key.pem is missing when certificates are issued by an external PKI
 		files = append(files, []entry{
-			{"ca.pem", &r.CACert, nil},
-			{"ca-key.pem", &r.CAKey, nil},
-			{"apiserver.pem", &r.APIServerCert, nil},
-			{"apiserver-key.pem", &r.APIServerKey, nil},
-			{"worker.pem", &r.WorkerCert, nil},
-			{"worker-key.pem", &r.WorkerKey, nil},
-			{"admin.pem", &r.AdminCert, nil},
-			{"admin-key.pem", &r.AdminKey, nil},
-			{"etcd.pem", &r.EtcdCert, nil},
-			{"etcd-key.pem", &r.EtcdKey, nil},
-			{"etcd-client.pem", &r.EtcdClientCert, nil},
-			{"etcd-client-key.pem", &r.EtcdClientKey, nil},
+			{"ca.pem", &r.CACert, nil, false},
+			{"ca-key.pem", &r.CAKey, nil, true},
+			{"apiserver.pem", &r.APIServerCert, nil, false},
+			{"apiserver-key.pem", &r.APIServerKey, nil, false},
+			{"worker.pem", &r.WorkerCert, nil, false},
+			{"worker-key.pem", &r.WorkerKey, nil, false},
+			{"admin.pem", &r.AdminCert, nil, false},
+			{"admin-key.pem", &r.AdminKey, nil, false},
+			{"etcd.pem", &r.EtcdCert, nil, false},
+			{"etcd-key.pem", &r.EtcdKey, nil, false},
+			{"etcd-client.pem", &r.EtcdClientCert, nil, false},
+			{"etcd-client-key.pem", &r.EtcdClientKey, nil, false},
+			// Clusters rendered before the dedicated service account key was introduced sign tokens with apiserver-key.pem
+			{serviceAccountKeyFileName, &r.ServiceAccountKey, nil, true},
+			{serviceAccountPublicKeysFileName, &r.ServiceAccountPublicKeys, nil, true},
 		}...)
+
+		n := countEtcdNodeCredentials(dirname)
+		r.EtcdNodeCerts = make([]RawCredentialOnDisk, n)
+		r.EtcdNodeKeys = make([]RawCredentialOnDisk, n)
+		for i := 0; i < n; i++ {
+			files = append(files, []entry{
+				{etcdNodeCertFileName(i), &r.EtcdNodeCerts[i], nil, false},
+				{etcdNodeKeyFileName(i), &r.EtcdNodeKeys[i], nil, false},
+			}...)
+		}
+
+		nodePools = nodePoolsWithCredentials(dirname)
+		nodePoolCerts = make([]RawCredentialOnDisk, len(nodePools))
+		nodePoolKeys = make([]RawCredentialOnDisk, len(nodePools))
+		for i, pool := range nodePools {
+			files = append(files, []entry{
+				{nodePoolWorkerCertFileName(pool), &nodePoolCerts[i], nil, false},
+				{nodePoolWorkerKeyFileName(pool), &nodePoolKeys[i], nil, false},
+			}...)
+		}
 	}
 
 	for _, file := range files {
 		path := filepath.Join(dirname, file.name)
+		if _, err := os.Stat(path); file.optional && os.IsNotExist(err) {
+			continue
+		}
 		data, err := RawCredentialFileFromPath(path, file.defaultValue)
 		if err != nil {
 			return nil, fmt.Errorf("Error reading credential file %s: %v", path, err)
@@ -303,6 +572,13 @@ func ReadRawAssets(dirname string, manageCertificates bool) (*RawAssetsOnDisk, e
 		*file.data = *data
 	}
 
+	r.WorkerNodePoolCerts = map[string]RawCredentialOnDisk{}
+	r.WorkerNodePoolKeys = map[string]RawCredentialOnDisk{}
+	for i, pool := range nodePools {
+		r.WorkerNodePoolCerts[pool] = nodePoolCerts[i]
+		r.WorkerNodePoolKeys[pool] = nodePoolKeys[i]
+	}
+
 	return r, nil
 }
 
@@ -315,37 +591,67 @@ func ReadOrEncryptAssets(dirname string, manageCertificates bool, encryptor Cach
 
 	r := new(EncryptedAssetsOnDisk)
 
+	var nodePools []string
+	var nodePoolCerts, nodePoolKeys []EncryptedCredentialOnDisk
+
 	type entry struct {
 		name         string
 		data         *EncryptedCredentialOnDisk
 		defaultValue *string
+		optional     bool
 	}
 
 	files := []entry{
-		{"tokens.csv", &r.AuthTokens, &defaultTokensFile},
-		{"kubelet-tls-bootstrap-token", &r.TLSBootstrapToken, &defaultTLSBootstrapToken},
+		{"tokens.csv", &r.AuthTokens, &defaultTokensFile, false},
+		{"kubelet-tls-bootstrap-token", &r.TLSBootstrapToken, &defaultTLSBootstrapToken, false},
 	}
 
 	if manageCertificates {
 		files = append(files, []entry{
-			{"ca.pem", &r.CACert, nil},
-			{"ca-key.pem", &r.CAKey, nil},
-			{"apiserver.pem", &r.APIServerCert, nil},
-			{"apiserver-key.pem", &r.APIServerKey, nil},
-			{"worker.pem", &r.WorkerCert, nil},
-			{"worker-key.pem", &r.WorkerKey, nil},
-			{"admin.pem", &r.AdminCert, nil},
-			{"admin-key.pem", &r.AdminKey, nil},
-			{"etcd.pem", &r.EtcdCert, nil},
-			{"etcd-key.pem", &r.EtcdKey, nil},
-			{"etcd-client.pem", &r.EtcdClientCert, nil},
-			{"etcd-client-key.pem", &r.EtcdClientKey, nil},
+			{"ca.pem", &r.CACert, nil, false},
+			{"ca-key.pem", &r.CAKey, nil, true},
+			{"apiserver.pem", &r.APIServerCert, nil, false},
+			{"apiserver-key.pem", &r.APIServerKey, nil, false},
+			{"worker.pem", &r.WorkerCert, nil, false},
+			{"worker-key.pem", &r.WorkerKey, nil, false},
+			{"admin.pem", &r.AdminCert, nil, false},
+			{"admin-key.pem", &r.AdminKey, nil, false},
+			{"etcd.pem", &r.EtcdCert, nil, false},
+			{"etcd-key.pem", &r.EtcdKey, nil, false},
+			{"etcd-client.pem", &r.EtcdClientCert, nil, false},
+			{"etcd-client-key.pem", &r.EtcdClientKey, nil, false},
+			// Clusters rendered before the dedicated service account key was introduced sign tokens with apiserver-key.pem
+			{serviceAccountKeyFileName, &r.ServiceAccountKey, nil, true},
+			{serviceAccountPublicKeysFileName, &r.ServiceAccountPublicKeys, nil, true},
 		}...)
+
+		n := countEtcdNodeCredentials(dirname)
+		r.EtcdNodeCerts = make([]EncryptedCredentialOnDisk, n)
+		r.EtcdNodeKeys = make([]EncryptedCredentialOnDisk, n)
+		for i := 0; i < n; i++ {
+			files = append(files, []entry{
+				{etcdNodeCertFileName(i), &r.EtcdNodeCerts[i], nil, false},
+				{etcdNodeKeyFileName(i), &r.EtcdNodeKeys[i], nil, false},
+			}...)
+		}
+
+		nodePools = nodePoolsWithCredentials(dirname)
+		nodePoolCerts = make([]EncryptedCredentialOnDisk, len(nodePools))
+		nodePoolKeys = make([]EncryptedCredentialOnDisk, len(nodePools))
+		for i, pool := range nodePools {
+			files = append(files, []entry{
+				{nodePoolWorkerCertFileName(pool), &nodePoolCerts[i], nil, false},
+				{nodePoolWorkerKeyFileName(pool), &nodePoolKeys[i], nil, false},
+			}...)
+		}
 	}
 
 	for _, file := range files {
 		path := filepath.Join(dirname, file.name)
-		data, err := encryptor.EncryptedCredentialFromPath(path, file.defaultValue)
+		if _, err := os.Stat(path); file.optional && os.IsNotExist(err) {
+			continue
+		}
+		data, err := encryptor.EncryptedCredentialFromPath(dirname, file.name, file.defaultValue)
 		if err != nil {
 			return nil, fmt.Errorf("Error encrypting %s: %v", path, err)
 		}
@@ -356,14 +662,22 @@ func ReadOrEncryptAssets(dirname string, manageCertificates bool, encryptor Cach
 		}
 	}
 
+	r.WorkerNodePoolCerts = map[string]EncryptedCredentialOnDisk{}
+	r.WorkerNodePoolKeys = map[string]EncryptedCredentialOnDisk{}
+	for i, pool := range nodePools {
+		r.WorkerNodePoolCerts[pool] = nodePoolCerts[i]
+		r.WorkerNodePoolKeys[pool] = nodePoolKeys[i]
+	}
+
 	return r, nil
 }
 
 func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool) error {
-	assets := []struct {
+	type asset struct {
 		name string
 		data []byte
-	}{
+	}
+	assets := []asset{
 		{"ca.pem", r.CACert},
 		{"ca-key.pem", r.CAKey},
 		{"apiserver.pem", r.APIServerCert},
@@ -376,14 +690,31 @@ func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool) error
 		{"etcd-key.pem", r.EtcdKey},
 		{"etcd-client.pem", r.EtcdClientCert},
 		{"etcd-client-key.pem", r.EtcdClientKey},
+		{serviceAccountKeyFileName, r.ServiceAccountKey},
+		{serviceAccountPublicKeysFileName, r.ServiceAccountPublicKeys},
 
 		{"tokens.csv", r.AuthTokens},
 		{"kubelet-tls-bootstrap-token", r.TLSBootstrapToken},
 	}
+	for i := range r.EtcdNodeCerts {
+		assets = append(assets, asset{etcdNodeCertFileName(i), r.EtcdNodeCerts[i]}, asset{etcdNodeKeyFileName(i), r.EtcdNodeKeys[i]})
+	}
+	for pool := range r.WorkerNodePoolCerts {
+		assets = append(assets, asset{nodePoolWorkerCertFileName(pool), r.WorkerNodePoolCerts[pool]}, asset{nodePoolWorkerKeyFileName(pool), r.WorkerNodePoolKeys[pool]})
+	}
 	for _, asset := range assets {
 		path := filepath.Join(dirname, asset.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+
+		// There are no previous service account keys to publish until the signing key is rotated
+		if asset.name == serviceAccountPublicKeysFileName && len(asset.data) == 0 {
+			continue
+		}
 
-		if asset.name != "ca-key.pem" || includeCAKey {
+		// The CA key isn't available when certificates are issued by an external PKI
+		if asset.name != "ca-key.pem" || includeCAKey && len(asset.data) > 0 {
 			if err := ioutil.WriteFile(path, asset.data, 0600); err != nil {
 				return err
 			}
@@ -393,10 +724,11 @@ func (r *RawAssetsOnMemory) WriteToDir(dirname string, includeCAKey bool) error
 }
 
 func (r *EncryptedAssetsOnDisk) WriteToDir(dirname string) error {
-	assets := []struct {
+	type asset struct {
 		name string
 		data EncryptedCredentialOnDisk
-	}{
+	}
+	assets := []asset{
 		{"ca.pem", r.CACert},
 		{"ca-key.pem", r.CAKey},
 		{"apiserver.pem", r.APIServerCert},
@@ -409,12 +741,21 @@ func (r *EncryptedAssetsOnDisk) WriteToDir(dirname string) error {
 		{"etcd-key.pem", r.EtcdKey},
 		{"etcd-client.pem", r.EtcdClientCert},
 		{"etcd-client-key.pem", r.EtcdClientKey},
+		{serviceAccountKeyFileName, r.ServiceAccountKey},
+		{serviceAccountPublicKeysFileName, r.ServiceAccountPublicKeys},
 
 		{"tokens.csv", r.AuthTokens},
 		{"kubelet-tls-bootstrap-token", r.TLSBootstrapToken},
 	}
+	for i := range r.EtcdNodeCerts {
+		assets = append(assets, asset{etcdNodeCertFileName(i), r.EtcdNodeCerts[i]}, asset{etcdNodeKeyFileName(i), r.EtcdNodeKeys[i]})
+	}
+	for pool := range r.WorkerNodePoolCerts {
+		assets = append(assets, asset{nodePoolWorkerCertFileName(pool), r.WorkerNodePoolCerts[pool]}, asset{nodePoolWorkerKeyFileName(pool), r.WorkerNodePoolKeys[pool]})
+	}
 	for _, asset := range assets {
-		if asset.name != "ca-key.pem" {
+		// Optional assets missing in the directory have nothing to persist
+		if asset.name != "ca-key.pem" && asset.data.filePath != "" {
 			if err := asset.data.Persist(); err != nil {
 				return err
 			}
@@ -454,9 +795,22 @@ func (r *RawAssetsOnDisk) Compact() (*CompactAssets, error) {
 		EtcdClientKey:  compact(r.EtcdClientKey),
 		EtcdKey:        compact(r.EtcdKey),
 
+		ServiceAccountKey:        compact(r.ServiceAccountKey),
+		ServiceAccountPublicKeys: compact(r.ServiceAccountPublicKeys),
+
 		AuthTokens:        compact(r.AuthTokens),
 		TLSBootstrapToken: compact(r.TLSBootstrapToken),
 	}
+	for i := range r.EtcdNodeCerts {
+		compactAssets.EtcdNodeCerts = append(compactAssets.EtcdNodeCerts, compact(r.EtcdNodeCerts[i]))
+		compactAssets.EtcdNodeKeys = append(compactAssets.EtcdNodeKeys, compact(r.EtcdNodeKeys[i]))
+	}
+	compactAssets.WorkerNodePoolCerts = map[string]string{}
+	compactAssets.WorkerNodePoolKeys = map[string]string{}
+	for pool := range r.WorkerNodePoolCerts {
+		compactAssets.WorkerNodePoolCerts[pool] = compact(r.WorkerNodePoolCerts[pool])
+		compactAssets.WorkerNodePoolKeys[pool] = compact(r.WorkerNodePoolKeys[pool])
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -495,37 +849,39 @@ func (r *EncryptedAssetsOnDisk) Compact() (*CompactAssets, error) {
 		EtcdClientKey:  compact(r.EtcdClientKey),
 		EtcdKey:        compact(r.EtcdKey),
 
+		ServiceAccountKey:        compact(r.ServiceAccountKey),
+		ServiceAccountPublicKeys: compact(r.ServiceAccountPublicKeys),
+
 		AuthTokens:        compact(r.AuthTokens),
 		TLSBootstrapToken: compact(r.TLSBootstrapToken),
 	}
+	for i := range r.EtcdNodeCerts {
+		compactAssets.EtcdNodeCerts = append(compactAssets.EtcdNodeCerts, compact(r.EtcdNodeCerts[i]))
+		compactAssets.EtcdNodeKeys = append(compactAssets.EtcdNodeKeys, compact(r.EtcdNodeKeys[i]))
+	}
+	compactAssets.WorkerNodePoolCerts = map[string]string{}
+	compactAssets.WorkerNodePoolKeys = map[string]string{}
+	for pool := range r.WorkerNodePoolCerts {
+		compactAssets.WorkerNodePoolCerts[pool] = compact(r.WorkerNodePoolCerts[pool])
+		compactAssets.WorkerNodePoolKeys[pool] = compact(r.WorkerNodePoolKeys[pool])
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &compactAssets, nil
 }
 
-type KMSConfig struct {
+type EncryptionConfig struct {
 	Region         model.Region
 	EncryptService EncryptService
 	KMSKeyARN      string
+	SecretBackend  model.SecretBackend
 }
 
-func ReadOrCreateEncryptedAssets(tlsAssetsDir string, manageCertificates bool, kmsConfig KMSConfig) (*EncryptedAssetsOnDisk, error) {
-	var kmsSvc EncryptService
-
-	// TODO Cleaner way to inject this dependency
-	if kmsConfig.EncryptService == nil {
-		awsConfig := aws.NewConfig().
-			WithRegion(kmsConfig.Region.String()).
-			WithCredentialsChainVerboseErrors(true)
-		kmsSvc = kms.New(session.New(awsConfig))
-	} else {
-		kmsSvc = kmsConfig.EncryptService
-	}
-
-	encryptionSvc := bytesEncryptionService{
-		kmsKeyARN: kmsConfig.KMSKeyARN,
-		kmsSvc:    kmsSvc,
+func ReadOrCreateEncryptedAssets(tlsAssetsDir string, manageCertificates bool, encryptionConfig EncryptionConfig) (*EncryptedAssetsOnDisk, error) {
+	encryptionSvc, err := newBytesEncryptionService(encryptionConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	encryptor := CachedEncryptor{
@@ -535,8 +891,8 @@ func ReadOrCreateEncryptedAssets(tlsAssetsDir string, manageCertificates bool, k
 	return ReadOrEncryptAssets(tlsAssetsDir, manageCertificates, encryptor)
 }
 
-func ReadOrCreateCompactAssets(assetsDir string, manageCertificates bool, kmsConfig KMSConfig) (*CompactAssets, error) {
-	encryptedAssets, err := ReadOrCreateEncryptedAssets(assetsDir, manageCertificates, kmsConfig)
+func ReadOrCreateCompactAssets(assetsDir string, manageCertificates bool, encryptionConfig EncryptionConfig) (*CompactAssets, error) {
+	encryptedAssets, err := ReadOrCreateEncryptedAssets(assetsDir, manageCertificates, encryptionConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read/create encrypted assets: %v", err)
 	}
@@ -579,3 +935,11 @@ func (a *CompactAssets) HasAuthTokens() bool {
 func (a *CompactAssets) HasTLSBootstrapToken() bool {
 	return len(a.TLSBootstrapToken) > 0
 }
+
+func (a *CompactAssets) HasServiceAccountKey() bool {
+	return len(a.ServiceAccountKey) > 0
+}
+
+func (a *CompactAssets) HasServiceAccountPublicKeys() bool {
+	return len(a.ServiceAccountPublicKeys) > 0
+}
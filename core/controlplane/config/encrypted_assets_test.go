@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/test/helper"
+	"github.com/kubernetes-incubator/kube-aws/tlsutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -26,7 +27,7 @@ func genAssets(t *testing.T) *RawAssetsOnMemory {
 	if err != nil {
 		t.Fatalf("failed generating tls ca: %v", err)
 	}
-	assets, err := cluster.NewAssetsOnMemory(caKey, caCert)
+	assets, err := cluster.NewAssetsOnMemory(tlsutil.NewLocalIssuer(caKey, caCert))
 	if err != nil {
 		t.Fatalf("failed generating assets: %v", err)
 	}
@@ -111,9 +112,197 @@ func TestTLSGeneration(t *testing.T) {
 	}
 }
 
+func TestEtcdNodeCredentials(t *testing.T) {
+	cluster, err := ClusterFromBytes([]byte(singleAzConfigYaml + `
+etcd:
+  count: 2
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  perNodeCertificates: true
+`))
+	if err != nil {
+		t.Fatalf("failed generating config: %v", err)
+	}
+
+	caKey, caCert, err := cluster.NewTLSCA()
+	if err != nil {
+		t.Fatalf("failed generating tls ca: %v", err)
+	}
+	assets, err := cluster.NewAssetsOnMemory(tlsutil.NewLocalIssuer(caKey, caCert))
+	if err != nil {
+		t.Fatalf("failed generating assets: %v", err)
+	}
+
+	if len(assets.EtcdNodeCerts) != 2 || len(assets.EtcdNodeKeys) != 2 {
+		t.Fatalf("expected credentials for 2 etcd nodes but got %d certs and %d keys", len(assets.EtcdNodeCerts), len(assets.EtcdNodeKeys))
+	}
+
+	for i, certBytes := range assets.EtcdNodeCerts {
+		certBlock, _ := pem.Decode(certBytes)
+		if certBlock == nil {
+			t.Fatalf("failed decoding pem block of the certificate for etcd node %d", i)
+		}
+		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			t.Fatalf("failed to parse the certificate for etcd node %d: %v", i, err)
+		}
+		expected := []string{fmt.Sprintf("etcd%d.internal.example.com", i)}
+		if !reflect.DeepEqual(cert.DNSNames, expected) {
+			t.Errorf("unexpected SANs in the certificate for etcd node %d: expected=%v, got=%v", i, expected, cert.DNSNames)
+		}
+		if err := cert.CheckSignatureFrom(caCert); err != nil {
+			t.Errorf("could not verify the certificate for etcd node %d: %v", i, err)
+		}
+	}
+
+	helper.WithTempDir(func(dir string) {
+		if err := assets.WriteToDir(dir, true); err != nil {
+			t.Fatalf("failed to write assets: %v", err)
+		}
+
+		compact, err := ReadOrCreateUnencryptedCompactAssets(dir, true)
+		if err != nil {
+			t.Fatalf("failed to read assets: %v", err)
+		}
+
+		if len(compact.EtcdNodeCerts) != 2 || len(compact.EtcdNodeKeys) != 2 {
+			t.Errorf("expected credentials for 2 etcd nodes to be read but got %d certs and %d keys", len(compact.EtcdNodeCerts), len(compact.EtcdNodeKeys))
+		}
+		if compact.EtcdNodeCerts[0] == compact.EtcdNodeCerts[1] || compact.EtcdNodeKeys[0] == compact.EtcdNodeKeys[1] {
+			t.Errorf("expected each etcd node to have its own credentials")
+		}
+	})
+}
+
+func TestNodePoolWorkerCredentials(t *testing.T) {
+	cluster, err := ClusterFromBytes([]byte(singleAzConfigYaml + `
+kubeletIdentity: nodePool
+experimental:
+  plugins:
+    rbac:
+      enabled: true
+`))
+	if err != nil {
+		t.Fatalf("failed generating config: %v", err)
+	}
+	cluster.NodePoolNames = []string{"pool1", "pool2"}
+
+	caKey, caCert, err := cluster.NewTLSCA()
+	if err != nil {
+		t.Fatalf("failed generating tls ca: %v", err)
+	}
+	assets, err := cluster.NewAssetsOnMemory(tlsutil.NewLocalIssuer(caKey, caCert))
+	if err != nil {
+		t.Fatalf("failed generating assets: %v", err)
+	}
+
+	for _, pool := range cluster.NodePoolNames {
+		certBlock, _ := pem.Decode(assets.WorkerNodePoolCerts[pool])
+		if certBlock == nil {
+			t.Fatalf("failed decoding pem block of the certificate for node pool %s", pool)
+		}
+		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			t.Fatalf("failed to parse the certificate for node pool %s: %v", pool, err)
+		}
+		expected := fmt.Sprintf("kube-worker:%s", pool)
+		if cert.Subject.CommonName != expected {
+			t.Errorf("unexpected CN in the certificate for node pool %s: expected=%s, got=%s", pool, expected, cert.Subject.CommonName)
+		}
+		for _, o := range cert.Subject.Organization {
+			if o == "system:nodes" {
+				t.Errorf("expected the certificate for node pool %s not to belong to the system:nodes group but it did", pool)
+			}
+		}
+	}
+
+	helper.WithTempDir(func(dir string) {
+		if err := assets.WriteToDir(dir, true); err != nil {
+			t.Fatalf("failed to write assets: %v", err)
+		}
+
+		compact, err := ReadOrCreateUnencryptedCompactAssets(dir, true)
+		if err != nil {
+			t.Fatalf("failed to read assets: %v", err)
+		}
+
+		if len(compact.WorkerNodePoolCerts) != 2 || len(compact.WorkerNodePoolKeys) != 2 {
+			t.Errorf("expected credentials for 2 node pools to be read but got %d certs and %d keys", len(compact.WorkerNodePoolCerts), len(compact.WorkerNodePoolKeys))
+		}
+		if compact.WorkerNodePoolCerts["pool1"] == compact.WorkerNodePoolCerts["pool2"] {
+			t.Errorf("expected each node pool to have its own credentials")
+		}
+	})
+}
+
+func TestServiceAccountKeyRotation(t *testing.T) {
+	cluster, err := ClusterFromBytes([]byte(singleAzConfigYaml))
+	if err != nil {
+		t.Fatalf("failed generating config: %v", err)
+	}
+
+	caKey, caCert, err := cluster.NewTLSCA()
+	if err != nil {
+		t.Fatalf("failed generating tls ca: %v", err)
+	}
+	issuer := tlsutil.NewLocalIssuer(caKey, caCert)
+
+	helper.WithTempDir(func(dir string) {
+		first, err := cluster.NewAssetsOnDisk(dir, CredentialsOptions{GenerateCA: true}, issuer)
+		if err != nil {
+			t.Fatalf("failed generating assets: %v", err)
+		}
+		if first.ServiceAccountKey.String() == "" {
+			t.Fatalf("expected a service account key to be generated but it wasn't")
+		}
+		if first.ServiceAccountKey.String() == first.APIServerKey.String() {
+			t.Errorf("expected the service account key to differ from the apiserver key")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "service-account-pub.pem")); !os.IsNotExist(err) {
+			t.Errorf("expected no service-account-pub.pem to be written before rotation: %v", err)
+		}
+
+		second, err := cluster.NewAssetsOnDisk(dir, CredentialsOptions{GenerateCA: true}, issuer)
+		if err != nil {
+			t.Fatalf("failed regenerating assets: %v", err)
+		}
+		if second.ServiceAccountKey.String() != first.ServiceAccountKey.String() {
+			t.Errorf("expected the service account key to be kept when not rotating")
+		}
+
+		rotated, err := cluster.NewAssetsOnDisk(dir, CredentialsOptions{GenerateCA: true, RotateServiceAccountKey: true}, issuer)
+		if err != nil {
+			t.Fatalf("failed rotating the service account key: %v", err)
+		}
+		if rotated.ServiceAccountKey.String() == first.ServiceAccountKey.String() {
+			t.Errorf("expected the service account key to be replaced on rotation")
+		}
+
+		prevKey, err := tlsutil.DecodePrivateKeyPEM([]byte(first.ServiceAccountKey.String()))
+		if err != nil {
+			t.Fatalf("failed decoding the previous service account key: %v", err)
+		}
+		expectedPubKey, err := tlsutil.EncodePublicKeyPEM(&prevKey.PublicKey)
+		if err != nil {
+			t.Fatalf("failed encoding the previous service account public key: %v", err)
+		}
+		if rotated.ServiceAccountPublicKeys.String() != string(expectedPubKey) {
+			t.Errorf("expected the public key of the previous service account key to be kept: got=%s", rotated.ServiceAccountPublicKeys.String())
+		}
+
+		compact, err := ReadOrCreateUnencryptedCompactAssets(dir, true)
+		if err != nil {
+			t.Fatalf("failed to read assets: %v", err)
+		}
+		if !compact.HasServiceAccountKey() || !compact.HasServiceAccountPublicKeys() {
+			t.Errorf("expected both the service account key and public keys to be read")
+		}
+	})
+}
+
 func TestReadOrCreateCompactAssets(t *testing.T) {
 	helper.WithDummyCredentials(func(dir string) {
-		kmsConfig := KMSConfig{
+		encryptionConfig := EncryptionConfig{
 			KMSKeyARN:      "keyarn",
 			Region:         model.RegionForName("us-west-1"),
 			EncryptService: &dummyEncryptService{},
@@ -121,7 +310,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 
 		// See https://github.com/kubernetes-incubator/kube-aws/issues/107
 		t.Run("CachedToPreventUnnecessaryNodeReplacement", func(t *testing.T) {
-			created, err := ReadOrCreateCompactAssets(dir, true, kmsConfig)
+			created, err := ReadOrCreateCompactAssets(dir, true, encryptionConfig)
 
 			if err != nil {
 				t.Errorf("failed to read or update compact assets in %s : %v", dir, err)
@@ -130,7 +319,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 			// This depends on TestDummyEncryptService which ensures dummy encrypt service to produce different ciphertext for each encryption
 			// created == read means that encrypted assets were loaded from cached files named *.pem.enc, instead of re-encrypting raw assets named *.pem files
 			// TODO Use some kind of mocking framework for tests like this
-			read, err := ReadOrCreateCompactAssets(dir, true, kmsConfig)
+			read, err := ReadOrCreateCompactAssets(dir, true, encryptionConfig)
 
 			if err != nil {
 				t.Errorf("failed to read or update compact assets in %s : %v", dir, err)
@@ -145,7 +334,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 		})
 
 		t.Run("RemoveFilesToRegenerate", func(t *testing.T) {
-			original, err := ReadOrCreateCompactAssets(dir, true, kmsConfig)
+			original, err := ReadOrCreateCompactAssets(dir, true, encryptionConfig)
 
 			if err != nil {
 				t.Errorf("failed to read the original encrypted assets : %v", err)
@@ -164,7 +353,7 @@ func TestReadOrCreateCompactAssets(t *testing.T) {
 				}
 			}
 
-			regenerated, err := ReadOrCreateCompactAssets(dir, true, kmsConfig)
+			regenerated, err := ReadOrCreateCompactAssets(dir, true, encryptionConfig)
 
 			if err != nil {
 				t.Errorf("failed to read the regenerated encrypted assets : %v", err)
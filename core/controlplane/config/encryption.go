@@ -9,12 +9,19 @@ type EncryptService interface {
 	Encrypt(*kms.EncryptInput) (*kms.EncryptOutput, error)
 }
 
-type bytesEncryptionService struct {
+// BytesEncryptionService turns a plaintext credential into the bytes embedded into userdata, from which only nodes are able
+// to recover the plaintext by using the method provided by the secret backend.
+// `name` is the file name of the credential unique in the cluster e.g. `apiserver-key.pem`
+type BytesEncryptionService interface {
+	Encrypt(name string, data []byte) ([]byte, error)
+}
+
+type kmsEncryptionService struct {
 	kmsKeyARN string
 	kmsSvc    EncryptService
 }
 
-func (s bytesEncryptionService) Encrypt(data []byte) ([]byte, error) {
+func (s kmsEncryptionService) Encrypt(name string, data []byte) ([]byte, error) {
 	if len(data) == 0 {
 		return []byte{}, nil
 	}
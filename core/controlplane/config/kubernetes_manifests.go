@@ -0,0 +1,254 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kubernetes-incubator/kube-aws/node"
+	"github.com/kubernetes-incubator/kube-aws/plugin/clusterextension"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// PluginLabel is the label put on every kubernetes object created from a manifest contributed by a plugin.
+	// Its value is the name of the plugin, which is used to prune objects created by plugins which are no longer enabled
+	PluginLabel = "kube-aws.coreos.com/plugin"
+
+	// KubernetesManifestsAppliedConfigMap is the configmap in kube-system created once any plugin has applied kubernetes manifests.
+	// Objects created by plugins are never pruned until then
+	KubernetesManifestsAppliedConfigMap = "kube-aws-plugins"
+
+	kubernetesManifestsDir = "/srv/kube-aws/plugins"
+
+	defaultKubernetesManifestWaitTimeoutSeconds = 300
+)
+
+// prunedKinds is the list of kinds of kubernetes objects pruned once they become unnecessary.
+// Kinds not served by the apiserver are skipped while pruning
+var prunedKinds = []string{
+	"deployments",
+	"daemonsets",
+	"statefulsets",
+	"jobs",
+	"services",
+	"ingresses",
+	"configmaps",
+	"secrets",
+	"serviceaccounts",
+	"roles",
+	"rolebindings",
+	"clusterroles",
+	"clusterrolebindings",
+	"networkpolicies",
+	"podsecuritypolicies",
+	"customresourcedefinitions",
+}
+
+var yamlDocumentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+type kubernetesManifestPlugin struct {
+	Manifests []pluggedInKubernetesManifest
+	// plugins is the sorted names of the plugins contributing the manifests
+	plugins []string
+	// podSecurityPoliciesServed is true when the apiserver serves podsecuritypolicies, which requires the PodSecurityPolicy admission controller
+	podSecurityPoliciesServed bool
+}
+
+type pluggedInKubernetesManifest struct {
+	ManifestFile node.UploadedFile
+	Weight       int
+	// Waits are the Deployments and DaemonSets defined in the manifest to be waited for until they become ready
+	Waits []kubernetesManifestWait
+}
+
+type kubernetesManifestWait struct {
+	Namespace string
+	// Resource is the type and name of a kubernetes object in the form of `<type>/<name>` e.g. `deployment/foo`
+	Resource       string
+	TimeoutSeconds int
+}
+
+// kubernetesManifestPhase is a set of manifests sharing the same weight, which are applied at once
+type kubernetesManifestPhase struct {
+	Weight    int
+	Manifests []pluggedInKubernetesManifest
+}
+
+func (p kubernetesManifestPlugin) Directory() string {
+	return kubernetesManifestsDir
+}
+
+// Phases returns manifests grouped by their weights in ascending order
+func (p kubernetesManifestPlugin) Phases() []kubernetesManifestPhase {
+	phases := []kubernetesManifestPhase{}
+	for _, m := range p.Manifests {
+		if len(phases) == 0 || phases[len(phases)-1].Weight != m.Weight {
+			phases = append(phases, kubernetesManifestPhase{Weight: m.Weight})
+		}
+		last := &phases[len(phases)-1]
+		last.Manifests = append(last.Manifests, m)
+	}
+	return phases
+}
+
+// PruneSelector returns the label selector matching kubernetes objects created by plugins which no longer contribute manifests
+func (p kubernetesManifestPlugin) PruneSelector() string {
+	if len(p.plugins) == 0 {
+		return PluginLabel
+	}
+	return fmt.Sprintf("%s,%s notin (%s)", PluginLabel, PluginLabel, strings.Join(p.plugins, ","))
+}
+
+// PrunedKinds returns the kinds of kubernetes objects to be pruned, excluding ones the apiserver is known not to serve
+func (p kubernetesManifestPlugin) PrunedKinds() []string {
+	kinds := []string{}
+	for _, k := range prunedKinds {
+		if k == "podsecuritypolicies" && !p.podSecurityPoliciesServed {
+			continue
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds
+}
+
+// AppliedConfigMap returns the name of the configmap in kube-system recording that plugins have applied kubernetes manifests
+func (p kubernetesManifestPlugin) AppliedConfigMap() string {
+	return KubernetesManifestsAppliedConfigMap
+}
+
+func newKubernetesManifestPlugin(manifests []clusterextension.KubernetesManifest) (kubernetesManifestPlugin, error) {
+	sorted := make([]clusterextension.KubernetesManifest, len(manifests))
+	copy(sorted, manifests)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Weight != b.Weight {
+			return a.Weight < b.Weight
+		}
+		if a.PluginName != b.PluginName {
+			return a.PluginName < b.PluginName
+		}
+		return a.Name < b.Name
+	})
+
+	p := kubernetesManifestPlugin{
+		Manifests: []pluggedInKubernetesManifest{},
+		plugins:   []string{},
+	}
+	seen := map[string]bool{}
+
+	for _, m := range sorted {
+		timeout := 0
+		if m.Wait.Enabled {
+			timeout = m.Wait.TimeoutSeconds
+			if timeout == 0 {
+				timeout = defaultKubernetesManifestWaitTimeoutSeconds
+			}
+		}
+
+		contents, waits, err := labelKubernetesManifest(m.Contents, m.PluginName, timeout)
+		if err != nil {
+			return p, fmt.Errorf("invalid kubernetes manifest %s of plugin %s: %v", m.Name, m.PluginName, err)
+		}
+
+		p.Manifests = append(p.Manifests, pluggedInKubernetesManifest{
+			ManifestFile: node.UploadedFile{
+				Path:    filepath.Join(kubernetesManifestsDir, m.PluginName, m.Name),
+				Content: node.NewUploadedFileContent([]byte(contents)),
+			},
+			Weight: m.Weight,
+			Waits:  waits,
+		})
+
+		if !seen[m.PluginName] {
+			seen[m.PluginName] = true
+			p.plugins = append(p.plugins, m.PluginName)
+		}
+	}
+	sort.Strings(p.plugins)
+
+	return p, nil
+}
+
+// labelKubernetesManifest puts the plugin label on every object in the possibly multi-document manifest.
+// It also returns the Deployments and DaemonSets to be waited for when `timeout` is greater than zero
+func labelKubernetesManifest(contents string, pluginName string, timeout int) (string, []kubernetesManifestWait, error) {
+	docs := []string{}
+	waits := []kubernetesManifestWait{}
+
+	for i, d := range yamlDocumentSeparator.Split(contents, -1) {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(d), &obj); err != nil {
+			return "", nil, fmt.Errorf("failed to parse document %d: %v", i, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		objs := []map[string]interface{}{obj}
+		if items, ok := obj["items"].([]interface{}); ok && strings.HasSuffix(fmt.Sprint(obj["kind"]), "List") {
+			objs = []map[string]interface{}{}
+			for _, item := range items {
+				o, ok := stringKeyedMap(item)
+				if !ok {
+					return "", nil, fmt.Errorf("unexpected item in document %d: %v", i, item)
+				}
+				objs = append(objs, o)
+			}
+			obj["items"] = objs
+		}
+
+		for _, o := range objs {
+			metadata, ok := stringKeyedMap(o["metadata"])
+			if !ok {
+				metadata = map[string]interface{}{}
+			}
+			labels, ok := stringKeyedMap(metadata["labels"])
+			if !ok {
+				labels = map[string]interface{}{}
+			}
+			labels[PluginLabel] = pluginName
+			metadata["labels"] = labels
+			o["metadata"] = metadata
+
+			if timeout > 0 {
+				switch kind := fmt.Sprint(o["kind"]); kind {
+				case "Deployment", "DaemonSet":
+					ns, _ := metadata["namespace"].(string)
+					if ns == "" {
+						ns = "default"
+					}
+					waits = append(waits, kubernetesManifestWait{
+						Namespace:      ns,
+						Resource:       fmt.Sprintf("%s/%v", strings.ToLower(kind), metadata["name"]),
+						TimeoutSeconds: timeout,
+					})
+				}
+			}
+		}
+
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal document %d: %v", i, err)
+		}
+		docs = append(docs, string(out))
+	}
+
+	return strings.Join(docs, "---\n"), waits, nil
+}
+
+func stringKeyedMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		r := map[string]interface{}{}
+		for k, v := range m {
+			r[fmt.Sprint(k)] = v
+		}
+		return r, true
+	}
+	return nil, false
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/plugin/clusterextension"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+)
+
+func TestKubernetesManifestPlugin(t *testing.T) {
+	manifests := []clusterextension.KubernetesManifest{
+		{
+			PluginName: "b-plugin",
+			Name:       "app.yaml",
+			Weight:     10,
+			Wait:       pluginmodel.KubernetesManifestWait{Enabled: true, TimeoutSeconds: 60},
+			Contents: `kind: Deployment
+metadata:
+  name: app
+  namespace: kube-system
+---
+kind: DaemonSet
+metadata:
+  name: agent
+  labels:
+    app: agent
+`,
+		},
+		{
+			PluginName: "b-plugin",
+			Name:       "config.yaml",
+			Contents:   "kind: ConfigMap\nmetadata:\n  name: config\n",
+		},
+		{
+			PluginName: "a-plugin",
+			Name:       "list.yaml",
+			Weight:     10,
+			Contents: `kind: List
+items:
+- kind: Deployment
+  metadata:
+    name: a
+`,
+		},
+	}
+
+	p, err := newKubernetesManifestPlugin(manifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	paths := []string{}
+	for _, m := range p.Manifests {
+		paths = append(paths, m.ManifestFile.Path)
+	}
+	expectedPaths := []string{
+		"/srv/kube-aws/plugins/b-plugin/config.yaml",
+		"/srv/kube-aws/plugins/a-plugin/list.yaml",
+		"/srv/kube-aws/plugins/b-plugin/app.yaml",
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("unexpected order of manifests: expected %v but was %v", expectedPaths, paths)
+	}
+
+	phases := p.Phases()
+	if len(phases) != 2 || phases[0].Weight != 0 || phases[1].Weight != 10 || len(phases[1].Manifests) != 2 {
+		t.Errorf("unexpected phases: %+v", phases)
+	}
+
+	if len(p.Manifests[1].Waits) != 0 {
+		t.Errorf("expected no waits for a manifest without wait enabled but was %+v", p.Manifests[1].Waits)
+	}
+
+	expectedWaits := []kubernetesManifestWait{
+		{Namespace: "kube-system", Resource: "deployment/app", TimeoutSeconds: 60},
+		{Namespace: "default", Resource: "daemonset/agent", TimeoutSeconds: 60},
+	}
+	if !reflect.DeepEqual(p.Manifests[2].Waits, expectedWaits) {
+		t.Errorf("unexpected waits: expected %+v but was %+v", expectedWaits, p.Manifests[2].Waits)
+	}
+
+	app := p.Manifests[2].ManifestFile.Content.String()
+	if strings.Count(app, "kube-aws.coreos.com/plugin: b-plugin") != 2 || !strings.Contains(app, "app: agent") || !strings.Contains(app, "---\n") {
+		t.Errorf("unexpected labeled manifest: %s", app)
+	}
+
+	list := p.Manifests[1].ManifestFile.Content.String()
+	if !strings.Contains(list, "kube-aws.coreos.com/plugin: a-plugin") {
+		t.Errorf("missing label in items of a list: %s", list)
+	}
+
+	if s := p.PruneSelector(); s != "kube-aws.coreos.com/plugin,kube-aws.coreos.com/plugin notin (a-plugin,b-plugin)" {
+		t.Errorf("unexpected prune selector: %s", s)
+	}
+
+	empty, err := newKubernetesManifestPlugin(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := empty.PruneSelector(); s != "kube-aws.coreos.com/plugin" {
+		t.Errorf("unexpected prune selector without manifests: %s", s)
+	}
+
+	for _, k := range p.PrunedKinds() {
+		if k == "podsecuritypolicies" {
+			t.Errorf("expected podsecuritypolicies not to be pruned without the PodSecurityPolicy admission controller")
+		}
+	}
+	p.podSecurityPoliciesServed = true
+	if kinds := p.PrunedKinds(); kinds[len(kinds)-2] != "podsecuritypolicies" {
+		t.Errorf("expected podsecuritypolicies to be pruned with the PodSecurityPolicy admission controller but was %v", kinds)
+	}
+
+	_, err = newKubernetesManifestPlugin([]clusterextension.KubernetesManifest{{PluginName: "c", Name: "broken.yaml", Contents: "kind: [\n"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid kubernetes manifest broken.yaml of plugin c") {
+		t.Errorf("expected an error for a broken manifest but was: %v", err)
+	}
+}
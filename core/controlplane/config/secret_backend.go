@@ -0,0 +1,396 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/kubernetes-incubator/kube-aws/model"
+)
+
+type SSMParameterService interface {
+	PutParameter(*ssm.PutParameterInput) (*ssm.PutParameterOutput, error)
+	DeleteParameter(*ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error)
+}
+
+// ssmEncryptionService stores each credential as a SecureString parameter named after the credential and returns
+// the parameter name suffixed with a random revision as the "ciphertext" so that nodes can fetch it via `aws ssm get-parameter --with-decryption`.
+// The parameter is overwritten whenever the credential changes, while the revision still changes userdata so that nodes are replaced.
+// The revision isn't a parameter version because the vendored aws-sdk-go predates parameter versions
+type ssmEncryptionService struct {
+	parameterPrefix string
+	kmsKeyARN       string
+	ssmSvc          SSMParameterService
+}
+
+func (s ssmEncryptionService) Encrypt(name string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+
+	paramName := path.Join(s.parameterPrefix, name)
+	input := ssm.PutParameterInput{
+		Name:      aws.String(paramName),
+		Type:      aws.String(ssm.ParameterTypeSecureString),
+		Value:     aws.String(string(data)),
+		KeyId:     aws.String(s.kmsKeyARN),
+		Overwrite: aws.Bool(true),
+	}
+	if _, err := s.ssmSvc.PutParameter(&input); err != nil {
+		return []byte{}, fmt.Errorf("failed to put ssm parameter %s: %v", paramName, err)
+	}
+	revision, err := randomRevision()
+	if err != nil {
+		return []byte{}, err
+	}
+	return []byte(fmt.Sprintf("%s:%s", paramName, revision)), nil
+}
+
+func (s ssmEncryptionService) Delete(ref string) error {
+	name := secretNameFromRef(ref)
+	_, err := s.ssmSvc.DeleteParameter(&ssm.DeleteParameterInput{Name: aws.String(name)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete ssm parameter %s: %v", name, err)
+	}
+	return nil
+}
+
+type SecretsManagerService interface {
+	// PutSecret creates the secret or stores a new version of it when it already exists, and returns the id of the version
+	PutSecret(name string, kmsKeyARN string, secretString string) (string, error)
+	DeleteSecret(name string) error
+}
+
+// secretsManagerEncryptionService stores each credential as a secret named after the credential and returns the secret name
+// suffixed with the version id as the "ciphertext" so that nodes can fetch it via `aws secretsmanager get-secret-value`
+type secretsManagerEncryptionService struct {
+	secretPrefix      string
+	kmsKeyARN         string
+	secretsManagerSvc SecretsManagerService
+}
+
+func (s secretsManagerEncryptionService) Encrypt(name string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+
+	secretName := path.Join(s.secretPrefix, name)
+	versionID, err := s.secretsManagerSvc.PutSecret(secretName, s.kmsKeyARN, string(data))
+	if err != nil {
+		return []byte{}, fmt.Errorf("failed to put secret %s: %v", secretName, err)
+	}
+	return []byte(fmt.Sprintf("%s:%s", secretName, versionID)), nil
+}
+
+func (s secretsManagerEncryptionService) Delete(ref string) error {
+	name := secretNameFromRef(ref)
+	if err := s.secretsManagerSvc.DeleteSecret(name); err != nil {
+		return fmt.Errorf("failed to delete secret %s: %v", name, err)
+	}
+	return nil
+}
+
+func randomRevision() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// secretNameFromRef returns the name of the parameter or the secret referenced by `<name>:<revision>`.
+// Neither SSM parameter names nor secret names are allowed to contain colons
+func secretNameFromRef(ref string) string {
+	return strings.SplitN(strings.TrimSpace(ref), ":", 2)[0]
+}
+
+// secretsManagerClient is a minimal AWS Secrets Manager client supporting only what kube-aws needs,
+// as the vendored aws-sdk-go predates the service.
+type secretsManagerClient struct {
+	*client.Client
+}
+
+type createSecretInput struct {
+	_ struct{} `type:"structure"`
+
+	Name         *string `type:"string"`
+	KmsKeyId     *string `type:"string"`
+	SecretString *string `type:"string"`
+}
+
+type putSecretValueInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId     *string `type:"string"`
+	SecretString *string `type:"string"`
+}
+
+type deleteSecretInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId                   *string `type:"string"`
+	ForceDeleteWithoutRecovery *bool   `type:"boolean"`
+}
+
+type secretVersionOutput struct {
+	_ struct{} `type:"structure"`
+
+	ARN       *string `type:"string"`
+	Name      *string `type:"string"`
+	VersionId *string `type:"string"`
+}
+
+func newSecretsManagerClient(p client.ConfigProvider, cfgs ...*aws.Config) *secretsManagerClient {
+	c := p.ClientConfig("secretsmanager", cfgs...)
+	svc := &secretsManagerClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   "secretsmanager",
+				SigningName:   c.SigningName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2017-10-17",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "secretsmanager",
+			},
+			c.Handlers,
+		),
+	}
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+	return svc
+}
+
+func (c *secretsManagerClient) call(name string, input interface{}, output interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return c.NewRequest(op, input, output).Send()
+}
+
+func (c *secretsManagerClient) PutSecret(name string, kmsKeyARN string, secretString string) (string, error) {
+	output := &secretVersionOutput{}
+	err := c.call("CreateSecret", &createSecretInput{
+		Name:         aws.String(name),
+		KmsKeyId:     aws.String(kmsKeyARN),
+		SecretString: aws.String(secretString),
+	}, output)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ResourceExistsException" {
+		err = c.call("PutSecretValue", &putSecretValueInput{
+			SecretId:     aws.String(name),
+			SecretString: aws.String(secretString),
+		}, output)
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.VersionId), nil
+}
+
+func (c *secretsManagerClient) DeleteSecret(name string) error {
+	err := c.call("DeleteSecret", &deleteSecretInput{
+		SecretId:                   aws.String(name),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	}, &secretVersionOutput{})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ResourceNotFoundException" {
+		return nil
+	}
+	return err
+}
+
+// vaultTransitEncryptionService encrypts each credential with a Vault transit key.
+// Nodes decrypt them via `vault write <mount>/decrypt/<key>` after logging in to Vault with the AWS auth method
+type vaultTransitEncryptionService struct {
+	address    string
+	mount      string
+	key        string
+	token      string
+	httpClient *http.Client
+}
+
+func (s vaultTransitEncryptionService) Encrypt(name string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+
+	reqBody, err := json.Marshal(map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(data),
+	})
+	if err != nil {
+		return []byte{}, err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/encrypt/%s", strings.TrimSuffix(s.address, "/"), s.mount, s.key)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return []byte{}, err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return []byte{}, fmt.Errorf("failed to encrypt with vault transit key %s: %v", s.key, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return []byte{}, fmt.Errorf("failed to encrypt with vault transit key %s: unexpected status %s", s.key, res.Status)
+	}
+
+	var resBody struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return []byte{}, fmt.Errorf("failed to parse response from vault: %v", err)
+	}
+	if resBody.Data.Ciphertext == "" {
+		return []byte{}, errors.New("vault returned an empty ciphertext")
+	}
+	return []byte(resBody.Data.Ciphertext), nil
+}
+
+// newBytesEncryptionService returns the encryption service for the secret backend selected in cluster.yaml
+func newBytesEncryptionService(config EncryptionConfig) (BytesEncryptionService, error) {
+	backend := config.SecretBackend
+
+	if backend.IsVault() {
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			return nil, errors.New("VAULT_TOKEN must be set to encrypt credentials with vault")
+		}
+		return vaultTransitEncryptionService{
+			address:    backend.Vault.Address,
+			mount:      backend.Vault.TransitMount,
+			key:        backend.Vault.TransitKey,
+			token:      token,
+			httpClient: http.DefaultClient,
+		}, nil
+	}
+
+	awsConfig := aws.NewConfig().
+		WithRegion(config.Region.String()).
+		WithCredentialsChainVerboseErrors(true)
+
+	switch backend.Type {
+	case model.SecretBackendSSM:
+		return ssmEncryptionService{
+			parameterPrefix: backend.SSM.ParameterPrefix,
+			kmsKeyARN:       config.KMSKeyARN,
+			ssmSvc:          ssm.New(session.New(awsConfig)),
+		}, nil
+	case model.SecretBackendSecretsManager:
+		return secretsManagerEncryptionService{
+			secretPrefix:      backend.SecretsManager.SecretPrefix,
+			kmsKeyARN:         config.KMSKeyARN,
+			secretsManagerSvc: newSecretsManagerClient(session.New(awsConfig)),
+		}, nil
+	}
+
+	var kmsSvc EncryptService
+
+	// TODO Cleaner way to inject this dependency
+	if config.EncryptService == nil {
+		kmsSvc = kms.New(session.New(awsConfig))
+	} else {
+		kmsSvc = config.EncryptService
+	}
+
+	return kmsEncryptionService{
+		kmsKeyARN: config.KMSKeyARN,
+		kmsSvc:    kmsSvc,
+	}, nil
+}
+
+// storedCredentialDeleter is implemented by the encryption services storing credentials outside of userdata
+type storedCredentialDeleter interface {
+	Delete(ref string) error
+}
+
+// DeleteStoredCredentials deletes the SSM parameters or the Secrets Manager secrets referenced from the encrypted credentials
+// cached in assetsDir. It does nothing for the secret backends which embed encrypted credentials into userdata
+func DeleteStoredCredentials(assetsDir string, config EncryptionConfig) error {
+	if !config.SecretBackend.IsSSM() && !config.SecretBackend.IsSecretsManager() {
+		return nil
+	}
+
+	svc, err := newBytesEncryptionService(config)
+	if err != nil {
+		return err
+	}
+	deleter, ok := svc.(storedCredentialDeleter)
+	if !ok {
+		return fmt.Errorf("[bug] %T is unable to delete stored credentials", svc)
+	}
+
+	refs, err := storedCredentialRefs(assetsDir)
+	if err != nil {
+		return err
+	}
+	return deleteStoredCredentials(refs, deleter)
+}
+
+// storedCredentialRefs returns the references to stored credentials read from the encrypted credentials cached in assetsDir
+// and its subdirectories e.g. `nodepools/<name>/` containing credentials of node pools
+func storedCredentialRefs(assetsDir string) ([]string, error) {
+	refs := []string{}
+	err := filepath.Walk(assetsDir, func(cachePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(cachePath) != "."+CacheFileExtension {
+			return nil
+		}
+		ref, err := ioutil.ReadFile(cachePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", cachePath, err)
+		}
+		if len(ref) > 0 {
+			refs = append(refs, string(ref))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+func deleteStoredCredentials(refs []string, deleter storedCredentialDeleter) error {
+	for _, ref := range refs {
+		if err := deleter.Delete(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
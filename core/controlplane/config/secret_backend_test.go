@@ -0,0 +1,283 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/kubernetes-incubator/kube-aws/model"
+)
+
+type dummySSMService struct {
+	parameters map[string]*ssm.PutParameterInput
+}
+
+func (d *dummySSMService) PutParameter(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	d.parameters[*input.Name] = input
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (d *dummySSMService) DeleteParameter(input *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
+	if _, ok := d.parameters[*input.Name]; !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil)
+	}
+	delete(d.parameters, *input.Name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+type dummySecretsManagerService struct {
+	secrets  map[string]string
+	versions int
+}
+
+func (d *dummySecretsManagerService) PutSecret(name string, kmsKeyARN string, secretString string) (string, error) {
+	d.secrets[name] = secretString
+	d.versions++
+	return fmt.Sprintf("version%d", d.versions), nil
+}
+
+func (d *dummySecretsManagerService) DeleteSecret(name string) error {
+	delete(d.secrets, name)
+	return nil
+}
+
+func TestSSMEncryptionService(t *testing.T) {
+	ssmSvc := &dummySSMService{parameters: map[string]*ssm.PutParameterInput{}}
+	encSvc := ssmEncryptionService{
+		parameterPrefix: "/kube-aws/mycluster",
+		kmsKeyARN:       "keyarn",
+		ssmSvc:          ssmSvc,
+	}
+
+	ref, err := encSvc.Encrypt("apiserver-key.pem", []byte("mysecret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	name := "/kube-aws/mycluster/apiserver-key.pem"
+	if !strings.HasPrefix(string(ref), name+":") {
+		t.Errorf("expected the reference to be prefixed with \"%s:\" but was \"%s\"", name, ref)
+	}
+
+	param, ok := ssmSvc.parameters[name]
+	if !ok {
+		t.Fatalf("expected parameter \"%s\" to be created but it was not", name)
+	}
+	if *param.Type != ssm.ParameterTypeSecureString || *param.Value != "mysecret" || *param.KeyId != "keyarn" || !*param.Overwrite {
+		t.Errorf("unexpected parameter: %+v", param)
+	}
+
+	rotated, err := encSvc.Encrypt("apiserver-key.pem", []byte("myrotatedsecret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ssmSvc.parameters) != 1 || *ssmSvc.parameters[name].Value != "myrotatedsecret" {
+		t.Errorf("expected parameter \"%s\" to be overwritten but it was not: %v", name, ssmSvc.parameters)
+	}
+	if string(rotated) == string(ref) {
+		t.Errorf("expected the reference to change on rotation so that nodes are replaced, but it was not: %s", ref)
+	}
+
+	empty, err := encSvc.Encrypt("empty.pem", []byte{})
+	if err != nil || len(empty) != 0 || len(ssmSvc.parameters) != 1 {
+		t.Errorf("expected no parameter to be created for an empty credential")
+	}
+
+	if err := encSvc.Delete(string(rotated)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ssmSvc.parameters) != 0 {
+		t.Errorf("expected parameter \"%s\" to be deleted but it was not", name)
+	}
+	if err := encSvc.Delete(string(rotated)); err != nil {
+		t.Errorf("expected deleting a missing parameter to succeed, but was: %v", err)
+	}
+}
+
+func TestSecretsManagerEncryptionService(t *testing.T) {
+	smSvc := &dummySecretsManagerService{secrets: map[string]string{}}
+	encSvc := secretsManagerEncryptionService{
+		secretPrefix:      "kube-aws/mycluster",
+		kmsKeyARN:         "keyarn",
+		secretsManagerSvc: smSvc,
+	}
+
+	ref, err := encSvc.Encrypt("apiserver-key.pem", []byte("mysecret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	name := "kube-aws/mycluster/apiserver-key.pem"
+	if string(ref) != name+":version1" {
+		t.Errorf("expected the reference to be \"%s:version1\" but was \"%s\"", name, ref)
+	}
+	if smSvc.secrets[name] != "mysecret" {
+		t.Errorf("expected secret \"%s\" to contain \"mysecret\" but it did not: %v", name, smSvc.secrets)
+	}
+
+	if err := encSvc.Delete(string(ref)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(smSvc.secrets) != 0 {
+		t.Errorf("expected secret \"%s\" to be deleted but it was not", name)
+	}
+}
+
+func TestDeleteStoredCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kube-aws-credentials")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "nodepools", "pool1"), 0700); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for file, content := range map[string]string{
+		"apiserver-key.pem.enc":              "/kube-aws/mycluster/apiserver-key.pem:rev",
+		"empty.pem.enc":                      "",
+		"apiserver-key.pem":                  "plaintext",
+		"nodepools/pool1/worker-key.pem.enc": "/kube-aws/mycluster/nodepools/pool1/worker-key.pem:rev",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Nothing is stored outside of userdata with the kms backend, hence no aws api call is expected
+	if err := DeleteStoredCredentials(dir, EncryptionConfig{SecretBackend: model.NewDefaultSecretBackend()}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	ssmSvc := &dummySSMService{parameters: map[string]*ssm.PutParameterInput{
+		"/kube-aws/mycluster/apiserver-key.pem":              {},
+		"/kube-aws/mycluster/nodepools/pool1/worker-key.pem": {},
+		"/kube-aws/othercluster/apiserver-key.pem":           {},
+	}}
+	refs, err := storedCredentialRefs(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := deleteStoredCredentials(refs, ssmEncryptionService{ssmSvc: ssmSvc}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := ssmSvc.parameters["/kube-aws/othercluster/apiserver-key.pem"]; !ok || len(ssmSvc.parameters) != 1 {
+		t.Errorf("expected only the parameters referenced from the credentials dir and its node pool dirs to be deleted, but was: %v", ssmSvc.parameters)
+	}
+}
+
+func TestCachedEncryptorNamesStoredCredentialsAfterPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kube-aws-credentials")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "nodepools", "pool1"), 0700); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for file, content := range map[string]string{
+		"worker.pem":                 "cluster-wide",
+		"nodepools/pool1/worker.pem": "pool1",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ssmSvc := &dummySSMService{parameters: map[string]*ssm.PutParameterInput{}}
+	encryptor := CachedEncryptor{bytesEncryptionService: ssmEncryptionService{parameterPrefix: "/kube-aws/mycluster", ssmSvc: ssmSvc}}
+	for _, name := range []string{"worker.pem", "nodepools/pool1/worker.pem"} {
+		cache, err := encryptor.EncryptedCredentialFromPath(dir, name, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ref := string(cache.content); !strings.HasPrefix(ref, "/kube-aws/mycluster/"+name+":") {
+			t.Errorf("expected %s to be referenced by its path relative to the credentials dir, but was %s", name, ref)
+		}
+	}
+
+	if p := ssmSvc.parameters["/kube-aws/mycluster/worker.pem"]; p == nil || *p.Value != "cluster-wide" {
+		t.Errorf("expected the cluster-wide worker.pem not to be overwritten by the one of the node pool, but was %v", p)
+	}
+	if p := ssmSvc.parameters["/kube-aws/mycluster/nodepools/pool1/worker.pem"]; p == nil || *p.Value != "pool1" {
+		t.Errorf("expected worker.pem of the node pool to be stored under its own name, but was %v", p)
+	}
+}
+
+func TestVaultTransitEncryptionService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transit/encrypt/kube-aws" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != "mytoken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":{"ciphertext":"vault:v1:` + body["plaintext"] + `"}}`))
+	}))
+	defer server.Close()
+
+	encSvc := vaultTransitEncryptionService{
+		address:    server.URL,
+		mount:      "transit",
+		key:        "kube-aws",
+		token:      "mytoken",
+		httpClient: server.Client(),
+	}
+
+	ciphertext, err := encSvc.Encrypt("apiserver-key.pem", []byte("mysecret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "vault:v1:" + base64.StdEncoding.EncodeToString([]byte("mysecret"))
+	if string(ciphertext) != expected {
+		t.Errorf("expected ciphertext to be \"%s\" but was \"%s\"", expected, ciphertext)
+	}
+
+	encSvc.token = "wrongtoken"
+	if _, err := encSvc.Encrypt("apiserver-key.pem", []byte("mysecret")); err == nil {
+		t.Errorf("expected an error when vault denies the request but there was none")
+	}
+}
+
+func TestNewBytesEncryptionService(t *testing.T) {
+	t.Run("KMS", func(t *testing.T) {
+		svc, err := newBytesEncryptionService(EncryptionConfig{
+			KMSKeyARN:      "keyarn",
+			Region:         model.RegionForName("us-west-1"),
+			EncryptService: &dummyEncryptService{},
+			SecretBackend:  model.NewDefaultSecretBackend(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := svc.(kmsEncryptionService); !ok {
+			t.Errorf("expected kms encryption service but was %T", svc)
+		}
+	})
+
+	t.Run("VaultWithoutToken", func(t *testing.T) {
+		backend := model.NewDefaultSecretBackend()
+		backend.Type = model.SecretBackendVault
+		if _, err := newBytesEncryptionService(EncryptionConfig{SecretBackend: backend}); err == nil {
+			t.Errorf("expected an error when VAULT_TOKEN is missing but there was none")
+		}
+	})
+}
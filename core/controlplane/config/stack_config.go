@@ -3,8 +3,10 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 
+	"github.com/kubernetes-incubator/kube-aws/cfnresource"
 	"github.com/kubernetes-incubator/kube-aws/filereader/jsontemplate"
 	"github.com/kubernetes-incubator/kube-aws/model"
 )
@@ -17,6 +19,18 @@ type StackConfig struct {
 	UserDataEtcd          model.UserData
 	ControllerSubnetIndex int
 	ExtraCfnResources     map[string]interface{}
+	ExtraCfnOutputs       map[string]interface{}
+	CfnResourcePatches    []cfnresource.Patch
+}
+
+// ExtraCfnOutputNames returns the sorted names of outputs contributed by plugins, which are passed to node pool stacks as parameters
+func (c *StackConfig) ExtraCfnOutputNames() []string {
+	names := []string{}
+	for n := range c.ExtraCfnOutputs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (c *StackConfig) s3Folders() model.S3Folders {
@@ -58,7 +72,11 @@ func (c StackConfig) EtcdSnapshotsS3PrefixRef() (string, error) {
 }
 
 func (c *StackConfig) RenderStackTemplateAsBytes() ([]byte, error) {
-	return jsontemplate.GetBytes(c.StackTemplateTmplFile, *c, c.PrettyPrint)
+	bytes, err := jsontemplate.GetBytes(c.StackTemplateTmplFile, *c, c.PrettyPrint)
+	if err != nil {
+		return nil, err
+	}
+	return cfnresource.ApplyPatches(bytes, c.CfnResourcePatches, c.PrettyPrint)
 }
 
 func (c *StackConfig) RenderStackTemplateAsString() (string, error) {
@@ -10,6 +10,7 @@ import (
 	"github.com/coreos/coreos-cloudinit/config/validate"
 	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/test/helper"
+	"github.com/kubernetes-incubator/kube-aws/tlsutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -73,11 +74,11 @@ func TestCloudConfigTemplating(t *testing.T) {
 	var compactAssets *CompactAssets
 
 	cachedEncryptor := CachedEncryptor{
-		bytesEncryptionService: bytesEncryptionService{kmsKeyARN: cfg.KMSKeyARN, kmsSvc: &dummyEncryptService{}},
+		bytesEncryptionService: kmsEncryptionService{kmsKeyARN: cfg.KMSKeyARN, kmsSvc: &dummyEncryptService{}},
 	}
 
 	helper.WithTempDir(func(dir string) {
-		_, err = cluster.NewAssetsOnDisk(dir, opts, caKey, caCert)
+		_, err = cluster.NewAssetsOnDisk(dir, opts, tlsutil.NewLocalIssuer(caKey, caCert))
 		if err != nil {
 			t.Fatalf("Error generating default assets: %v", err)
 		}
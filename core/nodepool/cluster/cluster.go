@@ -86,6 +86,8 @@ func NewCluster(provided *config.ProvidedConfig, opts config.StackTemplateOption
 		return nil, fmt.Errorf("failed to load node pool stack extras from plugins: %v", err)
 	}
 	c.StackConfig.ExtraCfnResources = extraStack.Resources
+	c.StackConfig.ExtraCfnOutputs = extraStack.Outputs
+	c.StackConfig.CfnResourcePatches = extraStack.ResourcePatches
 
 	extraWorker, err := extras.Worker()
 	if err != nil {
@@ -101,6 +103,8 @@ func NewCluster(provided *config.ProvidedConfig, opts config.StackTemplateOption
 	for k, v := range extraWorker.FeatureGates {
 		c.NodeSettings.FeatureGates[k] = v
 	}
+	c.StackConfig.ComputedConfig.Kubelet = extraWorker.Kubelet
+	c.StackConfig.ComputedConfig.KubeProxy = extraWorker.KubeProxy
 
 	c.assets, err = c.buildAssets()
 
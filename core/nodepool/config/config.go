@@ -16,6 +16,7 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/coreos/amiregistry"
 	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/model/derived"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 )
 
 type Ref struct {
@@ -29,6 +30,10 @@ type ComputedConfig struct {
 	AMI string
 
 	AssetsConfig *cfg.CompactAssets
+
+	// Kubelet and KubeProxy are customizations to the components contributed by plugins
+	Kubelet   pluginmodel.Component
+	KubeProxy pluginmodel.Component
 }
 
 type ProvidedConfig struct {
@@ -42,7 +47,10 @@ type ProvidedConfig struct {
 	Plugins                 model.PluginConfigs `yaml:"kubeAwsPlugins,omitempty"`
 	Private                 bool                `yaml:"private,omitempty"`
 	NodePoolName            string              `yaml:"name,omitempty"`
-	ProvidedEncryptService  cfg.EncryptService
+	// KubeletIdentityOf is the name of the node pool whose kubelet identity is presented by nodes in this node pool.
+	// It is set only for the temporary node pool replacing another one so that both are authorized as the same node pool
+	KubeletIdentityOf      string `yaml:"-"`
+	ProvidedEncryptService cfg.EncryptService
 }
 
 type DeploymentSettings struct {
@@ -52,6 +60,9 @@ type DeploymentSettings struct {
 type MainClusterSettings struct {
 	EtcdNodes             []derived.EtcdNode
 	KubeResourcesAutosave cfg.KubeResourcesAutosave
+	// ControlPlaneStackOutputs are names of outputs contributed by plugins to the control-plane stack.
+	// Each of them is passed to this node pool stack as a parameter of the same name
+	ControlPlaneStackOutputs []string
 }
 
 type StackTemplateOptions struct {
@@ -61,6 +72,9 @@ type StackTemplateOptions struct {
 	PrettyPrint           bool
 	S3URI                 string
 	SkipWait              bool
+	// DisableRollingUpdate removes the update policy from the autoscaling group so that its instances are replaced by kube-aws
+	// after the stack update rather than by CloudFormation
+	DisableRollingUpdate bool
 }
 
 // NestedStackName returns a sanitized name of this node pool which is usable as a valid cloudformation nested stack name
@@ -74,6 +88,7 @@ func (c ProvidedConfig) StackConfig(opts StackTemplateOptions) (*StackConfig, er
 	var err error
 	stackConfig := StackConfig{
 		ExtraCfnResources: map[string]interface{}{},
+		ExtraCfnOutputs:   map[string]interface{}{},
 	}
 
 	if stackConfig.ComputedConfig, err = c.Config(); err != nil {
@@ -81,10 +96,11 @@ func (c ProvidedConfig) StackConfig(opts StackTemplateOptions) (*StackConfig, er
 	}
 
 	if stackConfig.ComputedConfig.AssetsEncryptionEnabled() {
-		compactAssets, err := cfg.ReadOrCreateCompactAssets(opts.AssetsDir, c.ManageCertificates, cfg.KMSConfig{
+		compactAssets, err := cfg.ReadOrCreateCompactAssets(opts.AssetsDir, c.ManageCertificates, cfg.EncryptionConfig{
 			Region:         stackConfig.ComputedConfig.Region,
 			KMSKeyARN:      c.KMSKeyARN,
 			EncryptService: c.ProvidedEncryptService,
+			SecretBackend:  c.SecretBackend,
 		})
 		if err != nil {
 			return nil, err
@@ -95,6 +111,12 @@ func (c ProvidedConfig) StackConfig(opts StackTemplateOptions) (*StackConfig, er
 		stackConfig.ComputedConfig.AssetsConfig = rawAssets
 	}
 
+	if c.ManageCertificates && c.KubeletIdentity.PerNodePool() {
+		if assets := stackConfig.ComputedConfig.AssetsConfig; assets == nil || assets.WorkerNodePoolCerts[c.KubeletIdentityNodePoolName()] == "" {
+			return nil, fmt.Errorf("`kubeletIdentity` is \"nodePool\" but no certificate found for the node pool \"%s\" in %s. Run `kube-aws render credentials` to issue it", c.KubeletIdentityNodePoolName(), opts.AssetsDir)
+		}
+	}
+
 	stackConfig.StackTemplateOptions = opts
 
 	s3Folders := model.NewS3Folders(opts.S3URI, c.ClusterName)
@@ -156,12 +178,21 @@ func (c *ProvidedConfig) Load(main *cfg.Config) error {
 	}
 
 	c.WorkerNodePoolConfig = c.WorkerNodePoolConfig.WithDefaultsFrom(main.DefaultWorkerSettings)
-	c.DeploymentSettings = c.DeploymentSettings.WithDefaultsFrom(main.DeploymentSettings)
+	if c.IsSystem() {
+		c.NodeSettings = c.NodeSettings.WithSystemRole()
+	}
+	amiID := c.AmiId
+	c.DeploymentSettings = c.DeploymentSettings.WithDefaultsFrom(main.DeploymentSettings, c.Arch())
+	if arch := c.Arch(); arch != model.ArchitectureAMD64 {
+		// The AMI of the main cluster is for amd64 controller nodes
+		c.AmiId = amiID
+		c.DeploymentSettings = c.DeploymentSettings.ForArchitecture(arch)
+	}
 
 	// Inherit parameters from the control plane stack
 	c.KubeClusterSettings = main.KubeClusterSettings
 	c.Experimental.TLSBootstrap = main.DeploymentSettings.Experimental.TLSBootstrap
-	c.Experimental.NodeDrainer = main.DeploymentSettings.Experimental.NodeDrainer
+	c.Experimental.NodeDrainer = c.Experimental.NodeDrainer.WithDefaultsFrom(main.DeploymentSettings.Experimental.NodeDrainer)
 
 	// Validate whole the inputs including inherited ones
 	if err := c.validate(); err != nil {
@@ -260,7 +291,7 @@ func (c ProvidedConfig) Config() (*ComputedConfig, error) {
 
 	if c.AmiId == "" {
 		var err error
-		if config.AMI, err = amiregistry.GetAMI(config.Region.String(), config.ReleaseChannel); err != nil {
+		if config.AMI, err = amiregistry.GetAMIForArchitecture(config.Region.String(), config.ReleaseChannel, c.Arch()); err != nil {
 			return nil, fmt.Errorf("failed getting AMI for config: %v", err)
 		}
 	} else {
@@ -270,14 +301,30 @@ func (c ProvidedConfig) Config() (*ComputedConfig, error) {
 	return &config, nil
 }
 
+// KubeletIdentityNodePoolName returns the name of the node pool whose certificate kubelets present when `kubeletIdentity` is "nodePool"
+func (c ProvidedConfig) KubeletIdentityNodePoolName() string {
+	if c.KubeletIdentityOf != "" {
+		return c.KubeletIdentityOf
+	}
+	return c.NodePoolName
+}
+
 func (c ProvidedConfig) NodeLabels() model.NodeLabels {
 	labels := c.NodeSettings.NodeLabels
 	if c.ClusterAutoscalerSupport.Enabled {
 		labels["kube-aws.coreos.com/cluster-autoscaler-supported"] = "true"
 	}
+	if c.OSUpdates.CoordinatedByKubernetes() {
+		labels[model.OSUpdatesAgentLabelKey] = model.OSUpdatesStrategyKubernetes
+	}
 	return labels
 }
 
+// ClusterAutoscalerNodeTemplateTags returns tags of the autoscaling group required for cluster-autoscaler to scale out the node pool from zero
+func (c ProvidedConfig) ClusterAutoscalerNodeTemplateTags() map[string]string {
+	return c.Autoscaling.ClusterAutoscaler.NodeTemplateTags(c.NodeLabels(), c.Taints)
+}
+
 func (c ProvidedConfig) FeatureGates() model.FeatureGates {
 	gates := c.NodeSettings.FeatureGates
 	if c.Gpu.Nvidia.IsEnabledOn(c.InstanceType) {
@@ -311,6 +358,27 @@ func (c ProvidedConfig) ValidateInputs() error {
 	return nil
 }
 
+// validateImagesForArchitecture validates that every image run on nodes in the node pool has the one for the architecture of the node pool
+func (c ProvidedConfig) validateImagesForArchitecture() error {
+	images := map[string]model.Image{
+		"hyperkubeImage": c.HyperkubeImage,
+		"awsCliImage":    c.AWSCliImage,
+		"pauseImage":     c.PauseImage,
+		"flannelImage":   c.FlannelImage,
+	}
+	if c.UseCalico {
+		images["calicoCniImage"] = c.CalicoCniImage
+		images["calicoCtlImage"] = c.CalicoCtlImage
+	}
+	if c.CloudWatchLogging.Enabled {
+		images["journaldCloudWatchLogsImage"] = c.JournaldCloudWatchLogsImage
+	}
+	if c.SecretBackend.IsVault() {
+		images["secretBackend.vault.image"] = c.SecretBackend.Vault.Image
+	}
+	return model.ValidateImagesForArchitecture(images, c.Arch())
+}
+
 func (c ProvidedConfig) validate() error {
 	if _, err := c.KubeClusterSettings.Validate(); err != nil {
 		return err
@@ -336,6 +404,10 @@ func (c ProvidedConfig) validate() error {
 		return err
 	}
 
+	if err := c.validateImagesForArchitecture(); err != nil {
+		return err
+	}
+
 	clusterNamePlaceholder := "<my-cluster-name>"
 	nestedStackNamePlaceHolder := "<my-nested-stack-name>"
 	replacer := strings.NewReplacer(clusterNamePlaceholder, "", nestedStackNamePlaceHolder, "")
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"reflect"
 
 	cfg "github.com/kubernetes-incubator/kube-aws/core/controlplane/config"
+	"github.com/kubernetes-incubator/kube-aws/model"
 )
 
 func (c DeploymentSettings) ValidateInputs() error {
@@ -43,6 +45,8 @@ func (c DeploymentSettings) ValidateInputs() error {
 	// * Region
 	// * ContainerRuntime
 	// * KMSKeyARN
+	// * SecretBackend
+	// * KubeletIdentity
 
 	if !c.Region.IsEmpty() {
 		return fmt.Errorf("although you can't customize `region` per node pool but you did specify \"%s\" in your cluster.yaml", c.Region)
@@ -53,6 +57,12 @@ func (c DeploymentSettings) ValidateInputs() error {
 	if c.KMSKeyARN != "" {
 		return fmt.Errorf("although you can't customize `kmsKeyArn` per node pool but you did specify \"%s\" in your cluster.yaml", c.KMSKeyARN)
 	}
+	if !reflect.DeepEqual(c.SecretBackend, model.SecretBackend{}) {
+		return fmt.Errorf("although you can't customize `secretBackend` per node pool but you did specify %+v in your cluster.yaml", c.SecretBackend)
+	}
+	if c.KubeletIdentity != "" {
+		return fmt.Errorf("although you can't customize `kubeletIdentity` per node pool but you did specify \"%s\" in your cluster.yaml", c.KubeletIdentity)
+	}
 
 	if err := c.Experimental.Validate(); err != nil {
 		return err
@@ -65,11 +75,14 @@ func (s DeploymentSettings) Validate() error {
 	if err := s.Experimental.Validate(); err != nil {
 		return err
 	}
+	if err := s.OSUpdates.Validate(); err != nil {
+		return fmt.Errorf("invalid osUpdates: %v", err)
+	}
 	return nil
 }
 
 // TODO make this less smelly by e.g. moving this to core/nodepool/config
-func (c DeploymentSettings) WithDefaultsFrom(main cfg.DeploymentSettings) DeploymentSettings {
+func (c DeploymentSettings) WithDefaultsFrom(main cfg.DeploymentSettings, arch string) DeploymentSettings {
 	c.ClusterName = main.ClusterName
 
 	if c.KeyName == "" {
@@ -91,14 +104,14 @@ func (c DeploymentSettings) WithDefaultsFrom(main cfg.DeploymentSettings) Deploy
 	}
 
 	// Use main images if not defined in nodepool configuration
-	c.HyperkubeImage.MergeIfEmpty(main.HyperkubeImage)
+	c.HyperkubeImage.MergeIfEmpty(main.HyperkubeImage, arch)
 	c.HyperkubeImage.Tag = c.K8sVer
-	c.AWSCliImage.MergeIfEmpty(main.AWSCliImage)
-	c.CalicoCtlImage.MergeIfEmpty(main.CalicoCtlImage)
-	c.CalicoCniImage.MergeIfEmpty(main.CalicoCniImage)
-	c.PauseImage.MergeIfEmpty(main.PauseImage)
-	c.FlannelImage.MergeIfEmpty(main.FlannelImage)
-	c.JournaldCloudWatchLogsImage.MergeIfEmpty(main.JournaldCloudWatchLogsImage)
+	c.AWSCliImage.MergeIfEmpty(main.AWSCliImage, arch)
+	c.CalicoCtlImage.MergeIfEmpty(main.CalicoCtlImage, arch)
+	c.CalicoCniImage.MergeIfEmpty(main.CalicoCniImage, arch)
+	c.PauseImage.MergeIfEmpty(main.PauseImage, arch)
+	c.FlannelImage.MergeIfEmpty(main.FlannelImage, arch)
+	c.JournaldCloudWatchLogsImage.MergeIfEmpty(main.JournaldCloudWatchLogsImage, arch)
 
 	// Inherit main TLS bootstrap config
 	c.Experimental.TLSBootstrap = main.Experimental.TLSBootstrap
@@ -113,10 +126,14 @@ func (c DeploymentSettings) WithDefaultsFrom(main cfg.DeploymentSettings) Deploy
 	// * Region
 	// * ContainerRuntime
 	// * KMSKeyARN
+	// * SecretBackend
+	// * KubeletIdentity
 	// * ElasticFileSystemID
 	c.Region = main.Region
 	c.ContainerRuntime = main.ContainerRuntime
 	c.KMSKeyARN = main.KMSKeyARN
+	c.SecretBackend = main.SecretBackend
+	c.KubeletIdentity = main.KubeletIdentity
 
 	// TODO Allow providing one or more elasticFileSystemId's to be mounted both per-node-pool/cluster-wide
 	// TODO Allow providing elasticFileSystemId to a node pool in managed subnets.
@@ -134,5 +151,22 @@ func (c DeploymentSettings) WithDefaultsFrom(main cfg.DeploymentSettings) Deploy
 	//Inherit main KubeDns config
 	c.KubeDns.MergeIfEmpty(main.KubeDns)
 
+	if c.OSUpdates.Strategy == "" {
+		c.OSUpdates = main.OSUpdates
+	}
+
+	return c
+}
+
+// ForArchitecture returns the settings in which images run on worker nodes are replaced with the ones for the architecture
+func (c DeploymentSettings) ForArchitecture(arch string) DeploymentSettings {
+	c.HyperkubeImage = c.HyperkubeImage.ForArchitecture(arch)
+	c.AWSCliImage = c.AWSCliImage.ForArchitecture(arch)
+	c.CalicoCtlImage = c.CalicoCtlImage.ForArchitecture(arch)
+	c.CalicoCniImage = c.CalicoCniImage.ForArchitecture(arch)
+	c.PauseImage = c.PauseImage.ForArchitecture(arch)
+	c.FlannelImage = c.FlannelImage.ForArchitecture(arch)
+	c.JournaldCloudWatchLogsImage = c.JournaldCloudWatchLogsImage.ForArchitecture(arch)
+	c.SecretBackend.Vault.Image = c.SecretBackend.Vault.Image.ForArchitecture(arch)
 	return c
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/kubernetes-incubator/kube-aws/cfnresource"
 	"github.com/kubernetes-incubator/kube-aws/filereader/jsontemplate"
 	"github.com/kubernetes-incubator/kube-aws/model"
 )
@@ -9,11 +10,17 @@ type StackConfig struct {
 	*ComputedConfig
 	UserDataWorker model.UserData
 	StackTemplateOptions
-	ExtraCfnResources map[string]interface{}
+	ExtraCfnResources  map[string]interface{}
+	ExtraCfnOutputs    map[string]interface{}
+	CfnResourcePatches []cfnresource.Patch
 }
 
 func (c *StackConfig) RenderStackTemplateAsBytes() ([]byte, error) {
-	return jsontemplate.GetBytes(c.StackTemplateTmplFile, *c, c.PrettyPrint)
+	bytes, err := jsontemplate.GetBytes(c.StackTemplateTmplFile, *c, c.PrettyPrint)
+	if err != nil {
+		return nil, err
+	}
+	return cfnresource.ApplyPatches(bytes, c.CfnResourcePatches, c.PrettyPrint)
 }
 
 func (c *StackConfig) RenderStackTemplateAsString() (string, error) {
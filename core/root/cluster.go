@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/cfnresource"
 	"github.com/kubernetes-incubator/kube-aws/cfnstack"
 	controlplane "github.com/kubernetes-incubator/kube-aws/core/controlplane/cluster"
 	controlplane_cfg "github.com/kubernetes-incubator/kube-aws/core/controlplane/config"
@@ -16,8 +17,12 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/core/root/config"
 	"github.com/kubernetes-incubator/kube-aws/core/root/defaults"
 	"github.com/kubernetes-incubator/kube-aws/filereader/jsontemplate"
+	"github.com/kubernetes-incubator/kube-aws/kubenode"
 	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/noderoller"
+	"github.com/kubernetes-incubator/kube-aws/plugin"
 	"github.com/kubernetes-incubator/kube-aws/plugin/clusterextension"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginhook"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 	"io/ioutil"
 	"os"
@@ -48,7 +53,7 @@ func (c clusterImpl) Export() error {
 		if err := ioutil.WriteFile(path, []byte(asset.Content), 0600); err != nil {
 			return fmt.Errorf("Error writing %s : %v", path, err)
 		}
-		if strings.HasSuffix(path, "stack.json") && c.controlPlane.KMSKeyARN == "" {
+		if strings.HasSuffix(path, "stack.json") && !c.controlPlane.AssetsEncryptionEnabled() {
 			fmt.Printf("BEWARE: %s contains your TLS secrets!\n", path)
 		}
 	}
@@ -111,6 +116,7 @@ type Cluster interface {
 	ControlPlane() *controlplane.Cluster
 	NodePools() []*nodepool.Cluster
 	RenderStackTemplateAsString() (string, error)
+	RollNodePool(name string, nodes kubenode.Client, opts noderoller.Options) error
 }
 
 func ClusterFromFile(configPath string, opts options, awsDebug bool) (Cluster, error) {
@@ -122,7 +128,10 @@ func ClusterFromFile(configPath string, opts options, awsDebug bool) (Cluster, e
 }
 
 func ClusterFromConfig(cfg *config.Config, opts options, awsDebug bool) (Cluster, error) {
-	plugins := cfg.Plugins
+	plugins, err := plugin.Resolve(cfg.Plugins, cfg.PluginConfigs, controlplane.VERSION)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plugins: %v", err)
+	}
 
 	cpOpts := controlplane_cfg.StackTemplateOptions{
 		AssetsDir:             opts.AssetsDir,
@@ -137,8 +146,28 @@ func ClusterFromConfig(cfg *config.Config, opts options, awsDebug bool) (Cluster
 	if err != nil {
 		return nil, err
 	}
+	cpOutputs := cp.StackConfig.ExtraCfnOutputNames()
+	for _, n := range cpOutputs {
+		if n == "ControlPlaneStackName" || n == "CloudWatchLogGroupARN" {
+			return nil, fmt.Errorf("output %s of the control-plane stack contributed by a plugin conflicts with a parameter of node pool stacks managed by kube-aws", n)
+		}
+	}
+
+	rolled := map[string]bool{}
+	for _, name := range opts.RolledNodePools {
+		np, err := findNodePool(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		if np.SpotFleet.Enabled() {
+			return nil, fmt.Errorf("node pool %s can't be rolled because it is powered by Spot Fleet", name)
+		}
+		rolled[name] = true
+	}
+
 	nodePools := []*nodepool.Cluster{}
 	for i, c := range cfg.NodePools {
+		c.ControlPlaneStackOutputs = cpOutputs
 		npOpts := nodepool_cfg.StackTemplateOptions{
 			AssetsDir:             opts.AssetsDir,
 			WorkerTmplFile:        opts.WorkerTmplFile,
@@ -146,6 +175,7 @@ func ClusterFromConfig(cfg *config.Config, opts options, awsDebug bool) (Cluster
 			PrettyPrint:           opts.PrettyPrint,
 			S3URI:                 opts.S3URI,
 			SkipWait:              opts.SkipWait,
+			DisableRollingUpdate:  rolled[c.NodePoolName],
 		}
 		np, err := nodepool.NewCluster(c, npOpts, plugins, awsDebug)
 		if err != nil {
@@ -177,7 +207,10 @@ func ClusterFromConfig(cfg *config.Config, opts options, awsDebug bool) (Cluster
 		controlPlane:      cp,
 		nodePools:         nodePools,
 		session:           session,
+		plugins:           plugins,
 		ExtraCfnResources: extra.Resources,
+		ExtraCfnOutputs:   extra.Outputs,
+		resourcePatches:   extra.ResourcePatches,
 	}
 
 	return c, nil
@@ -188,7 +221,10 @@ type clusterImpl struct {
 	nodePools         []*nodepool.Cluster
 	opts              options
 	session           *session.Session
+	plugins           []*pluginmodel.Plugin
 	ExtraCfnResources map[string]interface{}
+	ExtraCfnOutputs   map[string]interface{}
+	resourcePatches   []cfnresource.Patch
 }
 
 func (c clusterImpl) ControlPlane() *controlplane.Cluster {
@@ -218,7 +254,15 @@ func (c clusterImpl) Create() error {
 		go streamStackEvents(c, cfSvc, q)
 	}
 
-	return c.stackProvisioner().CreateStackAtURLAndWait(cfSvc, stackTemplateURL)
+	if err := c.runHooks(cfSvc, pluginhook.PreUp); err != nil {
+		return err
+	}
+
+	if err := c.stackProvisioner().CreateStackAtURLAndWait(cfSvc, stackTemplateURL); err != nil {
+		return err
+	}
+
+	return c.runHooks(cfSvc, pluginhook.PostUp)
 }
 
 func (c clusterImpl) Info() (*Info, error) {
@@ -259,12 +303,7 @@ func (c clusterImpl) Assets() (cfnstack.Assets, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Error while rendering template : %v", err)
 	}
-	s3URI := fmt.Sprintf("%s/kube-aws/clusters/%s/exported/stacks",
-		strings.TrimSuffix(c.opts.S3URI, "/"),
-		c.controlPlane.ClusterName,
-	)
-
-	assetsBuilder := cfnstack.NewAssetsBuilder(c.stackName(), s3URI, c.controlPlane.Region)
+	assetsBuilder := cfnstack.NewAssetsBuilder(c.stackName(), c.exportedStacksS3URI(), c.controlPlane.Region)
 	assetsBuilder.Add(REMOTE_STACK_TEMPLATE_FILENAME, stackTemplate)
 	assets := assetsBuilder.Build()
 
@@ -279,6 +318,14 @@ func (c clusterImpl) Assets() (cfnstack.Assets, error) {
 	return assets, nil
 }
 
+// exportedStacksS3URI returns the S3 URI under which the root stack template and the assets of nested stacks are uploaded
+func (c clusterImpl) exportedStacksS3URI() string {
+	return fmt.Sprintf("%s/kube-aws/clusters/%s/exported/stacks",
+		strings.TrimSuffix(c.opts.S3URI, "/"),
+		c.controlPlane.ClusterName,
+	)
+}
+
 func (c clusterImpl) templatePath() string {
 	return c.opts.RootStackTemplateTmplFile
 }
@@ -293,11 +340,15 @@ func (c clusterImpl) RenderStackTemplateAsString() (string, error) {
 }
 
 func (c clusterImpl) renderTemplateAsString() (string, error) {
-	template, err := jsontemplate.GetString(c.templatePath(), c.templateParams(), c.opts.PrettyPrint)
+	template, err := jsontemplate.GetBytes(c.templatePath(), c.templateParams(), c.opts.PrettyPrint)
 	if err != nil {
 		return "", err
 	}
-	return template, nil
+	patched, err := cfnresource.ApplyPatches(template, c.resourcePatches, c.opts.PrettyPrint)
+	if err != nil {
+		return "", err
+	}
+	return string(patched), nil
 }
 
 func (c clusterImpl) stackProvisioner() *cfnstack.Provisioner {
@@ -347,7 +398,16 @@ func (c clusterImpl) Update() (string, error) {
 		go streamStackEvents(c, cfSvc, q)
 	}
 
-	return c.stackProvisioner().UpdateStackAtURLAndWait(cfSvc, templateUrl)
+	if err := c.runHooks(cfSvc, pluginhook.PreUpdate); err != nil {
+		return "", err
+	}
+
+	report, err := c.stackProvisioner().UpdateStackAtURLAndWait(cfSvc, templateUrl)
+	if err != nil {
+		return report, err
+	}
+
+	return report, c.runHooks(cfSvc, pluginhook.PostUpdate)
 }
 
 func (c clusterImpl) ValidateTemplates() error {
@@ -13,6 +13,7 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/plugin"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginutil"
 	"gopkg.in/yaml.v2"
 )
 
@@ -61,6 +62,21 @@ func ConfigFromBytes(data []byte, plugins []*pluginmodel.Plugin) (*Config, error
 	c.HyperkubeImage.Tag = c.K8sVer
 
 	cpCluster := &c.Cluster
+	for _, np := range c.Worker.NodePools {
+		if np != nil {
+			cpCluster.NodePoolNames = append(cpCluster.NodePoolNames, np.NodePoolName)
+			cpCluster.SystemNodePoolEnabled = cpCluster.SystemNodePoolEnabled || np.IsSystem()
+			cpCluster.NodePoolArchitectures = append(cpCluster.NodePoolArchitectures, np.Arch())
+			// Node pools inherit the cluster-wide osUpdates when they don't specify the strategy
+			osUpdates := np.OSUpdates
+			if osUpdates.Strategy == "" {
+				osUpdates = c.OSUpdates
+			}
+			if osUpdates.CoordinatedByKubernetes() {
+				cpCluster.NodePoolOSUpdatesCoordinatedArchitectures = append(cpCluster.NodePoolOSUpdatesCoordinatedArchitectures, np.Arch())
+			}
+		}
+	}
 	if err := cpCluster.Load(); err != nil {
 		return nil, err
 	}
@@ -123,11 +139,24 @@ func ConfigFromBytes(data []byte, plugins []*pluginmodel.Plugin) (*Config, error
 			{np.AutoScalingGroup, fmt.Sprintf("worker.nodePools[%d].autoScalingGroup", i)},
 			{np.Autoscaling.ClusterAutoscaler, fmt.Sprintf("worker.nodePools[%d].autoscaling.clusterAutoscaler", i)},
 			{np.SpotFleet, fmt.Sprintf("worker.nodePools[%d].spotFleet", i)},
+			{np.Scaling, fmt.Sprintf("worker.nodePools[%d].scaling", i)},
+			{np.KubeletConfig, fmt.Sprintf("worker.nodePools[%d].kubelet", i)},
+			{np.KubeletConfig.SystemReserved, fmt.Sprintf("worker.nodePools[%d].kubelet.systemReserved", i)},
+			{np.KubeletConfig.KubeReserved, fmt.Sprintf("worker.nodePools[%d].kubelet.kubeReserved", i)},
 		}); err != nil {
 			return nil, err
 		}
 	}
 
+	if err := validatePluginValues(plugins, c.PluginConfigs, "kubeAwsPlugins"); err != nil {
+		return nil, err
+	}
+	for i, np := range nodePools {
+		if err := validatePluginValues(plugins, np.Plugins, fmt.Sprintf("worker.nodePools[%d].kubeAwsPlugins", i)); err != nil {
+			return nil, err
+		}
+	}
+
 	cfg := &Config{Cluster: cpCluster, NodePools: nodePools}
 
 	validations := []unknownKeyValidation{
@@ -140,15 +169,28 @@ func ConfigFromBytes(data []byte, plugins []*pluginmodel.Plugin) (*Config, error
 		{c.Controller.AutoScalingGroup, "controller.autoScalingGroup"},
 		{c.Controller.Autoscaling.ClusterAutoscaler, "controller.autoscaling.clusterAutoscaler"},
 		{c.Controller.RootVolume, "controller.rootVolume"},
+		{c.Controller.KubeletConfig, "controller.kubelet"},
+		{c.Controller.KubeletConfig.SystemReserved, "controller.kubelet.systemReserved"},
+		{c.Controller.KubeletConfig.KubeReserved, "controller.kubelet.kubeReserved"},
 		{c.Experimental, "experimental"},
 		{c.Addons, "addons"},
 		{c.Addons.Rescheduler, "addons.rescheduler"},
 		{c.Addons.ClusterAutoscaler, "addons.clusterAutoscaler"},
+		{c.OSUpdates, "osUpdates"},
+		{c.OSUpdates.RebootWindow, "osUpdates.rebootWindow"},
 	}
 
 	for i, np := range c.Worker.NodePools {
 		validations = append(validations, unknownKeyValidation{np, fmt.Sprintf("worker.nodePools[%d]", i)})
 		validations = append(validations, unknownKeyValidation{np.RootVolume, fmt.Sprintf("worker.nodePools[%d].rootVolume", i)})
+		validations = append(validations, unknownKeyValidation{np.OSUpdates, fmt.Sprintf("worker.nodePools[%d].osUpdates", i)})
+		validations = append(validations, unknownKeyValidation{np.OSUpdates.RebootWindow, fmt.Sprintf("worker.nodePools[%d].osUpdates.rebootWindow", i)})
+		for j, a := range np.Scaling.ScheduledActions {
+			validations = append(validations, unknownKeyValidation{a, fmt.Sprintf("worker.nodePools[%d].scaling.scheduledActions[%d]", i, j)})
+		}
+		for j, p := range np.Scaling.TargetTrackingPolicies {
+			validations = append(validations, unknownKeyValidation{p, fmt.Sprintf("worker.nodePools[%d].scaling.targetTrackingPolicies[%d]", i, j)})
+		}
 
 	}
 
@@ -165,6 +207,18 @@ func ConfigFromBytes(data []byte, plugins []*pluginmodel.Plugin) (*Config, error
 	return cfg, nil
 }
 
+// validatePluginValues validates values of the enabled plugins against their values schemas
+func validatePluginValues(plugins []*pluginmodel.Plugin, configs model.PluginConfigs, path string) error {
+	for _, p := range plugins {
+		if enabled, pc := p.EnabledIn(configs); enabled {
+			if _, err := pluginutil.ValuesFor(p, pc, path+"."+p.SettingKey()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func failFastWhenUnknownKeysFound(vs []unknownKeyValidation) error {
 	for _, v := range vs {
 		if err := v.unknownKeysSupport.FailWhenUnknownKeysFound(v.keyPath); err != nil {
@@ -195,7 +249,12 @@ func ConfigFromFile(configPath string) (*Config, error) {
 		return nil, err
 	}
 
-	plugins, err := plugin.LoadAll()
+	pluginConfigs, err := PluginConfigsFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("file %s: %v", configPath, err)
+	}
+
+	plugins, err := plugin.LoadAll(pluginConfigs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load plugins: %v", err)
 	}
@@ -208,6 +267,31 @@ func ConfigFromFile(configPath string) (*Config, error) {
 	return c, nil
 }
 
+// PluginConfigsFromBytes reads only the cluster-wide plugin settings from cluster.yaml so that plugins can be loaded before the rest of it is parsed
+func PluginConfigsFromBytes(data []byte) (model.PluginConfigs, error) {
+	c := struct {
+		PluginConfigs model.PluginConfigs `yaml:"kubeAwsPlugins,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeAwsPlugins: %v", err)
+	}
+	return c.PluginConfigs, nil
+}
+
+func PluginConfigsFromFile(configPath string) (model.PluginConfigs, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := PluginConfigsFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("file %s: %v", configPath, err)
+	}
+
+	return c, nil
+}
+
 func (c *Config) RootStackName() string {
 	return c.ClusterName
 }
@@ -5,7 +5,14 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	controlplane "github.com/kubernetes-incubator/kube-aws/core/controlplane/cluster"
+	controlplane_cfg "github.com/kubernetes-incubator/kube-aws/core/controlplane/config"
 	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/core/root/defaults"
+	"github.com/kubernetes-incubator/kube-aws/plugin"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginhook"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"io/ioutil"
 )
 
 type DestroyOptions struct {
@@ -18,12 +25,26 @@ type ClusterDestroyer interface {
 
 type clusterDestroyerImpl struct {
 	underlying *cfnstack.Destroyer
+	hooks      *pluginhook.Runner
+	hookInput  pluginhook.Cluster
+	assetsDir  string
+	encryption controlplane_cfg.EncryptionConfig
 }
 
 func ClusterDestroyerFromFile(configPath string, opts DestroyOptions) (ClusterDestroyer, error) {
 	cfg, err := config.ConfigFromFile(configPath)
 	if err != nil {
-		return nil, err
+		// Plugins failing to load must not prevent the cluster from being destroyed
+		data, readErr := ioutil.ReadFile(configPath)
+		if readErr != nil {
+			return nil, err
+		}
+		withoutPlugins, parseErr := config.ConfigFromBytes(data, []*pluginmodel.Plugin{})
+		if parseErr != nil {
+			return nil, err
+		}
+		fmt.Printf("WARNING: destroying the cluster without running plugin hooks because plugins failed to load: %v\n", err)
+		cfg = withoutPlugins
 	}
 
 	region := cfg.Region
@@ -42,12 +63,45 @@ func ClusterDestroyerFromFile(configPath string, opts DestroyOptions) (ClusterDe
 		return nil, fmt.Errorf("failed to establish aws session: %v", err)
 	}
 
+	plugins, err := plugin.Resolve(cfg.Plugins, cfg.PluginConfigs, controlplane.VERSION)
+	if err != nil {
+		fmt.Printf("WARNING: destroying the cluster without running plugin hooks because plugins failed to be resolved: %v\n", err)
+		plugins = []*pluginmodel.Plugin{}
+	}
+
+	cpConfig, err := cfg.Cluster.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	hookInput, err := newHookInput(cfg.ClusterName, region.String(), stackName, cpConfig.AdminAPIEndpointURL())
+	if err != nil {
+		return nil, err
+	}
+
 	cfnDestroyer := cfnstack.NewDestroyer(stackName, session)
 	return clusterDestroyerImpl{
 		underlying: cfnDestroyer,
+		hooks:      pluginhook.NewRunner(plugins, cfg.PluginConfigs),
+		hookInput:  hookInput,
+		assetsDir:  defaults.AssetsDir,
+		encryption: controlplane_cfg.EncryptionConfig{
+			Region:        region,
+			KMSKeyARN:     cfg.KMSKeyARN,
+			SecretBackend: cfg.SecretBackend,
+		},
 	}, nil
 }
 
 func (d clusterDestroyerImpl) Destroy() error {
-	return d.underlying.Destroy()
+	if err := d.hooks.Run(pluginhook.PreDestroy, d.hookInput); err != nil {
+		return fmt.Errorf("aborted by a plugin: %v", err)
+	}
+	if err := d.underlying.Destroy(); err != nil {
+		return err
+	}
+	if err := controlplane_cfg.DeleteStoredCredentials(d.assetsDir, d.encryption); err != nil {
+		return fmt.Errorf("failed to delete credentials stored in the secret backend: %v", err)
+	}
+	return nil
 }
@@ -0,0 +1,64 @@
+package root
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginhook"
+)
+
+// newHookInput returns the description of the cluster given to hooks of plugins
+func newHookInput(clusterName string, region string, stackName string, apiEndpointURL string) (pluginhook.Cluster, error) {
+	kubeconfigPath, err := filepath.Abs("kubeconfig")
+	if err != nil {
+		return pluginhook.Cluster{}, err
+	}
+	return pluginhook.Cluster{
+		ClusterName:    clusterName,
+		Region:         region,
+		StackName:      stackName,
+		APIEndpointURL: apiEndpointURL,
+		KubeconfigPath: kubeconfigPath,
+	}, nil
+}
+
+func stackOutputs(cfSvc *cloudformation.CloudFormation, stackName string) (map[string]string, error) {
+	resp, err := cfSvc.DescribeStacks(&cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stack %s: %v", stackName, err)
+	}
+	outputs := map[string]string{}
+	for _, s := range resp.Stacks {
+		for _, o := range s.Outputs {
+			outputs[aws.StringValue(o.OutputKey)] = aws.StringValue(o.OutputValue)
+		}
+	}
+	return outputs, nil
+}
+
+func (c clusterImpl) runHooks(cfSvc *cloudformation.CloudFormation, event string) error {
+	cpConfig, err := c.controlPlane.Cluster.Config()
+	if err != nil {
+		return err
+	}
+
+	input, err := newHookInput(c.controlPlane.ClusterName, c.controlPlane.Region.String(), c.stackName(), cpConfig.AdminAPIEndpointURL())
+	if err != nil {
+		return err
+	}
+
+	if event == pluginhook.PostUp || event == pluginhook.PostUpdate {
+		if input.Outputs, err = stackOutputs(cfSvc, c.stackName()); err != nil {
+			return err
+		}
+	}
+
+	if err := pluginhook.NewRunner(c.plugins, c.controlPlane.PluginConfigs).Run(event, input); err != nil {
+		return fmt.Errorf("aborted by a plugin: %v", err)
+	}
+	return nil
+}
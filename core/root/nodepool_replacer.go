@@ -0,0 +1,421 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kubernetes-incubator/kube-aws/cfnstack"
+	nodepool "github.com/kubernetes-incubator/kube-aws/core/nodepool/config"
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/kubenode"
+)
+
+// ReplacementNodePoolSuffix is appended to the name of a node pool being replaced to name its temporary sibling
+const ReplacementNodePoolSuffix = "-replacement"
+
+type NodePoolReplaceOptions struct {
+	KubeconfigPath string
+	// ReadyTimeout is how long to wait for nodes of a node pool to become Ready after its stack is created or updated
+	ReadyTimeout time.Duration
+	// DrainTimeout is how long to wait for pods to be evicted from each node
+	DrainTimeout time.Duration
+}
+
+type NodePoolReplacer interface {
+	Replace() error
+}
+
+type nodePoolReplacerImpl struct {
+	// current is the cluster as configured in cluster.yaml
+	current clusterImpl
+	// withReplacement is the cluster with the temporary sibling of the node pool being replaced
+	withReplacement clusterImpl
+	nodePoolName    string
+	replacementName string
+	nodes           kubenode.Client
+	opts            NodePoolReplaceOptions
+}
+
+// NodePoolReplacerFromFile returns a replacer of the node pool named `name` in the cluster configured in `configPath`.
+//
+// The replacer keeps the logical names of node pool stacks in the root stack unchanged so that subsequent `kube-aws update`s
+// don't recreate the node pool. To do so, it replaces nodes in three steps:
+// 1. Creates a sibling node pool stack named `<name>-replacement` with the new configuration, without touching other stacks,
+// waits for its nodes to become Ready, and cordons and drains the nodes in the node pool so that pods are moved to the sibling
+// 2. Updates the node pool in place to the new configuration, waits for its new nodes to become Ready and drains the sibling
+// 3. Removes the sibling from the root stack
+//
+// Pods are therefore evicted twice, once onto the sibling and once back onto the node pool, in exchange for keeping the node pool
+// under its own name. Each step is skipped when the deployed stacks show it is already done, so that a replacement which failed
+// or was interrupted is resumed by running the replacer again. Running it after reverting the node pool in cluster.yaml instead
+// rolls back: the node pool is left as deployed, its nodes are uncordoned and the sibling is drained and removed
+func NodePoolReplacerFromFile(configPath string, name string, opts options, replaceOpts NodePoolReplaceOptions, awsDebug bool) (NodePoolReplacer, error) {
+	cfg, err := config.ConfigFromFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return NodePoolReplacerFromConfig(cfg, name, opts, replaceOpts, awsDebug)
+}
+
+func NodePoolReplacerFromConfig(cfg *config.Config, name string, opts options, replaceOpts NodePoolReplaceOptions, awsDebug bool) (NodePoolReplacer, error) {
+	var target *nodepool.ProvidedConfig
+	replacementName := name + ReplacementNodePoolSuffix
+	for _, np := range cfg.NodePools {
+		if np.NodePoolName == name {
+			target = np
+		}
+		if np.NodePoolName == replacementName {
+			return nil, fmt.Errorf("node pool %s can't be replaced because its replacement would conflict with the node pool %s", name, replacementName)
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("node pool %s not found", name)
+	}
+	if target.SpotFleet.Enabled() {
+		return nil, fmt.Errorf("node pool %s can't be replaced because it is powered by Spot Fleet", name)
+	}
+	if target.IAMConfig.Role.Name != "" {
+		return nil, fmt.Errorf("node pool %s can't be replaced because two node pools can't share the IAM role named %s", name, target.IAMConfig.Role.Name)
+	}
+
+	current, err := ClusterFromConfig(cfg, opts, awsDebug)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := *target
+	replacement.NodePoolName = replacementName
+	// Nodes in the replacement present the identity of the node pool being replaced, which is already authorized
+	replacement.KubeletIdentityOf = name
+	withReplacementCfg := *cfg
+	withReplacementCfg.NodePools = append(append([]*nodepool.ProvidedConfig{}, cfg.NodePools...), &replacement)
+	withReplacement, err := ClusterFromConfig(&withReplacementCfg, opts, awsDebug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the replacement of node pool %s: %v", name, err)
+	}
+
+	return nodePoolReplacerImpl{
+		current:         current.(clusterImpl),
+		withReplacement: withReplacement.(clusterImpl),
+		nodePoolName:    name,
+		replacementName: replacementName,
+		nodes:           kubenode.NewKubectl(replaceOpts.KubeconfigPath),
+		opts:            replaceOpts,
+	}, nil
+}
+
+func (r nodePoolReplacerImpl) Replace() error {
+	c := r.withReplacement
+	cfSvc := cloudformation.New(c.session)
+	ec2Svc := ec2.New(c.session)
+
+	target, err := r.logicalName(r.nodePoolName)
+	if err != nil {
+		return err
+	}
+	replacement, err := r.logicalName(r.replacementName)
+	if err != nil {
+		return err
+	}
+
+	deployed, err := r.deployedTemplate(cfSvc)
+	if err != nil {
+		return err
+	}
+	replacementExists, err := hasResource(deployed, replacement)
+	if err != nil {
+		return err
+	}
+	targetUpdated, err := r.nestedStackUpToDate(cfSvc, r.nodePoolName, target)
+	if err != nil {
+		return err
+	}
+
+	if !targetUpdated {
+		oldNodes, err := nodeNamesInNestedStack(cfSvc, ec2Svc, c.stackName(), target)
+		if err != nil {
+			return err
+		}
+
+		if replacementExists {
+			fmt.Printf("Resuming the replacement of node pool %s with the existing node pool %s\n", r.nodePoolName, r.replacementName)
+		} else {
+			fmt.Printf("Creating node pool %s with the new configuration...\n", r.replacementName)
+			if err := r.addReplacement(cfSvc, deployed, replacement); err != nil {
+				return fmt.Errorf("failed to create node pool %s: %v", r.replacementName, err)
+			}
+			replacementExists = true
+		}
+		if _, err := r.waitUntilReady(cfSvc, ec2Svc, replacement); err != nil {
+			return err
+		}
+
+		fmt.Printf("Draining nodes in node pool %s: %s\n", r.nodePoolName, strings.Join(oldNodes, ", "))
+		if err := kubenode.CordonAndDrain(r.nodes, oldNodes, r.opts.DrainTimeout); err != nil {
+			return err
+		}
+
+		fmt.Printf("Updating node pool %s to the new configuration...\n", r.nodePoolName)
+		if err := updateStack(c, cfSvc); err != nil {
+			return fmt.Errorf("failed to update node pool %s: %v", r.nodePoolName, err)
+		}
+	} else {
+		fmt.Printf("Node pool %s is already deployed with the configuration in cluster.yaml\n", r.nodePoolName)
+	}
+
+	newNodes, err := r.waitUntilReady(cfSvc, ec2Svc, target)
+	if err != nil {
+		return err
+	}
+	// Nodes survive the update when nothing has changed in the node pool, or remain cordoned when the replacement is rolled back.
+	// They need to accept pods moved back from the replacement
+	for _, n := range newNodes {
+		if err := r.nodes.Uncordon(n); err != nil {
+			return fmt.Errorf("failed to uncordon node %s: %v", n, err)
+		}
+	}
+
+	if !replacementExists {
+		return nil
+	}
+
+	replacementNodes, err := nodeNamesInNestedStack(cfSvc, ec2Svc, c.stackName(), replacement)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Draining nodes in node pool %s: %s\n", r.replacementName, strings.Join(replacementNodes, ", "))
+	if err := kubenode.CordonAndDrain(r.nodes, replacementNodes, r.opts.DrainTimeout); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removing node pool %s...\n", r.replacementName)
+	if err := updateStack(r.current, cfSvc); err != nil {
+		return fmt.Errorf("failed to remove node pool %s: %v", r.replacementName, err)
+	}
+
+	return nil
+}
+
+// deployedTemplate returns the template the root stack is currently deployed with
+func (r nodePoolReplacerImpl) deployedTemplate(cfSvc *cloudformation.CloudFormation) (string, error) {
+	stackName := r.withReplacement.stackName()
+	deployed, err := cfSvc.GetTemplate(&cloudformation.GetTemplateInput{
+		StackName:     aws.String(stackName),
+		TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the template of stack %s: %v", stackName, err)
+	}
+	return aws.StringValue(deployed.TemplateBody), nil
+}
+
+// nestedStackUpToDate returns true when the stack of the node pool is deployed with the template rendered from cluster.yaml
+func (r nodePoolReplacerImpl) nestedStackUpToDate(cfSvc *cloudformation.CloudFormation, nodePoolName string, logicalName string) (bool, error) {
+	res, err := cfSvc.DescribeStackResource(&cloudformation.DescribeStackResourceInput{
+		StackName:         aws.String(r.withReplacement.stackName()),
+		LogicalResourceId: aws.String(logicalName),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to describe the nested stack %s: %v", logicalName, err)
+	}
+	deployed, err := cfSvc.GetTemplate(&cloudformation.GetTemplateInput{
+		StackName:     res.StackResourceDetail.PhysicalResourceId,
+		TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get the template of the nested stack %s: %v", logicalName, err)
+	}
+
+	for _, np := range r.withReplacement.nodePools {
+		if np.NodePoolName == nodePoolName {
+			rendered, err := np.RenderStackTemplateAsString()
+			if err != nil {
+				return false, err
+			}
+			return sameTemplates(aws.StringValue(deployed.TemplateBody), rendered)
+		}
+	}
+	return false, fmt.Errorf("[bug] node pool %s not found", nodePoolName)
+}
+
+func (r nodePoolReplacerImpl) logicalName(nodePoolName string) (string, error) {
+	for _, np := range r.withReplacement.nodePools {
+		if np.NodePoolName == nodePoolName {
+			return np.NestedStackName(), nil
+		}
+	}
+	return "", fmt.Errorf("[bug] node pool %s not found", nodePoolName)
+}
+
+// addReplacement adds the stack of the replacement node pool to the deployed template of the root stack, keeping the other stacks as they are deployed.
+// Only the assets of the replacement are uploaded because updating assets of the other stacks results in updating them
+func (r nodePoolReplacerImpl) addReplacement(cfSvc *cloudformation.CloudFormation, deployed string, replacement string) error {
+	c := r.withReplacement
+
+	rendered, err := c.renderTemplateAsString()
+	if err != nil {
+		return err
+	}
+
+	template, err := addResources(deployed, rendered, replacement)
+	if err != nil {
+		return err
+	}
+
+	assetsBuilder := cfnstack.NewAssetsBuilder(c.stackName(), c.exportedStacksS3URI(), c.controlPlane.Region)
+	assetsBuilder.Add(REMOTE_STACK_TEMPLATE_FILENAME, template)
+	assets := assetsBuilder.Build()
+	for _, np := range c.nodePools {
+		if np.NestedStackName() == replacement {
+			assets = assets.Merge(np.Assets())
+		}
+	}
+
+	if err := c.stackProvisioner().UploadAssets(s3.New(c.session), assets); err != nil {
+		return err
+	}
+	asset, err := assets.FindAssetByStackAndFileName(c.stackName(), REMOTE_STACK_TEMPLATE_FILENAME)
+	if err != nil {
+		return err
+	}
+	url, err := asset.URL()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.stackProvisioner().UpdateStackAtURLAndWait(cfSvc, url)
+	return err
+}
+
+func (r nodePoolReplacerImpl) waitUntilReady(cfSvc *cloudformation.CloudFormation, ec2Svc *ec2.EC2, logicalName string) ([]string, error) {
+	nodes, err := nodeNamesInNestedStack(cfSvc, ec2Svc, r.withReplacement.stackName(), logicalName)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Waiting for nodes to become ready: %s\n", strings.Join(nodes, ", "))
+	if err := kubenode.WaitUntilReady(r.nodes, nodes, r.opts.ReadyTimeout, 10*time.Second); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// updateStack updates the root stack to the template of the cluster without running plugin hooks
+func updateStack(c clusterImpl, cfSvc *cloudformation.CloudFormation) error {
+	url, err := c.prepareTemplateWithAssets()
+	if err != nil {
+		return err
+	}
+	_, err = c.stackProvisioner().UpdateStackAtURLAndWait(cfSvc, url)
+	if cfnstack.NoUpdatesToBePerformed(err) {
+		return nil
+	}
+	return err
+}
+
+// hasResource returns true when the template contains the resource named `name`
+func hasResource(template string, name string) (bool, error) {
+	t := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(template), &t); err != nil {
+		return false, fmt.Errorf("failed to parse the deployed template: %v", err)
+	}
+	resources, ok := t["Resources"].(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("no resources found in the deployed template")
+	}
+	_, ok = resources[name]
+	return ok, nil
+}
+
+// sameTemplates returns true when the templates are the same regardless of their formatting
+func sameTemplates(a string, b string) (bool, error) {
+	ta := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(a), &ta); err != nil {
+		return false, fmt.Errorf("failed to parse the deployed template: %v", err)
+	}
+	tb := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(b), &tb); err != nil {
+		return false, fmt.Errorf("failed to parse the rendered template: %v", err)
+	}
+	return reflect.DeepEqual(ta, tb), nil
+}
+
+// addResources copies the resources named `names` from the template `from` into the template `to`
+func addResources(to string, from string, names ...string) (string, error) {
+	dst := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(to), &dst); err != nil {
+		return "", fmt.Errorf("failed to parse the deployed template: %v", err)
+	}
+	src := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(from), &src); err != nil {
+		return "", fmt.Errorf("failed to parse the rendered template: %v", err)
+	}
+
+	dstResources, ok := dst["Resources"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("no resources found in the deployed template")
+	}
+	srcResources, ok := src["Resources"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("no resources found in the rendered template")
+	}
+	for _, n := range names {
+		r, ok := srcResources[n]
+		if !ok {
+			return "", fmt.Errorf("[bug] resource %s not found in the rendered template", n)
+		}
+		dstResources[n] = r
+	}
+
+	bytes, err := json.Marshal(dst)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// nodeNamesInNestedStack returns names of the kubernetes nodes backed by EC2 instances launched in the nested stack.
+// Node names are private DNS names of instances as kubelets are run with the aws cloud provider
+func nodeNamesInNestedStack(cfSvc *cloudformation.CloudFormation, ec2Svc *ec2.EC2, stackName string, logicalName string) ([]string, error) {
+	res, err := cfSvc.DescribeStackResource(&cloudformation.DescribeStackResourceInput{
+		StackName:         aws.String(stackName),
+		LogicalResourceId: aws.String(logicalName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the nested stack %s: %v", logicalName, err)
+	}
+	stackID := aws.StringValue(res.StackResourceDetail.PhysicalResourceId)
+
+	names := []string{}
+	err = ec2Svc.DescribeInstancesPages(&ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:aws:cloudformation:stack-id"),
+				Values: []*string{aws.String(stackID)},
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []*string{aws.String(ec2.InstanceStateNamePending), aws.String(ec2.InstanceStateNameRunning)},
+			},
+		},
+	}, func(out *ec2.DescribeInstancesOutput, last bool) bool {
+		for _, r := range out.Reservations {
+			for _, i := range r.Instances {
+				names = append(names, aws.StringValue(i.PrivateDnsName))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances in the nested stack %s: %v", logicalName, err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
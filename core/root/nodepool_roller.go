@@ -0,0 +1,60 @@
+package root
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	nodepool_cluster "github.com/kubernetes-incubator/kube-aws/core/nodepool/cluster"
+	nodepool "github.com/kubernetes-incubator/kube-aws/core/nodepool/config"
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/kubenode"
+	"github.com/kubernetes-incubator/kube-aws/noderoller"
+)
+
+func findNodePool(cfg *config.Config, name string) (*nodepool.ProvidedConfig, error) {
+	for _, np := range cfg.NodePools {
+		if np.NodePoolName == name {
+			return np, nil
+		}
+	}
+	return nil, fmt.Errorf("node pool %s not found", name)
+}
+
+// RollNodePool replaces the instances of the node pool launched with outdated launch configurations, draining their nodes
+// batch by batch. The node pool must have been listed in RolledNodePools when the cluster is updated so that CloudFormation
+// doesn't replace the instances by itself
+func (c clusterImpl) RollNodePool(name string, nodes kubenode.Client, opts noderoller.Options) error {
+	var target *nodepool_cluster.Cluster
+	for _, np := range c.nodePools {
+		if np.NodePoolName == name {
+			target = np
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("node pool %s not found", name)
+	}
+
+	cfSvc := cloudformation.New(c.session)
+	nested, err := cfSvc.DescribeStackResource(&cloudformation.DescribeStackResourceInput{
+		StackName:         aws.String(c.stackName()),
+		LogicalResourceId: aws.String(target.NestedStackName()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe the nested stack %s: %v", target.NestedStackName(), err)
+	}
+	asg, err := cfSvc.DescribeStackResource(&cloudformation.DescribeStackResourceInput{
+		StackName:         nested.StackResourceDetail.PhysicalResourceId,
+		LogicalResourceId: aws.String(target.LogicalName()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe the autoscaling group of node pool %s: %v", name, err)
+	}
+
+	r := noderoller.New(autoscaling.New(c.session), ec2.New(c.session), nodes, opts)
+	r.Out = os.Stdout
+	return r.Roll(aws.StringValue(asg.StackResourceDetail.PhysicalResourceId))
+}
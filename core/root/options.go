@@ -13,6 +13,8 @@ type options struct {
 	S3URI                             string
 	SkipWait                          bool
 	PrettyPrint                       bool
+	// RolledNodePools are names of node pools whose instances are replaced by kube-aws instead of CloudFormation rolling updates
+	RolledNodePools []string
 }
 
 func NewOptions(s3URI string, prettyPrint bool, skipWait bool) options {
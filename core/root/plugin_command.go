@@ -0,0 +1,54 @@
+package root
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginhook"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginutil"
+)
+
+// RunPluginCommand runs the command named `commandName` of the plugin named `pluginName` against the cluster configured in `configPath`.
+// `args` are passed through to the command
+func RunPluginCommand(configPath string, pluginName string, commandName string, args []string) error {
+	cfg, err := config.ConfigFromFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range cfg.Plugins {
+		if p.Name != pluginName {
+			continue
+		}
+
+		enabled, pc := p.EnabledIn(cfg.PluginConfigs)
+		if !enabled {
+			return fmt.Errorf("plugin %s is not enabled. Set `kubeAwsPlugins.%s.enabled` to true in %s", p.Name, p.SettingKey(), configPath)
+		}
+
+		for _, c := range p.Spec.Commands {
+			if c.Name != commandName {
+				continue
+			}
+
+			values, err := pluginutil.ValuesFor(p, pc, "kubeAwsPlugins."+p.SettingKey())
+			if err != nil {
+				return err
+			}
+
+			cpConfig, err := cfg.Cluster.Config()
+			if err != nil {
+				return err
+			}
+
+			input, err := newHookInput(cfg.ClusterName, cfg.Region.String(), cfg.RootStackName(), cpConfig.AdminAPIEndpointURL())
+			if err != nil {
+				return err
+			}
+
+			return pluginhook.NewCommandRunner().Run(p, c, args, input, values)
+		}
+		return fmt.Errorf("plugin %s has no command named %s", pluginName, commandName)
+	}
+	return fmt.Errorf("plugin %s is not loaded", pluginName)
+}
@@ -28,31 +28,42 @@ func NewCredentialsRenderer(c *config.Cluster) CredentialsRenderer {
 func (r credentialsRendererImpl) RenderCredentials(renderCredentialsOpts config.CredentialsOptions) error {
 	cluster := r.c
 	fmt.Println("Generating credentials...")
-	var caKey *rsa.PrivateKey
-	var caCert *x509.Certificate
-	if renderCredentialsOpts.GenerateCA {
+	var issuer tlsutil.Issuer
+	if cluster.CertificateIssuer.IsExternal() {
 		var err error
-		caKey, caCert, err = cluster.NewTLSCA()
+		issuer, err = cluster.NewCertificateIssuer()
 		if err != nil {
-			return fmt.Errorf("failed generating cluster CA: %v", err)
+			return fmt.Errorf("failed initializing certificate issuer: %v", err)
 		}
-		fmt.Printf("-> Generating new TLS CA\n")
+		fmt.Printf("-> Using the external certificate issuer \"%s\"\n", cluster.CertificateIssuer.Type)
 	} else {
-		fmt.Printf("-> Parsing existing TLS CA\n")
-		if caKeyBytes, err := ioutil.ReadFile(renderCredentialsOpts.CaKeyPath); err != nil {
-			return fmt.Errorf("failed reading ca key file %s : %v", renderCredentialsOpts.CaKeyPath, err)
-		} else {
-			if caKey, err = tlsutil.DecodePrivateKeyPEM(caKeyBytes); err != nil {
-				return fmt.Errorf("failed parsing ca key: %v", err)
+		var caKey *rsa.PrivateKey
+		var caCert *x509.Certificate
+		if renderCredentialsOpts.GenerateCA {
+			var err error
+			caKey, caCert, err = cluster.NewTLSCA()
+			if err != nil {
+				return fmt.Errorf("failed generating cluster CA: %v", err)
 			}
-		}
-		if caCertBytes, err := ioutil.ReadFile(renderCredentialsOpts.CaCertPath); err != nil {
-			return fmt.Errorf("failed reading ca cert file %s : %v", renderCredentialsOpts.CaCertPath, err)
+			fmt.Printf("-> Generating new TLS CA\n")
 		} else {
-			if caCert, err = tlsutil.DecodeCertificatePEM(caCertBytes); err != nil {
-				return fmt.Errorf("failed parsing ca cert: %v", err)
+			fmt.Printf("-> Parsing existing TLS CA\n")
+			if caKeyBytes, err := ioutil.ReadFile(renderCredentialsOpts.CaKeyPath); err != nil {
+				return fmt.Errorf("failed reading ca key file %s : %v", renderCredentialsOpts.CaKeyPath, err)
+			} else {
+				if caKey, err = tlsutil.DecodePrivateKeyPEM(caKeyBytes); err != nil {
+					return fmt.Errorf("failed parsing ca key: %v", err)
+				}
+			}
+			if caCertBytes, err := ioutil.ReadFile(renderCredentialsOpts.CaCertPath); err != nil {
+				return fmt.Errorf("failed reading ca cert file %s : %v", renderCredentialsOpts.CaCertPath, err)
+			} else {
+				if caCert, err = tlsutil.DecodeCertificatePEM(caCertBytes); err != nil {
+					return fmt.Errorf("failed parsing ca cert: %v", err)
+				}
 			}
 		}
+		issuer = tlsutil.NewLocalIssuer(caKey, caCert)
 	}
 
 	dir := defaults.AssetsDir
@@ -61,7 +72,7 @@ func (r credentialsRendererImpl) RenderCredentials(renderCredentialsOpts config.
 	}
 
 	fmt.Println("-> Generating new assets")
-	_, err := cluster.NewAssetsOnDisk(dir, renderCredentialsOpts, caKey, caCert)
+	_, err := cluster.NewAssetsOnDisk(dir, renderCredentialsOpts, issuer)
 	if err != nil {
 		return err
 	}
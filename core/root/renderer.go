@@ -2,6 +2,7 @@ package root
 
 import (
 	"github.com/kubernetes-incubator/kube-aws/core/controlplane/config"
+	rootconfig "github.com/kubernetes-incubator/kube-aws/core/root/config"
 	"github.com/kubernetes-incubator/kube-aws/core/root/render"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 )
@@ -19,9 +20,10 @@ func StackAssetsRendererFromFile(configPath string) (render.StackRenderer, error
 }
 
 func CredentialsRendererFromFile(configPath string) (render.CredentialsRenderer, error) {
-	cluster, err := config.ClusterFromFile(configPath)
+	// Node pools are loaded as well so that credentials dedicated to each node pool can be issued
+	c, err := rootconfig.ConfigFromFile(configPath)
 	if err != nil {
 		return nil, err
 	}
-	return render.NewCredentialsRenderer(cluster), nil
+	return render.NewCredentialsRenderer(c.Cluster), nil
 }
@@ -17,6 +17,10 @@ func (p TemplateParams) ExtraCfnResources() map[string]interface{} {
 	return p.cluster.ExtraCfnResources
 }
 
+func (p TemplateParams) ExtraCfnOutputs() map[string]interface{} {
+	return p.cluster.ExtraCfnOutputs
+}
+
 func (p TemplateParams) ClusterName() string {
 	return p.cluster.controlPlane.ClusterName
 }
@@ -62,6 +66,11 @@ func (p controlPlane) NeedToExportIAMroles() bool {
 	return p.controlPlane.Controller.IAMConfig.InstanceProfile.Arn == ""
 }
 
+// ExtraCfnOutputNames returns names of outputs contributed by plugins, which are passed to every node pool stack as parameters
+func (p controlPlane) ExtraCfnOutputNames() []string {
+	return p.controlPlane.ExtraCfnOutputNames()
+}
+
 func (p controlPlane) TemplateURL() (string, error) {
 	u, err := p.controlPlane.TemplateURL()
 
@@ -25,6 +25,32 @@ func GetAMI(region, channel string) (string, error) {
 	return "", fmt.Errorf("could not find hvm image for region %s, channel %s", region, channel)
 }
 
+// GetAMIForArchitecture returns the AMI for nodes of the CPU architecture e.g. "arm64".
+// amd64 images are published as "hvm" while images for other architectures are looked up by keys like "arm64-hvm"
+func GetAMIForArchitecture(region, channel, arch string) (string, error) {
+	if arch == "" || arch == "amd64" {
+		return GetAMI(region, channel)
+	}
+
+	regions, err := GetAMIData(channel)
+
+	if err != nil {
+		return "", fmt.Errorf("error getting ami data for channel %s: %v", channel, err)
+	}
+
+	amis, ok := regions[region]
+	if !ok {
+		return "", fmt.Errorf("could not find region %s for channel %s", region, channel)
+	}
+
+	key := fmt.Sprintf("%s-hvm", arch)
+	if ami, ok := amis[key]; ok {
+		return ami, nil
+	}
+
+	return "", fmt.Errorf("could not find %s image for region %s, channel %s. Specify `amiId` of the node pool instead", key, region, channel)
+}
+
 func GetAMIData(channel string) (map[string]map[string]string, error) {
 	r, err := newHttp().Get(fmt.Sprintf("https://coreos.com/dist/aws/aws-%s.json", channel))
 	if err != nil {
@@ -0,0 +1,43 @@
+package kubenode
+
+import (
+	"errors"
+	"time"
+)
+
+// FakeClient is a Client recording operations on nodes, which is intended to be used in tests
+type FakeClient struct {
+	// ReadyAfter is the number of calls to Ready for each node before it becomes ready. Nodes not in it are ready from the beginning
+	ReadyAfter map[string]int
+	// FailDrain is the set of nodes which can't be drained e.g. because of PodDisruptionBudgets
+	FailDrain map[string]bool
+	// Calls are the operations done in the form of "<operation> <node>" e.g. "drain node1"
+	Calls []string
+}
+
+func (c *FakeClient) Ready(node string) (bool, error) {
+	c.Calls = append(c.Calls, "ready "+node)
+	if c.ReadyAfter[node] > 0 {
+		c.ReadyAfter[node]--
+		return false, errors.New("not found")
+	}
+	return true, nil
+}
+
+func (c *FakeClient) Cordon(node string) error {
+	c.Calls = append(c.Calls, "cordon "+node)
+	return nil
+}
+
+func (c *FakeClient) Uncordon(node string) error {
+	c.Calls = append(c.Calls, "uncordon "+node)
+	return nil
+}
+
+func (c *FakeClient) Drain(node string, timeout time.Duration) error {
+	c.Calls = append(c.Calls, "drain "+node)
+	if c.FailDrain[node] {
+		return errors.New("cannot evict pod as it would violate the pod's disruption budget")
+	}
+	return nil
+}
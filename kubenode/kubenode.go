@@ -0,0 +1,114 @@
+// Package kubenode provides operations on Kubernetes nodes run by kube-aws against clusters it manages, like waiting for nodes
+// to become Ready and draining them before their instances are terminated
+package kubenode
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Client operates on Kubernetes nodes by their names
+type Client interface {
+	// Ready returns true when the node is registered and its Ready condition is True
+	Ready(node string) (bool, error)
+	// Cordon marks the node unschedulable
+	Cordon(node string) error
+	// Uncordon marks the node schedulable
+	Uncordon(node string) error
+	// Drain evicts pods from the node, honoring PodDisruptionBudgets, until all of them are evicted or the timeout expires
+	Drain(node string, timeout time.Duration) error
+}
+
+// Kubectl is a Client backed by the kubectl command
+type Kubectl struct {
+	KubeconfigPath string
+}
+
+func NewKubectl(kubeconfigPath string) *Kubectl {
+	return &Kubectl{
+		KubeconfigPath: kubeconfigPath,
+	}
+}
+
+func (k *Kubectl) Ready(node string) (bool, error) {
+	out, err := k.run("get", "node", node, "-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "True", nil
+}
+
+func (k *Kubectl) Cordon(node string) error {
+	_, err := k.run("cordon", node)
+	return err
+}
+
+func (k *Kubectl) Uncordon(node string) error {
+	_, err := k.run("uncordon", node)
+	return err
+}
+
+func (k *Kubectl) Drain(node string, timeout time.Duration) error {
+	_, err := k.run("drain", node, "--ignore-daemonsets=true", "--delete-local-data=true", "--force=true", fmt.Sprintf("--timeout=%s", timeout))
+	return err
+}
+
+func (k *Kubectl) run(args ...string) (string, error) {
+	cmd := exec.Command("kubectl", append([]string{"--kubeconfig", k.KubeconfigPath}, args...)...)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("kubectl %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// WaitUntilReady polls every `interval` until all the nodes become Ready, or fails after `timeout`
+func WaitUntilReady(c Client, nodes []string, timeout time.Duration, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	pending := nodes
+	for {
+		notReady := []string{}
+		var lastErr error
+		for _, n := range pending {
+			ready, err := c.Ready(n)
+			if err != nil {
+				lastErr = err
+			}
+			if !ready {
+				notReady = append(notReady, n)
+			}
+		}
+		if len(notReady) == 0 {
+			return nil
+		}
+		if !time.Now().Add(interval).Before(deadline) {
+			if lastErr != nil {
+				return fmt.Errorf("nodes %s did not become ready in %s: %v", strings.Join(notReady, ", "), timeout, lastErr)
+			}
+			return fmt.Errorf("nodes %s did not become ready in %s", strings.Join(notReady, ", "), timeout)
+		}
+		pending = notReady
+		time.Sleep(interval)
+	}
+}
+
+// CordonAndDrain cordons all the nodes first so that evicted pods are not rescheduled onto them, and then drains them one by one
+func CordonAndDrain(c Client, nodes []string, timeout time.Duration) error {
+	for _, n := range nodes {
+		if err := c.Cordon(n); err != nil {
+			return fmt.Errorf("failed to cordon node %s: %v", n, err)
+		}
+	}
+	for _, n := range nodes {
+		if err := c.Drain(n, timeout); err != nil {
+			return fmt.Errorf("failed to drain node %s: %v", n, err)
+		}
+	}
+	return nil
+}
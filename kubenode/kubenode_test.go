@@ -0,0 +1,45 @@
+package kubenode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitUntilReady(t *testing.T) {
+	c := &FakeClient{ReadyAfter: map[string]int{"node1": 2}}
+	if err := WaitUntilReady(c, []string{"node1", "node2"}, time.Second, time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"ready node1", "ready node2", "ready node1", "ready node1"}
+	if !reflect.DeepEqual(c.Calls, expected) {
+		t.Errorf("expected only not-ready nodes to be polled again: expected %v but was %v", expected, c.Calls)
+	}
+
+	c = &FakeClient{ReadyAfter: map[string]int{"node1": 1000}}
+	err := WaitUntilReady(c, []string{"node1", "node2"}, 10*time.Millisecond, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "nodes node1 did not become ready") || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a timeout error but was: %v", err)
+	}
+}
+
+func TestCordonAndDrain(t *testing.T) {
+	c := &FakeClient{}
+	if err := CordonAndDrain(c, []string{"node1", "node2"}, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"cordon node1", "cordon node2", "drain node1", "drain node2"}
+	if !reflect.DeepEqual(c.Calls, expected) {
+		t.Errorf("expected all the nodes to be cordoned before draining: expected %v but was %v", expected, c.Calls)
+	}
+
+	c = &FakeClient{FailDrain: map[string]bool{"node1": true}}
+	err := CordonAndDrain(c, []string{"node1", "node2"}, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "failed to drain node node1") {
+		t.Errorf("expected a drain failure but was: %v", err)
+	}
+	if c.Calls[len(c.Calls)-1] != "drain node1" {
+		t.Errorf("expected draining to stop at the first failure: %v", c.Calls)
+	}
+}
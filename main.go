@@ -1,12 +1,17 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/kubernetes-incubator/kube-aws/cmd"
 )
 
 func main() {
+	if err := cmd.AddPluginCommands(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to add commands of plugins: %v\n", err)
+	}
+
 	if err := cmd.RootCmd.Execute(); err != nil {
 		os.Exit(2)
 	}
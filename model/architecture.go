@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	ArchitectureAMD64 = "amd64"
+	ArchitectureARM64 = "arm64"
+)
+
+var Architectures = []string{ArchitectureAMD64, ArchitectureARM64}
+
+// arm64InstanceFamilyRegexp matches instance families powered by AWS Graviton processors e.g. a1, m6g, c6gn and t4g
+var arm64InstanceFamilyRegexp = regexp.MustCompile(`^(a1|[a-z]+[0-9]+g[a-z]*)$`)
+
+// ArchitectureOfInstanceType returns the CPU architecture of the instance type in the GOARCH notation
+func ArchitectureOfInstanceType(instanceType string) string {
+	family := strings.Split(instanceType, ".")[0]
+	if arm64InstanceFamilyRegexp.MatchString(family) {
+		return ArchitectureARM64
+	}
+	return ArchitectureAMD64
+}
+
+func ValidateArchitecture(arch string) error {
+	for _, a := range Architectures {
+		if arch == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("architecture must be one of %s, but was \"%s\"", strings.Join(Architectures, ", "), arch)
+}
+
+// SortedArchitectures returns the unique architectures including amd64, which is always in use by controller and etcd nodes.
+// amd64 comes first so that manifests for it are rendered as they used to be in a single-arch cluster
+func SortedArchitectures(archs []string) []string {
+	return UniqueArchitectures(append([]string{ArchitectureAMD64}, archs...))
+}
+
+// UniqueArchitectures returns the unique architectures sorted in the same order as SortedArchitectures, without adding amd64
+func UniqueArchitectures(archs []string) []string {
+	set := map[string]bool{}
+	for _, a := range archs {
+		set[a] = true
+	}
+	others := []string{}
+	for a := range set {
+		if a != ArchitectureAMD64 {
+			others = append(others, a)
+		}
+	}
+	sort.Strings(others)
+	if set[ArchitectureAMD64] {
+		return append([]string{ArchitectureAMD64}, others...)
+	}
+	return others
+}
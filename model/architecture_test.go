@@ -0,0 +1,108 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArchitectureOfInstanceType(t *testing.T) {
+	testCases := map[string]string{
+		"t2.medium":   ArchitectureAMD64,
+		"c4.xlarge":   ArchitectureAMD64,
+		"p2.xlarge":   ArchitectureAMD64,
+		"a1.large":    ArchitectureARM64,
+		"m6g.large":   ArchitectureARM64,
+		"c6gn.xlarge": ArchitectureARM64,
+		"t4g.medium":  ArchitectureARM64,
+	}
+
+	for instanceType, expected := range testCases {
+		if actual := ArchitectureOfInstanceType(instanceType); actual != expected {
+			t.Errorf("expected the architecture of %s to be %s, but was %s", instanceType, expected, actual)
+		}
+	}
+}
+
+func TestSortedArchitectures(t *testing.T) {
+	if actual := SortedArchitectures(nil); !reflect.DeepEqual(actual, []string{"amd64"}) {
+		t.Errorf("expected amd64 to be always in use, but was %v", actual)
+	}
+
+	actual := SortedArchitectures([]string{"arm64", "amd64", "arm64"})
+	if !reflect.DeepEqual(actual, []string{"amd64", "arm64"}) {
+		t.Errorf("expected unique architectures with amd64 first, but was %v", actual)
+	}
+
+	if actual := UniqueArchitectures([]string{"arm64", "arm64"}); !reflect.DeepEqual(actual, []string{"arm64"}) {
+		t.Errorf("expected amd64 not to be added, but was %v", actual)
+	}
+	if actual := UniqueArchitectures(nil); len(actual) != 0 {
+		t.Errorf("expected no architectures, but was %v", actual)
+	}
+}
+
+func TestImageForArchitecture(t *testing.T) {
+	image := Image{
+		Repo: "quay.io/coreos/hyperkube",
+		Tag:  "v1.7.3_coreos.0",
+		Architectures: map[string]ArchImage{
+			"arm64": {Tag: "v1.7.3_coreos.0-arm64"},
+		},
+	}
+
+	if actual := image.ForArchitecture("amd64").RepoWithTag(); actual != "quay.io/coreos/hyperkube:v1.7.3_coreos.0" {
+		t.Errorf("unexpected image for amd64: %s", actual)
+	}
+	if actual := image.ForArchitecture("arm64").RepoWithTag(); actual != "quay.io/coreos/hyperkube:v1.7.3_coreos.0-arm64" {
+		t.Errorf("unexpected image for arm64: %s", actual)
+	}
+
+	images := map[string]Image{
+		"hyperkubeImage": image,
+		"awsCliImage":    {Repo: "quay.io/coreos/awscli", Tag: "master"},
+	}
+	if err := ValidateImagesForArchitecture(images, "amd64"); err != nil {
+		t.Errorf("expected all the images to support amd64, but was: %v", err)
+	}
+	err := ValidateImagesForArchitecture(images, "arm64")
+	if err == nil || !strings.Contains(err.Error(), "awsCliImage has no image for the architecture arm64") {
+		t.Errorf("expected awsCliImage to be reported as missing an image for arm64, but was: %v", err)
+	}
+}
+
+func TestImageMergeIfEmpty(t *testing.T) {
+	cluster := Image{
+		Repo: "quay.io/coreos/hyperkube",
+		Tag:  "v1.7.3_coreos.0",
+		Architectures: map[string]ArchImage{
+			"arm64": {Repo: "quay.io/coreos/hyperkube-arm64"},
+		},
+	}
+
+	inherited := Image{}
+	inherited.MergeIfEmpty(cluster, "arm64")
+	if actual := inherited.ForArchitecture("arm64").RepoWithTag(); actual != "quay.io/coreos/hyperkube-arm64:v1.7.3_coreos.0" {
+		t.Errorf("expected the image for arm64 to be inherited, but was %s", actual)
+	}
+
+	specified := Image{Repo: "example.com/hyperkube", Tag: "v1.8-custom"}
+	specified.MergeIfEmpty(cluster, "arm64")
+	if actual := specified.ForArchitecture("arm64").RepoWithTag(); actual != "example.com/hyperkube:v1.8-custom" {
+		t.Errorf("expected the specified image to be used as is, but was %s", actual)
+	}
+
+	images := map[string]Image{"hyperkubeImage": specified}
+	if err := ValidateImagesForArchitecture(images, "arm64"); err != nil {
+		t.Errorf("expected the specified image to support arm64 without the one for arm64 of the cluster, but was: %v", err)
+	}
+
+	specified = Image{Repo: "example.com/awscli", Tag: "v1"}
+	specified.MergeIfEmpty(Image{Repo: "quay.io/coreos/awscli", Tag: "master"}, "arm64")
+	if err := ValidateImagesForArchitecture(map[string]Image{"awsCliImage": specified}, "arm64"); err != nil {
+		t.Errorf("expected the specified image to support arm64 without any image for arm64 of the cluster, but was: %v", err)
+	}
+	if specified.SupportsArchitecture("ppc64le") {
+		t.Errorf("expected the specified image to support only the architecture of the node pool")
+	}
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	CertificateIssuerLocal   = "local"
+	CertificateIssuerVault   = "vault"
+	CertificateIssuerWebhook = "webhook"
+)
+
+// CertificateIssuer is what issues leaf certificates(apiserver, worker, admin, etcd, etcd-client) on `kube-aws render credentials`.
+// `local`, the default, signs them with the CA key generated by `--generate-ca` or read from `--ca-key-path`.
+// `vault` and `webhook` delegate signing to an external PKI so that the CA key never touches the machine running kube-aws.
+type CertificateIssuer struct {
+	Type    string                   `yaml:"type,omitempty"`
+	Vault   VaultCertificateIssuer   `yaml:"vault,omitempty"`
+	Webhook WebhookCertificateIssuer `yaml:"webhook,omitempty"`
+}
+
+type VaultCertificateIssuer struct {
+	// Address is the URL of the Vault server e.g. `https://vault.example.com:8200`
+	Address string `yaml:"address,omitempty"`
+	// Mount is the path the PKI secrets engine is mounted at. Defaults to `pki`
+	Mount string `yaml:"mount,omitempty"`
+	// Role is the name of the PKI role used to sign certificates
+	Role string `yaml:"role,omitempty"`
+}
+
+type WebhookCertificateIssuer struct {
+	// URL is the endpoint CSRs are POSTed to
+	URL string `yaml:"url,omitempty"`
+}
+
+func NewDefaultCertificateIssuer() CertificateIssuer {
+	return CertificateIssuer{
+		Type: CertificateIssuerLocal,
+		Vault: VaultCertificateIssuer{
+			Mount: "pki",
+		},
+	}
+}
+
+// IsExternal returns true when the CA key isn't available to kube-aws
+func (i CertificateIssuer) IsExternal() bool {
+	return i.Type == CertificateIssuerVault || i.Type == CertificateIssuerWebhook
+}
+
+func (i CertificateIssuer) Validate() error {
+	switch i.Type {
+	case "", CertificateIssuerLocal:
+	case CertificateIssuerVault:
+		if i.Vault.Address == "" {
+			return errors.New("certificateIssuer.vault.address must be set when certificateIssuer.type is \"vault\"")
+		}
+		if i.Vault.Role == "" {
+			return errors.New("certificateIssuer.vault.role must be set when certificateIssuer.type is \"vault\"")
+		}
+	case CertificateIssuerWebhook:
+		if i.Webhook.URL == "" {
+			return errors.New("certificateIssuer.webhook.url must be set when certificateIssuer.type is \"webhook\"")
+		}
+	default:
+		return fmt.Errorf("certificateIssuer.type must be one of \"%s\", \"%s\" or \"%s\" but was \"%s\"",
+			CertificateIssuerLocal, CertificateIssuerVault, CertificateIssuerWebhook, i.Type)
+	}
+	return nil
+}
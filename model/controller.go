@@ -16,6 +16,7 @@ type Controller struct {
 	Subnets            []Subnet            `yaml:"subnets,omitempty"`
 	CustomFiles        []CustomFile        `yaml:"customFiles,omitempty"`
 	CustomSystemdUnits []CustomSystemdUnit `yaml:"customSystemdUnits,omitempty"`
+	KubeletConfig      KubeletConfig       `yaml:"kubelet,omitempty"`
 	NodeSettings       `yaml:",inline"`
 	UnknownKeys        `yaml:",inline"`
 }
@@ -74,6 +75,9 @@ func (c Controller) Validate() error {
 	if len(c.Taints) > 0 {
 		return errors.New("`controller.taints` must not be specified because tainting controller nodes breaks the cluster")
 	}
+	if err := c.KubeletConfig.Validate(c.FeatureGates); err != nil {
+		return fmt.Errorf("invalid controller.kubelet: %v", err)
+	}
 	return nil
 }
 
@@ -6,6 +6,7 @@ import (
 )
 
 type EtcdNode interface {
+	AdvertisedFQDN() (string, error)
 	AdvertisedFQDNRef() (string, error)
 	DependencyExists() bool
 	DependencyRef() (string, error)
@@ -139,6 +140,14 @@ func (i etcdNodeImpl) AdvertisedFQDNRef() (string, error) {
 	return i.defaultPublicDNSNameRef()
 }
 
+// AdvertisedFQDN returns the advertised FQDN of this etcd node when it is known before the node is created
+func (i etcdNodeImpl) AdvertisedFQDN() (string, error) {
+	if i.cluster.NodeShouldHaveSecondaryENI() && !i.cluster.EC2InternalDomainUsed() {
+		return i.customPrivateDNSName(), nil
+	}
+	return "", fmt.Errorf("the advertised fqdn of the etcd node %s is derived from the ip address assigned by ec2 and therefore unknown until the node is created", i.Name())
+}
+
 func (i etcdNodeImpl) ImportedAdvertisedFQDNRef() (string, error) {
 	if i.cluster.NodeShouldHaveSecondaryENI() {
 		return i.importedPrivateDNSNameRef(), nil
@@ -9,6 +9,9 @@ type EtcdCluster struct {
 	ManageRecordSets       *bool       `yaml:"manageRecordSets,omitempty"`
 	KMSKeyARN              string      `yaml:"kmsKeyArn,omitempty"`
 	Version                EtcdVersion `yaml:"version,omitempty"`
+	// PerNodeCertificates makes kube-aws issue an individual server/peer certificate for each etcd node
+	// instead of the single certificate shared among all the nodes
+	PerNodeCertificates bool `yaml:"perNodeCertificates,omitempty"`
 }
 
 const (
@@ -2,43 +2,104 @@ package model
 
 import (
 	"fmt"
+	"sort"
 )
 
 type Image struct {
 	Repo          string `yaml:"repo,omitempty"`
 	RktPullDocker bool   `yaml:"rktPullDocker,omitempty"`
 	Tag           string `yaml:"tag,omitempty"`
+	// Architectures is a map from architectures other than amd64 to the images for them.
+	// Repo and tag of each image default to the ones of this image, which is used for amd64
+	Architectures map[string]ArchImage `yaml:"architectures,omitempty"`
 }
 
-func (i *Image) MergeIfEmpty(other Image) {
+type ArchImage struct {
+	Repo string `yaml:"repo,omitempty"`
+	Tag  string `yaml:"tag,omitempty"`
+}
+
+// MergeIfEmpty inherits the other image, including its images for other architectures, when this image isn't specified.
+// A specified image is for nodes of the architecture `arch` and is run on them as is
+func (i *Image) MergeIfEmpty(other Image, arch string) {
 	if i.Repo == "" || i.Tag == "" {
 		i.Repo = other.Repo
 		i.Tag = other.Tag
 		i.RktPullDocker = other.RktPullDocker
+		if i.Architectures == nil {
+			i.Architectures = other.Architectures
+		}
+		return
+	}
+	if _, ok := i.Architectures[arch]; arch != ArchitectureAMD64 && !ok {
+		archs := map[string]ArchImage{arch: {}}
+		for a, img := range i.Architectures {
+			archs[a] = img
+		}
+		i.Architectures = archs
+	}
+}
+
+// SupportsArchitecture returns true when the image can be run on nodes of the architecture
+func (i Image) SupportsArchitecture(arch string) bool {
+	if arch == ArchitectureAMD64 {
+		return true
 	}
+	_, ok := i.Architectures[arch]
+	return ok
 }
 
-func (i *Image) Options() string {
+// ForArchitecture returns the image to be run on nodes of the architecture
+func (i Image) ForArchitecture(arch string) Image {
+	a, ok := i.Architectures[arch]
+	if arch == ArchitectureAMD64 || !ok {
+		return i
+	}
+	if a.Repo != "" {
+		i.Repo = a.Repo
+	}
+	if a.Tag != "" {
+		i.Tag = a.Tag
+	}
+	return i
+}
+
+func (i Image) Options() string {
 	if i.RktPullDocker {
 		return "--insecure-options=image "
 	}
 	return ""
 }
 
-func (i *Image) RktRepo() string {
+func (i Image) RktRepo() string {
 	if i.RktPullDocker {
 		return fmt.Sprintf("docker://%s:%s", i.Repo, i.Tag)
 	}
 	return fmt.Sprintf("%s:%s", i.Repo, i.Tag)
 }
 
-func (i *Image) RktRepoWithoutTag() string {
+func (i Image) RktRepoWithoutTag() string {
 	if i.RktPullDocker {
 		return fmt.Sprintf("docker://%s", i.Repo)
 	}
 	return i.Repo
 }
 
-func (i *Image) RepoWithTag() string {
+func (i Image) RepoWithTag() string {
 	return fmt.Sprintf("%s:%s", i.Repo, i.Tag)
 }
+
+// ValidateImagesForArchitecture validates that every image in the map from config keys to images can be run on nodes of the architecture
+func ValidateImagesForArchitecture(images map[string]Image, arch string) error {
+	keys := []string{}
+	for k := range images {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !images[k].SupportsArchitecture(arch) {
+			return fmt.Errorf("%s has no image for the architecture %s. Specify one in `%s.architectures.%s`", k, arch, k, arch)
+		}
+	}
+	return nil
+}
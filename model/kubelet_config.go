@@ -0,0 +1,219 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// KubeletConfig is the structured configuration of kubelets run on controller nodes or nodes in a node pool.
+// Each setting is rendered as the corresponding kubelet flag only when it is specified
+type KubeletConfig struct {
+	// SystemReserved is the resources reserved for system daemons e.g. sshd and udev, rendered as `--system-reserved`
+	SystemReserved ReservedResources `yaml:"systemReserved,omitempty"`
+	// KubeReserved is the resources reserved for kubernetes system daemons e.g. kubelet and the container runtime,
+	// rendered as `--kube-reserved`
+	KubeReserved ReservedResources `yaml:"kubeReserved,omitempty"`
+	// EvictionHard is a map from eviction signals to thresholds e.g. `memory.available: 100Mi` or `nodefs.available: 10%`
+	EvictionHard map[string]string `yaml:"evictionHard,omitempty"`
+	// EvictionSoft is a map from eviction signals to thresholds, each of which needs a grace period in EvictionSoftGracePeriod
+	EvictionSoft map[string]string `yaml:"evictionSoft,omitempty"`
+	// EvictionSoftGracePeriod is a map from eviction signals to grace periods e.g. `memory.available: 1m30s`
+	EvictionSoftGracePeriod map[string]string `yaml:"evictionSoftGracePeriod,omitempty"`
+	MaxPods                 int               `yaml:"maxPods,omitempty"`
+	// ImageGCHighThresholdPercent is the percent of disk usage after which image garbage collection is always run
+	ImageGCHighThresholdPercent int `yaml:"imageGCHighThresholdPercent,omitempty"`
+	// ImageGCLowThresholdPercent is the percent of disk usage before which image garbage collection is never run
+	ImageGCLowThresholdPercent int `yaml:"imageGCLowThresholdPercent,omitempty"`
+	// CgroupDriver is either "cgroupfs" or "systemd", which must match the one used by the container runtime
+	CgroupDriver string `yaml:"cgroupDriver,omitempty"`
+	// CPUManagerPolicy is either "none" or "static". "static" requires a CPU reservation in SystemReserved or KubeReserved.
+	// Requires the feature gate CPUManager to be enabled
+	CPUManagerPolicy string `yaml:"cpuManagerPolicy,omitempty"`
+	UnknownKeys      `yaml:",inline"`
+}
+
+type ReservedResources struct {
+	CPU              string `yaml:"cpu,omitempty"`
+	Memory           string `yaml:"memory,omitempty"`
+	EphemeralStorage string `yaml:"ephemeralStorage,omitempty"`
+	UnknownKeys      `yaml:",inline"`
+}
+
+// KubeletFlag is a flag of kubelet in the form of `--<Name>=<Value>`
+type KubeletFlag struct {
+	Name  string
+	Value string
+}
+
+// SystemdEscapedValue returns the value escaped to be embedded in a systemd unit, in which `%` starts a specifier
+func (f KubeletFlag) SystemdEscapedValue() string {
+	return strings.Replace(f.Value, "%", "%%", -1)
+}
+
+var (
+	quantityRegexp          = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(m|k|Ki|M|Mi|G|Gi|T|Ti|P|Pi|E|Ei)?$`)
+	evictionThresholdRegexp = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?%|[0-9]+(\.[0-9]+)?(k|Ki|M|Mi|G|Gi|T|Ti|P|Pi|E|Ei)?)$`)
+	durationRegexp          = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?(ns|us|ms|s|m|h))+$`)
+)
+
+var evictionSignals = []string{
+	"memory.available",
+	"nodefs.available",
+	"nodefs.inodesFree",
+	"imagefs.available",
+	"imagefs.inodesFree",
+}
+
+func (r ReservedResources) IsEmpty() bool {
+	return r.CPU == "" && r.Memory == "" && r.EphemeralStorage == ""
+}
+
+// String returns the value of `--system-reserved` or `--kube-reserved` e.g. `cpu=100m,memory=256Mi`
+func (r ReservedResources) String() string {
+	pairs := []string{}
+	if r.CPU != "" {
+		pairs = append(pairs, "cpu="+r.CPU)
+	}
+	if r.Memory != "" {
+		pairs = append(pairs, "memory="+r.Memory)
+	}
+	if r.EphemeralStorage != "" {
+		pairs = append(pairs, "ephemeral-storage="+r.EphemeralStorage)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (r ReservedResources) Validate(name string) error {
+	for k, v := range map[string]string{"cpu": r.CPU, "memory": r.Memory, "ephemeralStorage": r.EphemeralStorage} {
+		if v != "" && !quantityRegexp.MatchString(v) {
+			return fmt.Errorf("invalid %s.%s: %s is not a resource quantity like 100m or 1Gi", name, k, v)
+		}
+	}
+	return nil
+}
+
+// Validate validates the settings. `gates` are the feature gates of kubelet, which must enable CPUManager to specify cpuManagerPolicy
+func (c KubeletConfig) Validate(gates FeatureGates) error {
+	if err := c.SystemReserved.Validate("systemReserved"); err != nil {
+		return err
+	}
+	if err := c.KubeReserved.Validate("kubeReserved"); err != nil {
+		return err
+	}
+
+	for name, thresholds := range map[string]map[string]string{"evictionHard": c.EvictionHard, "evictionSoft": c.EvictionSoft} {
+		for signal, threshold := range thresholds {
+			if !isEvictionSignal(signal) {
+				return fmt.Errorf("invalid %s: unknown eviction signal %s. It must be one of %s", name, signal, strings.Join(evictionSignals, ", "))
+			}
+			if !evictionThresholdRegexp.MatchString(threshold) {
+				return fmt.Errorf("invalid %s.%s: %s is neither a quantity like 100Mi nor a percentage like 10%%", name, signal, threshold)
+			}
+		}
+	}
+	for signal, period := range c.EvictionSoftGracePeriod {
+		if _, ok := c.EvictionSoft[signal]; !ok {
+			return fmt.Errorf("invalid evictionSoftGracePeriod: eviction signal %s has no threshold in evictionSoft", signal)
+		}
+		if !durationRegexp.MatchString(period) {
+			return fmt.Errorf("invalid evictionSoftGracePeriod.%s: %s is not a duration like 1m30s", signal, period)
+		}
+	}
+	for signal := range c.EvictionSoft {
+		if _, ok := c.EvictionSoftGracePeriod[signal]; !ok {
+			return fmt.Errorf("invalid evictionSoft: eviction signal %s requires a grace period in evictionSoftGracePeriod", signal)
+		}
+	}
+
+	if c.MaxPods < 0 {
+		return fmt.Errorf("maxPods must be a positive integer, but was %d", c.MaxPods)
+	}
+
+	for name, percent := range map[string]int{"imageGCHighThresholdPercent": c.ImageGCHighThresholdPercent, "imageGCLowThresholdPercent": c.ImageGCLowThresholdPercent} {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("%s must be an integer between 0 and 100, but was %d", name, percent)
+		}
+	}
+	if c.ImageGCHighThresholdPercent != 0 && c.ImageGCLowThresholdPercent != 0 && c.ImageGCLowThresholdPercent >= c.ImageGCHighThresholdPercent {
+		return fmt.Errorf("imageGCLowThresholdPercent(%d) must be lower than imageGCHighThresholdPercent(%d)", c.ImageGCLowThresholdPercent, c.ImageGCHighThresholdPercent)
+	}
+
+	if c.CgroupDriver != "" && c.CgroupDriver != "cgroupfs" && c.CgroupDriver != "systemd" {
+		return fmt.Errorf("cgroupDriver must be either \"cgroupfs\" or \"systemd\", but was \"%s\"", c.CgroupDriver)
+	}
+
+	if c.CPUManagerPolicy != "" && gates["CPUManager"] != "true" {
+		return fmt.Errorf("cpuManagerPolicy requires the feature gate CPUManager to be \"true\"")
+	}
+	switch c.CPUManagerPolicy {
+	case "", "none":
+	case "static":
+		if c.SystemReserved.CPU == "" && c.KubeReserved.CPU == "" {
+			return fmt.Errorf("cpuManagerPolicy \"static\" requires cpu to be reserved in systemReserved or kubeReserved")
+		}
+	default:
+		return fmt.Errorf("cpuManagerPolicy must be either \"none\" or \"static\", but was \"%s\"", c.CPUManagerPolicy)
+	}
+
+	return nil
+}
+
+// Flags returns the kubelet flags for the settings in a stable order
+func (c KubeletConfig) Flags() []KubeletFlag {
+	flags := []KubeletFlag{}
+	if !c.SystemReserved.IsEmpty() {
+		flags = append(flags, KubeletFlag{Name: "system-reserved", Value: c.SystemReserved.String()})
+	}
+	if !c.KubeReserved.IsEmpty() {
+		flags = append(flags, KubeletFlag{Name: "kube-reserved", Value: c.KubeReserved.String()})
+	}
+	if len(c.EvictionHard) > 0 {
+		flags = append(flags, KubeletFlag{Name: "eviction-hard", Value: joinSorted(c.EvictionHard, "<")})
+	}
+	if len(c.EvictionSoft) > 0 {
+		flags = append(flags, KubeletFlag{Name: "eviction-soft", Value: joinSorted(c.EvictionSoft, "<")})
+	}
+	if len(c.EvictionSoftGracePeriod) > 0 {
+		flags = append(flags, KubeletFlag{Name: "eviction-soft-grace-period", Value: joinSorted(c.EvictionSoftGracePeriod, "=")})
+	}
+	if c.MaxPods != 0 {
+		flags = append(flags, KubeletFlag{Name: "max-pods", Value: fmt.Sprintf("%d", c.MaxPods)})
+	}
+	if c.ImageGCHighThresholdPercent != 0 {
+		flags = append(flags, KubeletFlag{Name: "image-gc-high-threshold", Value: fmt.Sprintf("%d", c.ImageGCHighThresholdPercent)})
+	}
+	if c.ImageGCLowThresholdPercent != 0 {
+		flags = append(flags, KubeletFlag{Name: "image-gc-low-threshold", Value: fmt.Sprintf("%d", c.ImageGCLowThresholdPercent)})
+	}
+	if c.CgroupDriver != "" {
+		flags = append(flags, KubeletFlag{Name: "cgroup-driver", Value: c.CgroupDriver})
+	}
+	if c.CPUManagerPolicy != "" {
+		flags = append(flags, KubeletFlag{Name: "cpu-manager-policy", Value: c.CPUManagerPolicy})
+	}
+	return flags
+}
+
+func isEvictionSignal(s string) bool {
+	for _, signal := range evictionSignals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+func joinSorted(m map[string]string, sep string) string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, k+sep+m[k])
+	}
+	return strings.Join(pairs, ",")
+}
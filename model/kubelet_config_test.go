@@ -0,0 +1,116 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKubeletConfigFlags(t *testing.T) {
+	c := KubeletConfig{
+		SystemReserved: ReservedResources{CPU: "100m", Memory: "256Mi"},
+		KubeReserved:   ReservedResources{CPU: "200m", Memory: "512Mi", EphemeralStorage: "1Gi"},
+		EvictionHard: map[string]string{
+			"nodefs.available": "10%",
+			"memory.available": "100Mi",
+		},
+		EvictionSoft:                map[string]string{"memory.available": "300Mi"},
+		EvictionSoftGracePeriod:     map[string]string{"memory.available": "1m30s"},
+		MaxPods:                     50,
+		ImageGCHighThresholdPercent: 80,
+		ImageGCLowThresholdPercent:  60,
+		CgroupDriver:                "systemd",
+		CPUManagerPolicy:            "static",
+	}
+
+	expected := []KubeletFlag{
+		{Name: "system-reserved", Value: "cpu=100m,memory=256Mi"},
+		{Name: "kube-reserved", Value: "cpu=200m,memory=512Mi,ephemeral-storage=1Gi"},
+		{Name: "eviction-hard", Value: "memory.available<100Mi,nodefs.available<10%"},
+		{Name: "eviction-soft", Value: "memory.available<300Mi"},
+		{Name: "eviction-soft-grace-period", Value: "memory.available=1m30s"},
+		{Name: "max-pods", Value: "50"},
+		{Name: "image-gc-high-threshold", Value: "80"},
+		{Name: "image-gc-low-threshold", Value: "60"},
+		{Name: "cgroup-driver", Value: "systemd"},
+		{Name: "cpu-manager-policy", Value: "static"},
+	}
+	actual := c.Flags()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("unexpected flags: expected=%v actual=%v", expected, actual)
+	}
+
+	if escaped := actual[2].SystemdEscapedValue(); escaped != "memory.available<100Mi,nodefs.available<10%%" {
+		t.Errorf("expected %% to be escaped for systemd, but was %s", escaped)
+	}
+
+	if err := c.Validate(FeatureGates{"CPUManager": "true"}); err != nil {
+		t.Errorf("expected the config to be valid, but it was not: %v", err)
+	}
+
+	if flags := (KubeletConfig{}).Flags(); len(flags) != 0 {
+		t.Errorf("expected no flags by default, but was %v", flags)
+	}
+}
+
+func TestKubeletConfigValidate(t *testing.T) {
+	testCases := []struct {
+		config        KubeletConfig
+		gates         FeatureGates
+		expectedError string
+	}{
+		{
+			config:        KubeletConfig{SystemReserved: ReservedResources{Memory: "1 Gi"}},
+			expectedError: "invalid systemReserved.memory",
+		},
+		{
+			config:        KubeletConfig{EvictionHard: map[string]string{"memory.free": "100Mi"}},
+			expectedError: "unknown eviction signal memory.free",
+		},
+		{
+			config:        KubeletConfig{EvictionHard: map[string]string{"memory.available": "lots"}},
+			expectedError: "invalid evictionHard.memory.available",
+		},
+		{
+			config:        KubeletConfig{EvictionSoft: map[string]string{"memory.available": "100Mi"}},
+			expectedError: "requires a grace period",
+		},
+		{
+			config:        KubeletConfig{EvictionSoftGracePeriod: map[string]string{"memory.available": "1m"}},
+			expectedError: "has no threshold in evictionSoft",
+		},
+		{
+			config:        KubeletConfig{ImageGCHighThresholdPercent: 101},
+			expectedError: "imageGCHighThresholdPercent must be an integer between 0 and 100",
+		},
+		{
+			config:        KubeletConfig{ImageGCHighThresholdPercent: 60, ImageGCLowThresholdPercent: 60},
+			expectedError: "must be lower than imageGCHighThresholdPercent",
+		},
+		{
+			config:        KubeletConfig{CgroupDriver: "docker"},
+			expectedError: "cgroupDriver must be either",
+		},
+		{
+			config:        KubeletConfig{CPUManagerPolicy: "static", SystemReserved: ReservedResources{CPU: "1"}},
+			expectedError: "requires the feature gate CPUManager",
+		},
+		{
+			config:        KubeletConfig{CPUManagerPolicy: "static"},
+			gates:         FeatureGates{"CPUManager": "true"},
+			expectedError: "requires cpu to be reserved",
+		},
+		{
+			config:        KubeletConfig{CPUManagerPolicy: "dynamic"},
+			gates:         FeatureGates{"CPUManager": "true"},
+			expectedError: "cpuManagerPolicy must be either",
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.config.Validate(testCase.gates)
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("expected an error containing \"%s\" for %+v, but was: %v", testCase.expectedError, testCase.config, err)
+		}
+	}
+}
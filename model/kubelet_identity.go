@@ -0,0 +1,43 @@
+package model
+
+import "fmt"
+
+const (
+	KubeletIdentityShared       = "shared"
+	KubeletIdentityNodePool     = "nodePool"
+	KubeletIdentityTLSBootstrap = "tlsBootstrap"
+)
+
+// KubeletIdentity determines the client certificates kubelets on worker nodes authenticate to the apiserver with.
+// `shared`, the default, makes every worker node in every node pool present the same `worker.pem` with the CN `kube-worker`.
+// `nodePool` issues a dedicated client certificate per node pool so that credentials of a single node pool can be revoked.
+// `tlsBootstrap` requires every worker node to obtain its own certificate via TLS bootstrapping
+type KubeletIdentity string
+
+func (i KubeletIdentity) PerNodePool() bool {
+	return i == KubeletIdentityNodePool
+}
+
+func (i KubeletIdentity) TLSBootstrapRequired() bool {
+	return i == KubeletIdentityTLSBootstrap
+}
+
+// SharedCertificateAuthorized returns true when the `kube-worker` user of the shared worker certificate should be authorized
+func (i KubeletIdentity) SharedCertificateAuthorized() bool {
+	return i == "" || i == KubeletIdentityShared
+}
+
+func (i KubeletIdentity) Validate() error {
+	switch i {
+	case "", KubeletIdentityShared, KubeletIdentityNodePool, KubeletIdentityTLSBootstrap:
+		return nil
+	}
+	return fmt.Errorf("kubeletIdentity must be one of \"%s\", \"%s\" or \"%s\" but was \"%s\"",
+		KubeletIdentityShared, KubeletIdentityNodePool, KubeletIdentityTLSBootstrap, i)
+}
+
+// NodePoolKubeletUserName returns the name of the user kubelets in the node pool authenticate as when the identity is `nodePool`.
+// It doesn't belong to the `system:nodes` group so that revoking the RBAC bindings for the user is enough to revoke the node pool's credentials
+func NodePoolKubeletUserName(nodePoolName string) string {
+	return fmt.Sprintf("kube-worker:%s", nodePoolName)
+}
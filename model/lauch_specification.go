@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strconv"
 )
 
 type LaunchSpecification struct {
@@ -30,6 +31,18 @@ func (s *LaunchSpecification) UnmarshalYAML(unmarshal func(interface{}) error) e
 }
 
 func (c LaunchSpecification) Validate() error {
+	if c.WeightedCapacity < 1 {
+		return fmt.Errorf("weightedCapacity must be 1 or greater, but was %d", c.WeightedCapacity)
+	}
+
+	if c.InstanceType == "" {
+		return fmt.Errorf("instanceType must be specified")
+	}
+
+	if _, err := strconv.ParseFloat(c.SpotPrice, 64); c.SpotPrice != "" && err != nil {
+		return fmt.Errorf("spotPrice must be a price per instance hour like 0.12, but was \"%s\"", c.SpotPrice)
+	}
+
 	if err := c.RootVolume.Validate(); err != nil {
 		return err
 	}
@@ -8,12 +8,46 @@ import (
 type NodeDrainer struct {
 	Enabled      bool `yaml:"enabled"`
 	DrainTimeout int  `yaml:"drainTimeout"`
+	// GracePeriod is the period in seconds given to each evicted pod to terminate gracefully.
+	// Defaults to 0, which means the terminationGracePeriodSeconds of each pod is respected
+	GracePeriod int `yaml:"gracePeriod,omitempty"`
+	// IgnoreDaemonSets makes the drainer evict pods even if the node runs pods managed by DaemonSets, which are left as they are.
+	// When set to false, draining fails and is retried until the drain timeout expires as long as such pods exist. Defaults to true
+	IgnoreDaemonSets *bool `yaml:"ignoreDaemonSets,omitempty"`
 }
 
 func (nd *NodeDrainer) DrainTimeoutInSeconds() int {
 	return int((time.Duration(nd.DrainTimeout) * time.Minute) / time.Second)
 }
 
+// GracePeriodFlag returns the value of the `--grace-period` flag of `kubectl drain`, in which -1 means the grace period of each pod
+func (nd *NodeDrainer) GracePeriodFlag() int {
+	if nd.GracePeriod == 0 {
+		return -1
+	}
+	return nd.GracePeriod
+}
+
+func (nd *NodeDrainer) IgnoresDaemonSets() bool {
+	return nd.IgnoreDaemonSets == nil || *nd.IgnoreDaemonSets
+}
+
+// WithDefaultsFrom returns the node drainer settings of a node pool, in which settings missing in the node pool are inherited
+// from the main cluster. Whether the node drainer is enabled or not is always inherited as the drainer is deployed cluster-wide
+func (nd NodeDrainer) WithDefaultsFrom(main NodeDrainer) NodeDrainer {
+	nd.Enabled = main.Enabled
+	if nd.DrainTimeout == 0 {
+		nd.DrainTimeout = main.DrainTimeout
+	}
+	if nd.GracePeriod == 0 {
+		nd.GracePeriod = main.GracePeriod
+	}
+	if nd.IgnoreDaemonSets == nil {
+		nd.IgnoreDaemonSets = main.IgnoreDaemonSets
+	}
+	return nd
+}
+
 func (nd *NodeDrainer) Validate() error {
 	if !nd.Enabled {
 		return nil
@@ -23,5 +57,9 @@ func (nd *NodeDrainer) Validate() error {
 		return fmt.Errorf("Drain timeout must be an integer between 1 and 60, but was %d", nd.DrainTimeout)
 	}
 
+	if nd.GracePeriod < 0 || nd.GracePeriod >= nd.DrainTimeoutInSeconds() {
+		return fmt.Errorf("Grace period must be an integer between 0 and %d, which is shorter than the drain timeout, but was %d", nd.DrainTimeoutInSeconds()-1, nd.GracePeriod)
+	}
+
 	return nil
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -38,6 +39,7 @@ func TestValidate(t *testing.T) {
 	testCases := []struct {
 		enabled      bool
 		drainTimeout int
+		gracePeriod  int
 		isValid      bool
 	}{
 		// Invalid, drainTimeout is < 1
@@ -74,12 +76,37 @@ func TestValidate(t *testing.T) {
 			drainTimeout: 60,
 			isValid:      true,
 		},
+
+		// Invalid, gracePeriod < 0
+		{
+			enabled:      true,
+			drainTimeout: 1,
+			gracePeriod:  -1,
+			isValid:      false,
+		},
+
+		// Invalid, gracePeriod is not shorter than drainTimeout
+		{
+			enabled:      true,
+			drainTimeout: 1,
+			gracePeriod:  60,
+			isValid:      false,
+		},
+
+		// Valid, gracePeriod within boundaries
+		{
+			enabled:      true,
+			drainTimeout: 1,
+			gracePeriod:  59,
+			isValid:      true,
+		},
 	}
 
 	for _, testCase := range testCases {
 		drainer := NodeDrainer{
 			Enabled:      testCase.enabled,
 			DrainTimeout: testCase.drainTimeout,
+			GracePeriod:  testCase.gracePeriod,
 		}
 
 		err := drainer.Validate()
@@ -92,3 +119,37 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestWithDefaultsFrom(t *testing.T) {
+	ignore := false
+	main := NodeDrainer{
+		Enabled:          true,
+		DrainTimeout:     5,
+		GracePeriod:      30,
+		IgnoreDaemonSets: &ignore,
+	}
+
+	inherited := NodeDrainer{}.WithDefaultsFrom(main)
+	if !reflect.DeepEqual(inherited, main) {
+		t.Errorf("Expected all the settings to be inherited: expected %+v, but was %+v", main, inherited)
+	}
+	if inherited.IgnoresDaemonSets() {
+		t.Errorf("Expected DaemonSets not to be ignored")
+	}
+
+	overridden := NodeDrainer{Enabled: false, DrainTimeout: 10, GracePeriod: 60}.WithDefaultsFrom(main)
+	if !overridden.Enabled {
+		t.Errorf("Expected enabled to be inherited from the main cluster")
+	}
+	if overridden.DrainTimeout != 10 || overridden.GracePeriod != 60 {
+		t.Errorf("Expected drain timeout and grace period to be overridden, but was %+v", overridden)
+	}
+
+	defaults := NodeDrainer{}
+	if !defaults.IgnoresDaemonSets() {
+		t.Errorf("Expected DaemonSets to be ignored by default")
+	}
+	if defaults.GracePeriodFlag() != -1 {
+		t.Errorf("Expected the grace period of each pod to be used by default, but was %d", defaults.GracePeriodFlag())
+	}
+}
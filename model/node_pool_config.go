@@ -1,12 +1,14 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 )
 
 type NodePoolConfig struct {
 	Autoscaling               Autoscaling      `yaml:"autoscaling,omitempty"`
 	AutoScalingGroup          AutoScalingGroup `yaml:"autoScalingGroup,omitempty"`
+	Scaling                   Scaling          `yaml:"scaling,omitempty"`
 	SpotFleet                 SpotFleet        `yaml:"spotFleet,omitempty"`
 	EC2Instance               `yaml:",inline"`
 	IAMConfig                 IAMConfig              `yaml:"iam,omitempty"`
@@ -20,8 +22,20 @@ type NodePoolConfig struct {
 	CustomFiles               []CustomFile        `yaml:"customFiles,omitempty"`
 	CustomSystemdUnits        []CustomSystemdUnit `yaml:"customSystemdUnits,omitempty"`
 	Gpu                       Gpu                 `yaml:"gpu"`
+	KubeletConfig             KubeletConfig       `yaml:"kubelet,omitempty"`
+	// Role is either empty or "system". Nodes in a "system" node pool are labeled and tainted so that they run only addons managed by kube-aws
+	Role string `yaml:"role,omitempty"`
+	// Architecture is the CPU architecture of nodes in the node pool, either "amd64" or "arm64".
+	// Defaults to the one of the instance type
+	Architecture string `yaml:"architecture,omitempty"`
 }
 
+const (
+	NodePoolRoleSystem = "system"
+	// SystemRoleKey is the key of the label and the taint added to nodes in a "system" node pool
+	SystemRoleKey = "kube-aws.coreos.com/role"
+)
+
 type ClusterAutoscaler struct {
 	Enabled     bool `yaml:"enabled,omitempty"`
 	UnknownKeys `yaml:",inline"`
@@ -31,6 +45,20 @@ func (a ClusterAutoscaler) AutoDiscoveryTagKey() string {
 	return "k8s.io/cluster-autoscaler/enabled"
 }
 
+// NodeTemplateTags returns tags of an autoscaling group describing labels and taints of nodes launched by it.
+// cluster-autoscaler builds a template node from them to know whether pending pods fit into the group even when it has no nodes,
+// which allows the group to be scaled out from zero
+func (a ClusterAutoscaler) NodeTemplateTags(labels NodeLabels, taints Taints) map[string]string {
+	tags := map[string]string{}
+	for k, v := range labels {
+		tags["k8s.io/cluster-autoscaler/node-template/label/"+k] = v
+	}
+	for _, t := range taints {
+		tags["k8s.io/cluster-autoscaler/node-template/taint/"+t.Key] = t.Value + ":" + t.Effect
+	}
+	return tags
+}
+
 func NewDefaultNodePoolConfig() NodePoolConfig {
 	return NodePoolConfig{
 		SpotFleet: newDefaultSpotFleet(),
@@ -63,6 +91,21 @@ func newDefaultSpotFleet() SpotFleet {
 	}
 }
 
+// Arch returns the CPU architecture of nodes in the node pool
+func (c NodePoolConfig) Arch() string {
+	if c.Architecture != "" {
+		return c.Architecture
+	}
+	if c.SpotFleet.Enabled() && len(c.SpotFleet.LaunchSpecifications) > 0 {
+		return ArchitectureOfInstanceType(c.SpotFleet.LaunchSpecifications[0].InstanceType)
+	}
+	return ArchitectureOfInstanceType(c.InstanceType)
+}
+
+func (c NodePoolConfig) IsSystem() bool {
+	return c.Role == NodePoolRoleSystem
+}
+
 func (c NodePoolConfig) LogicalName() string {
 	return "Workers"
 }
@@ -77,6 +120,37 @@ func (c NodePoolConfig) Validate() error {
 		return err
 	}
 
+	if err := c.validateArchitecture(); err != nil {
+		return err
+	}
+
+	if c.Role != "" && !c.IsSystem() {
+		return fmt.Errorf("role must be either empty or \"%s\", but was \"%s\"", NodePoolRoleSystem, c.Role)
+	}
+
+	if c.IsSystem() && !c.SpotFleet.Enabled() && c.MinCount() == 0 {
+		return errors.New("`autoScalingGroup.minSize` must be 1 or greater for a node pool with the \"system\" role, " +
+			"as addons like kube-dns can't be scheduled anywhere else")
+	}
+
+	if err := c.Scaling.Validate(); err != nil {
+		return err
+	}
+
+	if c.Scaling.Enabled() && c.SpotFleet.Enabled() {
+		return errors.New("`scaling` can't be specified for a node pool powered by Spot Fleet, which has no autoscaling group")
+	}
+
+	if c.Scaling.Enabled() && c.Autoscaling.ClusterAutoscaler.Enabled {
+		return errors.New("`scaling` conflicts with `autoscaling.clusterAutoscaler.enabled` as both of them change the size of the node pool. " +
+			"Use either of them")
+	}
+
+	if !c.SpotFleet.Enabled() && c.MinCount() == 0 && c.MaxCount() > 0 && !c.Autoscaling.ClusterAutoscaler.Enabled && !c.Scaling.ScalesOutFromZero() {
+		return errors.New("`autoScalingGroup.minSize` is 0 but nothing scales out the node pool from zero nodes. " +
+			"Enable `autoscaling.clusterAutoscaler.enabled`, add a scheduled action scaling it out in `scaling.scheduledActions` or set `autoScalingGroup.minSize` to 1 or greater")
+	}
+
 	if c.Tenancy != "default" && c.SpotFleet.Enabled() {
 		return fmt.Errorf("selected worker tenancy (%s) is incompatible with spot fleet", c.Tenancy)
 	}
@@ -109,6 +183,40 @@ func (c NodePoolConfig) Validate() error {
 		return err
 	}
 
+	if err := c.KubeletConfig.Validate(c.FeatureGates); err != nil {
+		return fmt.Errorf("invalid kubelet: %v", err)
+	}
+
+	return nil
+}
+
+func (c NodePoolConfig) validateArchitecture() error {
+	arch := c.Arch()
+	if err := ValidateArchitecture(arch); err != nil {
+		return err
+	}
+
+	instanceTypes := []string{c.InstanceType}
+	if c.SpotFleet.Enabled() {
+		instanceTypes = []string{}
+		for _, spec := range c.SpotFleet.LaunchSpecifications {
+			instanceTypes = append(instanceTypes, spec.InstanceType)
+		}
+	}
+	for _, t := range instanceTypes {
+		if a := ArchitectureOfInstanceType(t); a != arch {
+			return fmt.Errorf("instance type %s is for %s but the architecture of the node pool is %s", t, a, arch)
+		}
+	}
+
+	if arch != ArchitectureAMD64 && c.IsSystem() {
+		return fmt.Errorf("a node pool with the \"%s\" role must be of the architecture %s, on which addons managed by kube-aws are run", NodePoolRoleSystem, ArchitectureAMD64)
+	}
+
+	if arch != ArchitectureAMD64 && c.Gpu.Nvidia.Enabled {
+		return fmt.Errorf("gpu.nvidia can't be enabled for a node pool of the architecture %s", arch)
+	}
+
 	return nil
 }
 
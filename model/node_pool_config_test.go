@@ -1,6 +1,7 @@
 package model
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -118,3 +119,44 @@ func TestNodePoolRollingUpdateMinInstancesInService(t *testing.T) {
 		t.Errorf("min instances in service should be 2 but was %d in %+v", c6.RollingUpdateMinInstancesInService(), c6)
 	}
 }
+
+func TestClusterAutoscalerNodeTemplateTags(t *testing.T) {
+	labels := NodeLabels{"kube-aws.coreos.com/role": "gpu"}
+	taints := Taints{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}}
+
+	expected := map[string]string{
+		"k8s.io/cluster-autoscaler/node-template/label/kube-aws.coreos.com/role": "gpu",
+		"k8s.io/cluster-autoscaler/node-template/taint/dedicated":                "gpu:NoSchedule",
+	}
+	actual := ClusterAutoscaler{Enabled: true}.NodeTemplateTags(labels, taints)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("unexpected node template tags: expected=%v actual=%v", expected, actual)
+	}
+}
+
+func TestNodeSettingsWithSystemRole(t *testing.T) {
+	s := NodeSettings{
+		NodeLabels: NodeLabels{"team": "infra"},
+		Taints: Taints{
+			{Key: "dedicated", Value: "infra", Effect: "NoExecute"},
+			{Key: SystemRoleKey, Value: "custom", Effect: "NoSchedule"},
+		},
+	}
+
+	actual := s.WithSystemRole()
+
+	expectedLabels := NodeLabels{"team": "infra", "kube-aws.coreos.com/role": "system"}
+	if !reflect.DeepEqual(expectedLabels, actual.NodeLabels) {
+		t.Errorf("unexpected node labels: expected=%v actual=%v", expectedLabels, actual.NodeLabels)
+	}
+	expectedTaints := Taints{
+		{Key: "dedicated", Value: "infra", Effect: "NoExecute"},
+		{Key: "kube-aws.coreos.com/role", Value: "system", Effect: "NoSchedule"},
+	}
+	if !reflect.DeepEqual(expectedTaints, actual.Taints) {
+		t.Errorf("unexpected taints: expected=%v actual=%v", expectedTaints, actual.Taints)
+	}
+	if _, ok := s.NodeLabels[SystemRoleKey]; ok {
+		t.Errorf("expected the original node labels to be left unchanged, but was %v", s.NodeLabels)
+	}
+}
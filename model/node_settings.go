@@ -20,3 +20,25 @@ func (s NodeSettings) Validate() error {
 	}
 	return nil
 }
+
+// WithSystemRole returns the settings with the label and the taint of the "system" node pool role added,
+// so that only addons managed by kube-aws are scheduled onto the nodes
+func (s NodeSettings) WithSystemRole() NodeSettings {
+	labels := NodeLabels{}
+	for k, v := range s.NodeLabels {
+		labels[k] = v
+	}
+	labels[SystemRoleKey] = NodePoolRoleSystem
+	s.NodeLabels = labels
+
+	taint := Taint{Key: SystemRoleKey, Value: NodePoolRoleSystem, Effect: "NoSchedule"}
+	taints := Taints{}
+	for _, t := range s.Taints {
+		if t.Key != taint.Key || t.Effect != taint.Effect {
+			taints = append(taints, t)
+		}
+	}
+	s.Taints = append(taints, taint)
+
+	return s
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// OSUpdatesStrategyOff lets update-engine download updates but never reboots nodes to apply them
+	OSUpdatesStrategyOff = "off"
+	// OSUpdatesStrategyDisabled stops update-engine so that nodes are never updated
+	OSUpdatesStrategyDisabled = "disabled"
+	// OSUpdatesStrategyRebootWindow lets locksmith reboot nodes to apply updates only within the reboot window
+	OSUpdatesStrategyRebootWindow = "rebootWindow"
+	// OSUpdatesStrategyKubernetes lets container-linux-update-operator drain, reboot and uncordon one node at a time
+	OSUpdatesStrategyKubernetes = "kubernetes"
+
+	// OSUpdatesAgentLabelKey is the label of nodes on which the update-agent of container-linux-update-operator runs
+	OSUpdatesAgentLabelKey = "kube-aws.coreos.com/os-updates"
+)
+
+var OSUpdatesStrategies = []string{
+	OSUpdatesStrategyOff,
+	OSUpdatesStrategyDisabled,
+	OSUpdatesStrategyRebootWindow,
+	OSUpdatesStrategyKubernetes,
+}
+
+// OSUpdates is the configuration of how Container Linux updates are applied to nodes
+type OSUpdates struct {
+	// Strategy is one of "off", "disabled", "rebootWindow" and "kubernetes". Defaults to "off"
+	Strategy     string       `yaml:"strategy,omitempty"`
+	RebootWindow RebootWindow `yaml:"rebootWindow,omitempty"`
+	UnknownKeys  `yaml:",inline"`
+}
+
+// RebootWindow is the time window in which locksmith is allowed to reboot nodes
+type RebootWindow struct {
+	// Start is a time in UTC optionally prefixed with a day of week e.g. "Thu 04:00". Without the day, the window opens every day
+	Start string `yaml:"start,omitempty"`
+	// Length is a duration like "1h30m"
+	Length      string `yaml:"length,omitempty"`
+	UnknownKeys `yaml:",inline"`
+}
+
+var rebootWindowStartRegexp = regexp.MustCompile(`^((Mon|Tue|Wed|Thu|Fri|Sat|Sun) )?([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+func (u OSUpdates) StrategyOrDefault() string {
+	if u.Strategy == "" {
+		return OSUpdatesStrategyOff
+	}
+	return u.Strategy
+}
+
+func (u OSUpdates) Disabled() bool {
+	return u.StrategyOrDefault() == OSUpdatesStrategyDisabled
+}
+
+func (u OSUpdates) RebootsInWindow() bool {
+	return u.StrategyOrDefault() == OSUpdatesStrategyRebootWindow
+}
+
+func (u OSUpdates) CoordinatedByKubernetes() bool {
+	return u.StrategyOrDefault() == OSUpdatesStrategyKubernetes
+}
+
+// RebootStrategy returns the reboot strategy of locksmith on worker nodes.
+// Reboots coordinated by Kubernetes are done by the update-agent instead of locksmith
+func (u OSUpdates) RebootStrategy() string {
+	if u.RebootsInWindow() {
+		return "reboot"
+	}
+	return "off"
+}
+
+// ControllerRebootStrategy returns the reboot strategy of locksmith on controller nodes.
+// Controller nodes take the lock in etcd so that no more than one of them reboots at a time and the control plane stays available
+func (u OSUpdates) ControllerRebootStrategy() string {
+	if u.RebootsInWindow() {
+		return "etcd-lock"
+	}
+	return "off"
+}
+
+// EtcdRebootStrategy returns the reboot strategy of locksmith on etcd nodes.
+// Etcd nodes aren't Kubernetes nodes, so they take the lock in etcd to never reboot more than one member at a time
+func (u OSUpdates) EtcdRebootStrategy() string {
+	if u.RebootsInWindow() || u.CoordinatedByKubernetes() {
+		return "etcd-lock"
+	}
+	return "off"
+}
+
+func (u OSUpdates) Validate() error {
+	valid := false
+	for _, s := range OSUpdatesStrategies {
+		if u.StrategyOrDefault() == s {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("strategy must be one of %s, but was \"%s\"", strings.Join(OSUpdatesStrategies, ", "), u.Strategy)
+	}
+	if !u.RebootsInWindow() {
+		if u.RebootWindow.Start != "" || u.RebootWindow.Length != "" {
+			return fmt.Errorf("rebootWindow can only be specified with the \"%s\" strategy", OSUpdatesStrategyRebootWindow)
+		}
+		return nil
+	}
+	if err := u.RebootWindow.Validate(); err != nil {
+		return fmt.Errorf("invalid rebootWindow: %v", err)
+	}
+	return nil
+}
+
+func (w RebootWindow) Validate() error {
+	if !rebootWindowStartRegexp.MatchString(w.Start) {
+		return fmt.Errorf("start must be a time in UTC optionally prefixed with a day of week like \"Thu 04:00\", but was \"%s\"", w.Start)
+	}
+	d, err := time.ParseDuration(w.Length)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("length must be a positive duration like \"1h30m\", but was \"%s\"", w.Length)
+	}
+	return nil
+}
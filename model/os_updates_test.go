@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOSUpdatesValidate(t *testing.T) {
+	testCases := []struct {
+		updates       OSUpdates
+		expectedError string
+	}{
+		{
+			updates:       OSUpdates{Strategy: "always"},
+			expectedError: "strategy must be one of off, disabled, rebootWindow, kubernetes",
+		},
+		{
+			updates:       OSUpdates{Strategy: "kubernetes", RebootWindow: RebootWindow{Start: "04:00", Length: "1h"}},
+			expectedError: "rebootWindow can only be specified with the \"rebootWindow\" strategy",
+		},
+		{
+			updates:       OSUpdates{Strategy: "rebootWindow", RebootWindow: RebootWindow{Start: "Thursday 04:00", Length: "1h"}},
+			expectedError: "start must be a time in UTC optionally prefixed with a day of week",
+		},
+		{
+			updates:       OSUpdates{Strategy: "rebootWindow", RebootWindow: RebootWindow{Start: "24:00", Length: "1h"}},
+			expectedError: "start must be a time in UTC optionally prefixed with a day of week",
+		},
+		{
+			updates:       OSUpdates{Strategy: "rebootWindow", RebootWindow: RebootWindow{Start: "Thu 04:00", Length: "1 hour"}},
+			expectedError: "length must be a positive duration",
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.updates.Validate()
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("expected an error containing \"%s\" for %+v, but was: %v", testCase.expectedError, testCase.updates, err)
+		}
+	}
+
+	for _, valid := range []OSUpdates{
+		{},
+		{Strategy: "disabled"},
+		{Strategy: "kubernetes"},
+		{Strategy: "rebootWindow", RebootWindow: RebootWindow{Start: "04:00", Length: "30m"}},
+		{Strategy: "rebootWindow", RebootWindow: RebootWindow{Start: "Sun 23:30", Length: "1h30m"}},
+	} {
+		if err := valid.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, but it was not: %v", valid, err)
+		}
+	}
+}
+
+func TestOSUpdatesRebootStrategies(t *testing.T) {
+	testCases := []struct {
+		strategy         string
+		reboot           string
+		controllerReboot string
+		etcdReboot       string
+		updateEngine     bool
+	}{
+		{strategy: "", reboot: "off", controllerReboot: "off", etcdReboot: "off", updateEngine: true},
+		{strategy: "off", reboot: "off", controllerReboot: "off", etcdReboot: "off", updateEngine: true},
+		{strategy: "disabled", reboot: "off", controllerReboot: "off", etcdReboot: "off", updateEngine: false},
+		{strategy: "rebootWindow", reboot: "reboot", controllerReboot: "etcd-lock", etcdReboot: "etcd-lock", updateEngine: true},
+		{strategy: "kubernetes", reboot: "off", controllerReboot: "off", etcdReboot: "etcd-lock", updateEngine: true},
+	}
+
+	for _, testCase := range testCases {
+		u := OSUpdates{Strategy: testCase.strategy}
+		if u.RebootStrategy() != testCase.reboot {
+			t.Errorf("expected the reboot strategy for \"%s\" to be %s, but was %s", testCase.strategy, testCase.reboot, u.RebootStrategy())
+		}
+		if u.ControllerRebootStrategy() != testCase.controllerReboot {
+			t.Errorf("expected the controller reboot strategy for \"%s\" to be %s, but was %s", testCase.strategy, testCase.controllerReboot, u.ControllerRebootStrategy())
+		}
+		if u.EtcdRebootStrategy() != testCase.etcdReboot {
+			t.Errorf("expected the etcd reboot strategy for \"%s\" to be %s, but was %s", testCase.strategy, testCase.etcdReboot, u.EtcdRebootStrategy())
+		}
+		if u.Disabled() == testCase.updateEngine {
+			t.Errorf("expected update-engine to be running=%v for \"%s\"", testCase.updateEngine, testCase.strategy)
+		}
+	}
+}
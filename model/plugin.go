@@ -1,10 +1,57 @@
 package model
 
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
 type PluginConfigs map[string]PluginConfig
 
 type PluginConfig struct {
-	Enabled bool `yaml:"enabled,omitempty"`
+	Enabled bool         `yaml:"enabled,omitempty"`
+	Source  PluginSource `yaml:"source,omitempty"`
 	Values  `yaml:",inline"`
 }
 
 type Values map[string]interface{}
+
+// PluginSource is the remote location a plugin is fetched from by `kube-aws plugin install`.
+// Plugins without a source are loaded from `plugins/` in the working directory or `~/.kube-aws/plugins`
+type PluginSource struct {
+	// Git is the URL of the git repository containing the plugin
+	Git string `yaml:"git,omitempty"`
+	// Ref is the branch, tag or commit the plugin fetched from the git repository is pinned to
+	Ref string `yaml:"ref,omitempty"`
+	// URL is the HTTP(S) URL of the gzipped tarball containing the plugin
+	URL string `yaml:"url,omitempty"`
+	// SHA256 is the hex-encoded sha256 checksum of the tarball
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Path is the directory containing plugin.yaml, relative to the root of the git repository or the tarball
+	Path string `yaml:"path,omitempty"`
+}
+
+func (s PluginSource) Remote() bool {
+	return s.Git != "" || s.URL != ""
+}
+
+func (s PluginSource) Validate() error {
+	if s.Git != "" && s.URL != "" {
+		return errors.New("`git` and `url` can't be specified at the same time")
+	}
+	if s.Git != "" && s.Ref == "" {
+		return errors.New("`ref` must be specified to pin the plugin fetched from the git repository")
+	}
+	if s.URL != "" {
+		if s.SHA256 == "" {
+			return errors.New("`sha256` must be specified to verify the plugin fetched from the url")
+		}
+		if b, err := hex.DecodeString(s.SHA256); err != nil || len(b) != 32 {
+			return fmt.Errorf("`sha256` must be a hex-encoded sha256 checksum but was \"%s\"", s.SHA256)
+		}
+	}
+	if !s.Remote() && (s.Ref != "" || s.SHA256 != "" || s.Path != "") {
+		return errors.New("either `git` or `url` must be specified")
+	}
+	return nil
+}
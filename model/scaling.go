@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Scaling is the configuration of scaling policies attached to the autoscaling group of a node pool
+type Scaling struct {
+	// ScheduledActions change the size of the node pool at specific times e.g. to scale it down overnight
+	ScheduledActions []ScheduledAction `yaml:"scheduledActions,omitempty"`
+	// TargetTrackingPolicies keep a metric of the node pool around a target value by adding or removing nodes
+	TargetTrackingPolicies []TargetTrackingPolicy `yaml:"targetTrackingPolicies,omitempty"`
+	UnknownKeys            `yaml:",inline"`
+}
+
+// ScheduledAction is rendered as an `AWS::AutoScaling::ScheduledAction`
+type ScheduledAction struct {
+	// Name is an alphanumeric name of the action unique in the node pool, used to name the CloudFormation resource
+	Name string `yaml:"name,omitempty"`
+	// Recurrence is a cron expression in UTC e.g. `0 20 * * 1-5` which runs the action at 20:00 on weekdays
+	Recurrence string `yaml:"recurrence,omitempty"`
+	// StartTime and EndTime are optional times in UTC in the format of `2017-12-31T00:00:00Z` limiting when the action runs
+	StartTime       string `yaml:"startTime,omitempty"`
+	EndTime         string `yaml:"endTime,omitempty"`
+	MinSize         *int   `yaml:"minSize,omitempty"`
+	MaxSize         *int   `yaml:"maxSize,omitempty"`
+	DesiredCapacity *int   `yaml:"desiredCapacity,omitempty"`
+	UnknownKeys     `yaml:",inline"`
+}
+
+// TargetTrackingPolicy is rendered as an `AWS::AutoScaling::ScalingPolicy` of the `TargetTrackingScaling` type
+type TargetTrackingPolicy struct {
+	// Name is an alphanumeric name of the policy unique in the node pool, used to name the CloudFormation resource
+	Name string `yaml:"name,omitempty"`
+	// PredefinedMetric is one of ASGAverageCPUUtilization, ASGAverageNetworkIn and ASGAverageNetworkOut
+	PredefinedMetric string  `yaml:"predefinedMetric,omitempty"`
+	TargetValue      float64 `yaml:"targetValue,omitempty"`
+	// DisableScaleIn prevents the policy from removing nodes
+	DisableScaleIn bool `yaml:"disableScaleIn,omitempty"`
+	// EstimatedInstanceWarmup is the number of seconds until a newly launched node starts contributing to the metric
+	EstimatedInstanceWarmup int `yaml:"estimatedInstanceWarmup,omitempty"`
+	UnknownKeys             `yaml:",inline"`
+}
+
+var (
+	scalingNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	timestampRegexp   = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}Z$`)
+)
+
+var predefinedMetrics = []string{
+	"ASGAverageCPUUtilization",
+	"ASGAverageNetworkIn",
+	"ASGAverageNetworkOut",
+}
+
+func (s Scaling) Enabled() bool {
+	return len(s.ScheduledActions) > 0 || len(s.TargetTrackingPolicies) > 0
+}
+
+// ScalesOutFromZero returns true when any of the scheduled actions brings the node pool to one or more nodes
+func (s Scaling) ScalesOutFromZero() bool {
+	for _, a := range s.ScheduledActions {
+		if a.MinSize != nil && *a.MinSize > 0 || a.DesiredCapacity != nil && *a.DesiredCapacity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Scaling) Validate() error {
+	names := map[string]bool{}
+	for i, a := range s.ScheduledActions {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("invalid scaling.scheduledActions[%d]: %v", i, err)
+		}
+		if names[a.Name] {
+			return fmt.Errorf("invalid scaling.scheduledActions[%d]: name %s is used more than once", i, a.Name)
+		}
+		names[a.Name] = true
+	}
+	names = map[string]bool{}
+	for i, p := range s.TargetTrackingPolicies {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("invalid scaling.targetTrackingPolicies[%d]: %v", i, err)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("invalid scaling.targetTrackingPolicies[%d]: name %s is used more than once", i, p.Name)
+		}
+		names[p.Name] = true
+	}
+	return nil
+}
+
+func (a ScheduledAction) LogicalName() string {
+	return "ScheduledAction" + a.Name
+}
+
+func (a ScheduledAction) Validate() error {
+	if !scalingNameRegexp.MatchString(a.Name) {
+		return fmt.Errorf("name must be a non-empty alphanumeric string, but was \"%s\"", a.Name)
+	}
+	if a.Recurrence == "" && a.StartTime == "" {
+		return fmt.Errorf("either recurrence or startTime must be specified")
+	}
+	if a.Recurrence != "" && len(strings.Fields(a.Recurrence)) != 5 {
+		return fmt.Errorf("recurrence must be a cron expression with 5 fields like \"0 20 * * 1-5\", but was \"%s\"", a.Recurrence)
+	}
+	for name, t := range map[string]string{"startTime": a.StartTime, "endTime": a.EndTime} {
+		if t != "" && !timestampRegexp.MatchString(t) {
+			return fmt.Errorf("%s must be a time in UTC like 2017-12-31T00:00:00Z, but was \"%s\"", name, t)
+		}
+	}
+	if a.MinSize == nil && a.MaxSize == nil && a.DesiredCapacity == nil {
+		return fmt.Errorf("at least one of minSize, maxSize and desiredCapacity must be specified")
+	}
+	for name, size := range map[string]*int{"minSize": a.MinSize, "maxSize": a.MaxSize, "desiredCapacity": a.DesiredCapacity} {
+		if size != nil && *size < 0 {
+			return fmt.Errorf("%s must be zero or greater, but was %d", name, *size)
+		}
+	}
+	if a.MinSize != nil && a.MaxSize != nil && *a.MinSize > *a.MaxSize {
+		return fmt.Errorf("minSize(%d) must be less than or equal to maxSize(%d)", *a.MinSize, *a.MaxSize)
+	}
+	if a.DesiredCapacity != nil {
+		if a.MinSize != nil && *a.DesiredCapacity < *a.MinSize {
+			return fmt.Errorf("desiredCapacity(%d) must be greater than or equal to minSize(%d)", *a.DesiredCapacity, *a.MinSize)
+		}
+		if a.MaxSize != nil && *a.DesiredCapacity > *a.MaxSize {
+			return fmt.Errorf("desiredCapacity(%d) must be less than or equal to maxSize(%d)", *a.DesiredCapacity, *a.MaxSize)
+		}
+	}
+	return nil
+}
+
+func (p TargetTrackingPolicy) LogicalName() string {
+	return "TargetTrackingPolicy" + p.Name
+}
+
+func (p TargetTrackingPolicy) Validate() error {
+	if !scalingNameRegexp.MatchString(p.Name) {
+		return fmt.Errorf("name must be a non-empty alphanumeric string, but was \"%s\"", p.Name)
+	}
+	if !isPredefinedMetric(p.PredefinedMetric) {
+		return fmt.Errorf("predefinedMetric must be one of %s, but was \"%s\"", strings.Join(predefinedMetrics, ", "), p.PredefinedMetric)
+	}
+	if p.TargetValue <= 0 {
+		return fmt.Errorf("targetValue must be greater than 0, but was %v", p.TargetValue)
+	}
+	if p.EstimatedInstanceWarmup < 0 {
+		return fmt.Errorf("estimatedInstanceWarmup must be zero or greater, but was %d", p.EstimatedInstanceWarmup)
+	}
+	return nil
+}
+
+func isPredefinedMetric(m string) bool {
+	for _, metric := range predefinedMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScalingValidate(t *testing.T) {
+	testCases := []struct {
+		scaling       Scaling
+		expectedError string
+	}{
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "scale-down", Recurrence: "0 20 * * *", DesiredCapacity: intp(0)}}},
+			expectedError: "name must be a non-empty alphanumeric string",
+		},
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "ScaleDown", DesiredCapacity: intp(0)}}},
+			expectedError: "either recurrence or startTime must be specified",
+		},
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "ScaleDown", Recurrence: "0 20 * *", DesiredCapacity: intp(0)}}},
+			expectedError: "recurrence must be a cron expression with 5 fields",
+		},
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "ScaleDown", StartTime: "2017-12-31 00:00:00", DesiredCapacity: intp(0)}}},
+			expectedError: "startTime must be a time in UTC",
+		},
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "ScaleDown", Recurrence: "0 20 * * *"}}},
+			expectedError: "at least one of minSize, maxSize and desiredCapacity must be specified",
+		},
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "ScaleDown", Recurrence: "0 20 * * *", MinSize: intp(2), MaxSize: intp(1)}}},
+			expectedError: "minSize(2) must be less than or equal to maxSize(1)",
+		},
+		{
+			scaling:       Scaling{ScheduledActions: []ScheduledAction{{Name: "ScaleDown", Recurrence: "0 20 * * *", MaxSize: intp(1), DesiredCapacity: intp(2)}}},
+			expectedError: "desiredCapacity(2) must be less than or equal to maxSize(1)",
+		},
+		{
+			scaling: Scaling{ScheduledActions: []ScheduledAction{
+				{Name: "ScaleDown", Recurrence: "0 20 * * *", DesiredCapacity: intp(0)},
+				{Name: "ScaleDown", Recurrence: "0 8 * * *", DesiredCapacity: intp(1)},
+			}},
+			expectedError: "scaling.scheduledActions[1]: name ScaleDown is used more than once",
+		},
+		{
+			scaling:       Scaling{TargetTrackingPolicies: []TargetTrackingPolicy{{Name: "CPU", PredefinedMetric: "ALBRequestCountPerTarget", TargetValue: 50}}},
+			expectedError: "predefinedMetric must be one of",
+		},
+		{
+			scaling:       Scaling{TargetTrackingPolicies: []TargetTrackingPolicy{{Name: "CPU", PredefinedMetric: "ASGAverageCPUUtilization"}}},
+			expectedError: "targetValue must be greater than 0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.scaling.Validate()
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("expected an error containing \"%s\" for %+v, but was: %v", testCase.expectedError, testCase.scaling, err)
+		}
+	}
+
+	valid := Scaling{
+		ScheduledActions: []ScheduledAction{
+			{Name: "ScaleDownAtNight", Recurrence: "0 20 * * 1-5", MinSize: intp(0), DesiredCapacity: intp(0)},
+			{Name: "ScaleUpInTheMorning", Recurrence: "0 8 * * 1-5", StartTime: "2017-12-31T00:00:00Z", MinSize: intp(1), MaxSize: intp(3), DesiredCapacity: intp(2)},
+		},
+		TargetTrackingPolicies: []TargetTrackingPolicy{
+			{Name: "CPU", PredefinedMetric: "ASGAverageCPUUtilization", TargetValue: 50, EstimatedInstanceWarmup: 300},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected the scaling config to be valid, but it was not: %v", err)
+	}
+	if !valid.ScalesOutFromZero() {
+		t.Errorf("expected the scheduled actions to scale out the node pool from zero")
+	}
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	SecretBackendKMS            = "kms"
+	SecretBackendSSM            = "ssm"
+	SecretBackendSecretsManager = "secretsmanager"
+	SecretBackendVault          = "vault"
+)
+
+// SecretBackend is where kube-aws stores credentials(TLS assets, tokens, etc.) for nodes and
+// how nodes fetch and decrypt them on boot.
+// `kms`, the default, embeds KMS-encrypted credentials into userdata.
+// `ssm` and `secretsmanager` store credentials in AWS SSM Parameter Store SecureStrings or AWS Secrets Manager secrets
+// encrypted with `kmsKeyArn` and embed only references to them into userdata. Parameters and secrets are named after the paths of
+// credentials relative to the credentials directory under the prefix so that they are overwritten when credentials change and deleted on `kube-aws destroy`.
+// `vault` encrypts credentials with a HashiCorp Vault transit key so that `kmsKeyArn` isn't required at all.
+type SecretBackend struct {
+	Type           string                      `yaml:"type,omitempty"`
+	SSM            SSMSecretBackend            `yaml:"ssm,omitempty"`
+	SecretsManager SecretsManagerSecretBackend `yaml:"secretsManager,omitempty"`
+	Vault          VaultSecretBackend          `yaml:"vault,omitempty"`
+}
+
+type SSMSecretBackend struct {
+	// ParameterPrefix is the path prefix of SSM parameters created by kube-aws. Defaults to `/kube-aws/<clusterName>`
+	ParameterPrefix string `yaml:"parameterPrefix,omitempty"`
+}
+
+type SecretsManagerSecretBackend struct {
+	// SecretPrefix is the name prefix of secrets created by kube-aws. Defaults to `kube-aws/<clusterName>`
+	SecretPrefix string `yaml:"secretPrefix,omitempty"`
+}
+
+type VaultSecretBackend struct {
+	// Address is the URL of the Vault server reachable from both kube-aws and nodes e.g. `https://vault.example.com:8200`
+	Address string `yaml:"address,omitempty"`
+	// TransitMount is the path the transit secrets engine is mounted at. Defaults to `transit`
+	TransitMount string `yaml:"transitMount,omitempty"`
+	// TransitKey is the name of the transit key used to encrypt credentials
+	TransitKey string `yaml:"transitKey,omitempty"`
+	// AuthRole is the name of the Vault role nodes log in as via the AWS auth method
+	AuthRole string `yaml:"authRole,omitempty"`
+	// Image is the container image providing the `vault` command to nodes
+	Image Image `yaml:"image,omitempty"`
+}
+
+func NewDefaultSecretBackend() SecretBackend {
+	return SecretBackend{
+		Type: SecretBackendKMS,
+		Vault: VaultSecretBackend{
+			TransitMount: "transit",
+			Image:        Image{Repo: "vault", Tag: "0.9.0", RktPullDocker: true},
+		},
+	}
+}
+
+func (b *SecretBackend) SetDefaults(clusterName string) {
+	if b.Type == "" {
+		b.Type = SecretBackendKMS
+	}
+	if b.SSM.ParameterPrefix == "" {
+		b.SSM.ParameterPrefix = fmt.Sprintf("/kube-aws/%s", clusterName)
+	}
+	if b.SecretsManager.SecretPrefix == "" {
+		b.SecretsManager.SecretPrefix = fmt.Sprintf("kube-aws/%s", clusterName)
+	}
+}
+
+func (b SecretBackend) IsKMS() bool {
+	return b.Type == "" || b.Type == SecretBackendKMS
+}
+
+func (b SecretBackend) IsSSM() bool {
+	return b.Type == SecretBackendSSM
+}
+
+func (b SecretBackend) IsSecretsManager() bool {
+	return b.Type == SecretBackendSecretsManager
+}
+
+func (b SecretBackend) IsVault() bool {
+	return b.Type == SecretBackendVault
+}
+
+// UsesKMSKey returns true when credentials are encrypted with `kmsKeyArn` hence nodes need to be allowed to decrypt with it
+func (b SecretBackend) UsesKMSKey() bool {
+	return b.IsKMS() || b.IsSSM() || b.IsSecretsManager()
+}
+
+// ResourceARN returns the ARN matching the SSM parameters or the secrets storing all the credentials of the cluster
+func (b SecretBackend) ResourceARN(region Region) string {
+	if b.IsSecretsManager() {
+		return fmt.Sprintf("arn:%s:secretsmanager:%s:*:secret:%s/*", region.Partition(), region, b.SecretsManager.SecretPrefix)
+	}
+	return fmt.Sprintf("arn:%s:ssm:%s:*:parameter%s/*", region.Partition(), region, b.SSM.ParameterPrefix)
+}
+
+// ResourceARNs returns the ARNs of the SSM parameters or the secrets storing the credentials named `fileNames`
+func (b SecretBackend) ResourceARNs(region Region, fileNames []string) []string {
+	arns := []string{}
+	for _, f := range fileNames {
+		if b.IsSecretsManager() {
+			// Secrets Manager appends a hyphen and 6 random characters to the name of each secret in its ARN
+			arns = append(arns, fmt.Sprintf("arn:%s:secretsmanager:%s:*:secret:%s/%s-??????", region.Partition(), region, b.SecretsManager.SecretPrefix, f))
+		} else {
+			arns = append(arns, fmt.Sprintf("arn:%s:ssm:%s:*:parameter%s/%s", region.Partition(), region, b.SSM.ParameterPrefix, f))
+		}
+	}
+	return arns
+}
+
+// Image returns the container image used by nodes to fetch and decrypt credentials
+func (b SecretBackend) Image(awsCliImage Image) *Image {
+	if b.IsVault() {
+		return &b.Vault.Image
+	}
+	return &awsCliImage
+}
+
+// Shell returns the shell available in the image returned by `Image`
+func (b SecretBackend) Shell() string {
+	if b.IsVault() {
+		return "/bin/sh"
+	}
+	return "/bin/bash"
+}
+
+// DecryptPreamble returns shell commands run once before credentials are decrypted on nodes
+func (b SecretBackend) DecryptPreamble() string {
+	if b.IsVault() {
+		return fmt.Sprintf("export VAULT_ADDR=%s; vault login -method=aws role=%s >/dev/null", b.Vault.Address, b.Vault.AuthRole)
+	}
+	return "true"
+}
+
+// DecryptCommand returns a shell pipeline which writes the plaintext of the credential stored at `path` to stdout
+func (b SecretBackend) DecryptCommand(region Region, path string) string {
+	switch b.Type {
+	case SecretBackendSSM:
+		return fmt.Sprintf(`/usr/bin/aws --region %s ssm get-parameter --name "$(cut -d: -f1 %s)" --with-decryption --output text --query Parameter.Value`, region, path)
+	case SecretBackendSecretsManager:
+		return fmt.Sprintf(`/usr/bin/aws --region %s secretsmanager get-secret-value --secret-id "$(cut -d: -f1 %s)" --version-id "$(cut -d: -f2 %s)" --output text --query SecretString`, region, path, path)
+	case SecretBackendVault:
+		return fmt.Sprintf(`vault write -field=plaintext %s/decrypt/%s ciphertext="$(cat %s)" | base64 -d`, b.Vault.TransitMount, b.Vault.TransitKey, path)
+	default:
+		return fmt.Sprintf(`/usr/bin/aws --region %s kms decrypt --ciphertext-blob fileb://%s --output text --query Plaintext | base64 -d`, region, path)
+	}
+}
+
+func (b SecretBackend) Validate() error {
+	switch b.Type {
+	case "", SecretBackendKMS, SecretBackendSecretsManager:
+	case SecretBackendSSM:
+		if b.SSM.ParameterPrefix != "" && !strings.HasPrefix(b.SSM.ParameterPrefix, "/") {
+			return fmt.Errorf("secretBackend.ssm.parameterPrefix must start with \"/\" but was \"%s\"", b.SSM.ParameterPrefix)
+		}
+	case SecretBackendVault:
+		if b.Vault.Address == "" {
+			return errors.New("secretBackend.vault.address must be set when secretBackend.type is \"vault\"")
+		}
+		if b.Vault.TransitKey == "" {
+			return errors.New("secretBackend.vault.transitKey must be set when secretBackend.type is \"vault\"")
+		}
+		if b.Vault.AuthRole == "" {
+			return errors.New("secretBackend.vault.authRole must be set when secretBackend.type is \"vault\"")
+		}
+	default:
+		return fmt.Errorf("secretBackend.type must be one of \"%s\", \"%s\", \"%s\" or \"%s\" but was \"%s\"",
+			SecretBackendKMS, SecretBackendSSM, SecretBackendSecretsManager, SecretBackendVault, b.Type)
+	}
+	return nil
+}
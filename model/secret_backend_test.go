@@ -0,0 +1,122 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecretBackendValidate(t *testing.T) {
+	testCases := []struct {
+		backend SecretBackend
+		isValid bool
+	}{
+		// Valid, defaults to kms
+		{
+			backend: SecretBackend{},
+			isValid: true,
+		},
+
+		// Valid, ssm with an absolute parameter prefix
+		{
+			backend: SecretBackend{Type: "ssm", SSM: SSMSecretBackend{ParameterPrefix: "/kube-aws/mycluster"}},
+			isValid: true,
+		},
+
+		// Invalid, ssm with a relative parameter prefix
+		{
+			backend: SecretBackend{Type: "ssm", SSM: SSMSecretBackend{ParameterPrefix: "kube-aws/mycluster"}},
+			isValid: false,
+		},
+
+		// Invalid, vault without a transit key
+		{
+			backend: SecretBackend{Type: "vault", Vault: VaultSecretBackend{Address: "https://vault:8200", AuthRole: "nodes"}},
+			isValid: false,
+		},
+
+		// Valid, vault
+		{
+			backend: SecretBackend{Type: "vault", Vault: VaultSecretBackend{Address: "https://vault:8200", TransitKey: "kube-aws", AuthRole: "nodes"}},
+			isValid: true,
+		},
+
+		// Invalid, unknown type
+		{
+			backend: SecretBackend{Type: "gpg"},
+			isValid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.backend.Validate()
+		if testCase.isValid && err != nil {
+			t.Errorf("Expected secret backend %+v to be valid, but it was not: %v", testCase.backend, err)
+		}
+
+		if !testCase.isValid && err == nil {
+			t.Errorf("Expected secret backend %+v to be invalid, but it was not", testCase.backend)
+		}
+	}
+}
+
+func TestSecretBackendDecryptCommand(t *testing.T) {
+	region := RegionForName("us-west-1")
+
+	testCases := []struct {
+		backendType string
+		expected    string
+	}{
+		{
+			backendType: "kms",
+			expected:    "kms decrypt --ciphertext-blob fileb://$encKey",
+		},
+		{
+			backendType: "ssm",
+			expected:    `ssm get-parameter --name "$(cut -d: -f1 $encKey)" --with-decryption`,
+		},
+		{
+			backendType: "secretsmanager",
+			expected:    `secretsmanager get-secret-value --secret-id "$(cut -d: -f1 $encKey)" --version-id "$(cut -d: -f2 $encKey)"`,
+		},
+		{
+			backendType: "vault",
+			expected:    `vault write -field=plaintext transit/decrypt/kube-aws ciphertext="$(cat $encKey)"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		backend := NewDefaultSecretBackend()
+		backend.Type = testCase.backendType
+		backend.Vault.TransitKey = "kube-aws"
+
+		actual := backend.DecryptCommand(region, "$encKey")
+		if !strings.Contains(actual, testCase.expected) {
+			t.Errorf("Expected decrypt command for %s to contain \"%s\", but was \"%s\"", testCase.backendType, testCase.expected, actual)
+		}
+	}
+}
+
+func TestSecretBackendResourceARNs(t *testing.T) {
+	region := RegionForName("us-west-1")
+	backend := NewDefaultSecretBackend()
+	backend.SetDefaults("mycluster")
+
+	backend.Type = "ssm"
+	if actual := backend.ResourceARN(region); actual != "arn:aws:ssm:us-west-1:*:parameter/kube-aws/mycluster/*" {
+		t.Errorf("unexpected ssm resource arn: %s", actual)
+	}
+	actual := backend.ResourceARNs(region, []string{"etcd-node-1.pem"})
+	if !reflect.DeepEqual(actual, []string{"arn:aws:ssm:us-west-1:*:parameter/kube-aws/mycluster/etcd-node-1.pem"}) {
+		t.Errorf("unexpected ssm resource arns: %v", actual)
+	}
+
+	backend.Type = "secretsmanager"
+	if actual := backend.ResourceARN(region); actual != "arn:aws:secretsmanager:us-west-1:*:secret:kube-aws/mycluster/*" {
+		t.Errorf("unexpected secretsmanager resource arn: %s", actual)
+	}
+	actual = backend.ResourceARNs(region, []string{"etcd-node-1.pem"})
+	if !reflect.DeepEqual(actual, []string{"arn:aws:secretsmanager:us-west-1:*:secret:kube-aws/mycluster/etcd-node-1.pem-??????"}) {
+		t.Errorf("unexpected secretsmanager resource arns: %v", actual)
+	}
+}
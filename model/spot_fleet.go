@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // UnitRootVolumeSize/IOPS are used for spot fleets instead of WorkerRootVolumeSize/IOPS,
@@ -16,23 +17,97 @@ type SpotFleet struct {
 	UnitRootVolumeSize   int                   `yaml:"unitRootVolumeSize"`
 	UnitRootVolumeIOPS   int                   `yaml:"unitRootVolumeIOPS"`
 	LaunchSpecifications []LaunchSpecification `yaml:"launchSpecifications,omitempty"`
-	UnknownKeys          `yaml:",inline"`
+	// AllocationStrategy is one of "diversified", "lowestPrice" and "capacityOptimized". Defaults to "diversified"
+	AllocationStrategy string `yaml:"allocationStrategy,omitempty"`
+	// InstancePoolsToUseCount is the number of the cheapest spot instance pools to allocate capacity across.
+	// Only valid with the "lowestPrice" allocation strategy
+	InstancePoolsToUseCount int `yaml:"instancePoolsToUseCount,omitempty"`
+	// ReplaceUnhealthyInstances makes the fleet replace instances failing EC2 health checks
+	ReplaceUnhealthyInstances bool `yaml:"replaceUnhealthyInstances,omitempty"`
+	// CapacityRebalance makes the fleet launch a replacement instance when a spot instance receives a rebalance recommendation,
+	// which usually arrives before the two-minute interruption notice
+	CapacityRebalance bool `yaml:"capacityRebalance,omitempty"`
+	// OnDemandTargetCapacity is the portion of TargetCapacity fulfilled by on-demand instances.
+	// Launch templates are created for the launch specifications when this is specified, as Spot Fleet requires them to launch on-demand instances
+	OnDemandTargetCapacity int `yaml:"onDemandTargetCapacity,omitempty"`
+	UnknownKeys            `yaml:",inline"`
+}
+
+var allocationStrategies = []string{
+	"diversified",
+	"lowestPrice",
+	"capacityOptimized",
 }
 
 func (f SpotFleet) Enabled() bool {
 	return f.TargetCapacity > 0
 }
 
+func (f SpotFleet) AllocationStrategyOrDefault() string {
+	if f.AllocationStrategy == "" {
+		return "diversified"
+	}
+	return f.AllocationStrategy
+}
+
+// UsesLaunchTemplates returns true when the fleet is rendered with launch templates instead of launch specifications
+func (f SpotFleet) UsesLaunchTemplates() bool {
+	return f.OnDemandTargetCapacity > 0
+}
+
 func (c SpotFleet) Validate() error {
+	if len(c.LaunchSpecifications) == 0 {
+		return fmt.Errorf("at least one launchSpecification must be specified")
+	}
+
+	instanceTypes := map[string]bool{}
+	minWeightedCapacity := 0
 	for i, spec := range c.LaunchSpecifications {
 		if err := spec.Validate(); err != nil {
 			return fmt.Errorf("invalid launchSpecification at index %d: %v", i, err)
 		}
+		if instanceTypes[spec.InstanceType] {
+			return fmt.Errorf("invalid launchSpecification at index %d: instance type %s is specified more than once", i, spec.InstanceType)
+		}
+		instanceTypes[spec.InstanceType] = true
+		if minWeightedCapacity == 0 || spec.WeightedCapacity < minWeightedCapacity {
+			minWeightedCapacity = spec.WeightedCapacity
+		}
+	}
+
+	if minWeightedCapacity > c.TargetCapacity {
+		return fmt.Errorf("targetCapacity(%d) must be greater than or equal to the smallest weightedCapacity(%d) of launchSpecifications", c.TargetCapacity, minWeightedCapacity)
+	}
+
+	if c.OnDemandTargetCapacity < 0 || c.OnDemandTargetCapacity > c.TargetCapacity {
+		return fmt.Errorf("onDemandTargetCapacity must be an integer between 0 and targetCapacity(%d), but was %d", c.TargetCapacity, c.OnDemandTargetCapacity)
+	}
+	if c.OnDemandTargetCapacity > 0 && c.OnDemandTargetCapacity < minWeightedCapacity {
+		return fmt.Errorf("onDemandTargetCapacity(%d) must be greater than or equal to the smallest weightedCapacity(%d) of launchSpecifications", c.OnDemandTargetCapacity, minWeightedCapacity)
+	}
+
+	if !isAllocationStrategy(c.AllocationStrategyOrDefault()) {
+		return fmt.Errorf("allocationStrategy must be one of %s, but was \"%s\"", strings.Join(allocationStrategies, ", "), c.AllocationStrategy)
+	}
+	if c.InstancePoolsToUseCount < 0 {
+		return fmt.Errorf("instancePoolsToUseCount must be zero or greater, but was %d", c.InstancePoolsToUseCount)
+	}
+	if c.InstancePoolsToUseCount > 0 && c.AllocationStrategyOrDefault() != "lowestPrice" {
+		return fmt.Errorf("instancePoolsToUseCount can only be specified with the \"lowestPrice\" allocationStrategy")
 	}
 
 	return nil
 }
 
+func isAllocationStrategy(s string) bool {
+	for _, strategy := range allocationStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *SpotFleet) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type t SpotFleet
 	work := t(newDefaultSpotFleet())
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpotFleetValidate(t *testing.T) {
+	specs := []LaunchSpecification{
+		{WeightedCapacity: 2, InstanceType: "c4.xlarge", SpotPrice: "0.12", RootVolume: NewGp2RootVolume(60)},
+		{WeightedCapacity: 4, InstanceType: "c4.2xlarge", SpotPrice: "0.24", RootVolume: NewGp2RootVolume(120)},
+	}
+
+	testCases := []struct {
+		fleet         SpotFleet
+		expectedError string
+	}{
+		{
+			fleet:         SpotFleet{TargetCapacity: 10},
+			expectedError: "at least one launchSpecification must be specified",
+		},
+		{
+			fleet: SpotFleet{TargetCapacity: 10, LaunchSpecifications: []LaunchSpecification{
+				{WeightedCapacity: 0, InstanceType: "c4.large", RootVolume: NewGp2RootVolume(30)},
+			}},
+			expectedError: "weightedCapacity must be 1 or greater",
+		},
+		{
+			fleet: SpotFleet{TargetCapacity: 10, LaunchSpecifications: []LaunchSpecification{
+				{WeightedCapacity: 1, InstanceType: "c4.large", SpotPrice: "cheap", RootVolume: NewGp2RootVolume(30)},
+			}},
+			expectedError: "spotPrice must be a price per instance hour",
+		},
+		{
+			fleet:         SpotFleet{TargetCapacity: 10, LaunchSpecifications: []LaunchSpecification{specs[0], specs[0]}},
+			expectedError: "instance type c4.xlarge is specified more than once",
+		},
+		{
+			fleet:         SpotFleet{TargetCapacity: 1, LaunchSpecifications: specs},
+			expectedError: "targetCapacity(1) must be greater than or equal to the smallest weightedCapacity(2)",
+		},
+		{
+			fleet:         SpotFleet{TargetCapacity: 10, OnDemandTargetCapacity: 11, LaunchSpecifications: specs},
+			expectedError: "onDemandTargetCapacity must be an integer between 0 and targetCapacity(10)",
+		},
+		{
+			fleet:         SpotFleet{TargetCapacity: 10, OnDemandTargetCapacity: 1, LaunchSpecifications: specs},
+			expectedError: "onDemandTargetCapacity(1) must be greater than or equal to the smallest weightedCapacity(2)",
+		},
+		{
+			fleet:         SpotFleet{TargetCapacity: 10, AllocationStrategy: "cheapest", LaunchSpecifications: specs},
+			expectedError: "allocationStrategy must be one of",
+		},
+		{
+			fleet:         SpotFleet{TargetCapacity: 10, InstancePoolsToUseCount: 2, LaunchSpecifications: specs},
+			expectedError: "instancePoolsToUseCount can only be specified with the \"lowestPrice\" allocationStrategy",
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.fleet.Validate()
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("expected an error containing \"%s\" for %+v, but was: %v", testCase.expectedError, testCase.fleet, err)
+		}
+	}
+
+	valid := SpotFleet{
+		TargetCapacity:          10,
+		OnDemandTargetCapacity:  4,
+		AllocationStrategy:      "lowestPrice",
+		InstancePoolsToUseCount: 2,
+		LaunchSpecifications:    specs,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected the spot fleet to be valid, but it was not: %v", err)
+	}
+	if !valid.UsesLaunchTemplates() {
+		t.Errorf("expected the spot fleet to use launch templates to launch on-demand instances")
+	}
+	if (SpotFleet{}).AllocationStrategyOrDefault() != "diversified" {
+		t.Errorf("expected the allocation strategy to default to diversified")
+	}
+}
@@ -26,10 +26,12 @@ type UserData struct {
 }
 
 type UserDataPart struct {
-	Asset    Asset
-	tmpl     *template.Template
-	tmplData interface{}
-	validate UserDataValidateFunc
+	Asset Asset
+	// PerInstanceAssets are populated instead of Asset when the part is rendered differently for each instance
+	PerInstanceAssets []Asset
+	tmpl              *template.Template
+	tmplData          interface{}
+	validate          UserDataValidateFunc
 }
 
 type PartDesc struct {
@@ -97,6 +99,14 @@ func NewUserData(templateFile string, context interface{}, opts ...UserDataOptio
 	return v, nil
 }
 
+// AssetFor returns the asset the instance at the index should fetch this part from
+func (self UserDataPart) AssetFor(index int) Asset {
+	if index < len(self.PerInstanceAssets) {
+		return self.PerInstanceAssets[index]
+	}
+	return self.Asset
+}
+
 func (self UserDataPart) Base64(compress bool, extra ...map[string]interface{}) (string, error) {
 	content, err := self.Template(extra...)
 	if err != nil {
@@ -0,0 +1,216 @@
+// Package noderoller replaces instances in an autoscaling group launched with outdated launch configurations batch by batch,
+// draining the kubernetes nodes backed by them before terminating them
+package noderoller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/kubernetes-incubator/kube-aws/kubenode"
+)
+
+// ErrPaused is returned when the roll is paused. A paused roll is resumed by rolling the same group again
+var ErrPaused = errors.New("paused")
+
+type AutoScalingService interface {
+	DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+	TerminateInstanceInAutoScalingGroup(*autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error)
+}
+
+type EC2Service interface {
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+}
+
+type Options struct {
+	// MaxUnavailable is the maximum number of nodes drained and terminated at once. Defaults to 1
+	MaxUnavailable int
+	// DrainTimeout is how long to wait for pods to be evicted from each node
+	DrainTimeout time.Duration
+	// ReadyTimeout is how long to wait for instances replacing terminated ones to be launched and become Ready nodes
+	ReadyTimeout time.Duration
+	// PollInterval is the interval to poll the autoscaling group and nodes. Defaults to 10 seconds
+	PollInterval time.Duration
+	// Pause pauses the roll once the batch in progress is done when it is closed or receives a value
+	Pause <-chan struct{}
+}
+
+type Roller struct {
+	asg   AutoScalingService
+	ec2   EC2Service
+	nodes kubenode.Client
+	opts  Options
+	Out   io.Writer
+}
+
+func New(asg AutoScalingService, ec2 EC2Service, nodes kubenode.Client, opts Options) *Roller {
+	if opts.MaxUnavailable < 1 {
+		opts.MaxUnavailable = 1
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 10 * time.Second
+	}
+	return &Roller{
+		asg:   asg,
+		ec2:   ec2,
+		nodes: nodes,
+		opts:  opts,
+		Out:   ioutil.Discard,
+	}
+}
+
+// Roll replaces the outdated instances in the autoscaling group until none of them remains or the roll is paused.
+// In each batch, up to MaxUnavailable nodes are cordoned and drained, honoring PodDisruptionBudgets, and their instances are
+// terminated without decrementing the desired capacity. The next batch starts once the replacements become Ready
+func (r *Roller) Roll(groupName string) error {
+	for {
+		select {
+		case <-r.opts.Pause:
+			return ErrPaused
+		default:
+		}
+
+		group, err := r.describe(groupName)
+		if err != nil {
+			return err
+		}
+
+		outdated := outdatedInstanceIDs(group)
+		if len(outdated) == 0 {
+			return nil
+		}
+
+		batch := outdated
+		if len(batch) > r.opts.MaxUnavailable {
+			batch = batch[:r.opts.MaxUnavailable]
+		}
+		fmt.Fprintf(r.Out, "Replacing %d of %d outdated instances in %s: %s\n", len(batch), len(outdated), groupName, strings.Join(batch, ", "))
+
+		nodes, err := r.nodeNames(batch)
+		if err != nil {
+			return err
+		}
+		if err := kubenode.CordonAndDrain(r.nodes, nodes, r.opts.DrainTimeout); err != nil {
+			return err
+		}
+
+		for _, id := range batch {
+			_, err := r.asg.TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+				InstanceId:                     aws.String(id),
+				ShouldDecrementDesiredCapacity: aws.Bool(false),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to terminate instance %s: %v", id, err)
+			}
+		}
+
+		if err := r.waitForReplacements(groupName, batch); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Roller) describe(groupName string) (*autoscaling.Group, error) {
+	out, err := r.asg.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String(groupName)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe autoscaling group %s: %v", groupName, err)
+	}
+	if len(out.AutoScalingGroups) == 0 {
+		return nil, fmt.Errorf("autoscaling group %s not found", groupName)
+	}
+	return out.AutoScalingGroups[0], nil
+}
+
+// waitForReplacements waits until the terminated instances disappear from the group, the group gets as many up-to-date
+// instances in service as its desired capacity, and they become Ready nodes
+func (r *Roller) waitForReplacements(groupName string, terminated []string) error {
+	deadline := time.Now().Add(r.opts.ReadyTimeout)
+	for {
+		group, err := r.describe(groupName)
+		if err != nil {
+			return err
+		}
+
+		if replaced(group, terminated) {
+			ids := []string{}
+			for _, i := range group.Instances {
+				if !outdated(group, i) {
+					ids = append(ids, aws.StringValue(i.InstanceId))
+				}
+			}
+			nodes, err := r.nodeNames(ids)
+			if err != nil {
+				return err
+			}
+			return kubenode.WaitUntilReady(r.nodes, nodes, deadline.Sub(time.Now()), r.opts.PollInterval)
+		}
+
+		if !time.Now().Add(r.opts.PollInterval).Before(deadline) {
+			return fmt.Errorf("instances %s in autoscaling group %s were not replaced in %s", strings.Join(terminated, ", "), groupName, r.opts.ReadyTimeout)
+		}
+		time.Sleep(r.opts.PollInterval)
+	}
+}
+
+func replaced(group *autoscaling.Group, terminated []string) bool {
+	inService := 0
+	for _, i := range group.Instances {
+		id := aws.StringValue(i.InstanceId)
+		for _, t := range terminated {
+			if id == t {
+				return false
+			}
+		}
+		if !outdated(group, i) && aws.StringValue(i.LifecycleState) == autoscaling.LifecycleStateInService {
+			inService++
+		}
+	}
+	return int64(inService) >= aws.Int64Value(group.DesiredCapacity)-int64(len(outdatedInstanceIDs(group)))
+}
+
+func outdated(group *autoscaling.Group, i *autoscaling.Instance) bool {
+	return aws.StringValue(i.LaunchConfigurationName) != aws.StringValue(group.LaunchConfigurationName)
+}
+
+// outdatedInstanceIDs returns sorted IDs of the instances in service launched with launch configurations other than the group's current one
+func outdatedInstanceIDs(group *autoscaling.Group) []string {
+	ids := []string{}
+	for _, i := range group.Instances {
+		if outdated(group, i) && aws.StringValue(i.LifecycleState) == autoscaling.LifecycleStateInService {
+			ids = append(ids, aws.StringValue(i.InstanceId))
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// nodeNames returns the names of nodes backed by the instances, which are private DNS names of the instances as kubelets are run
+// with the aws cloud provider
+func (r *Roller) nodeNames(instanceIDs []string) ([]string, error) {
+	if len(instanceIDs) == 0 {
+		return []string{}, nil
+	}
+	out, err := r.ec2.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances %s: %v", strings.Join(instanceIDs, ", "), err)
+	}
+	names := []string{}
+	for _, res := range out.Reservations {
+		for _, i := range res.Instances {
+			names = append(names, aws.StringValue(i.PrivateDnsName))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,190 @@
+package noderoller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/kubernetes-incubator/kube-aws/kubenode"
+)
+
+// fakeAutoScaling replaces a terminated instance with a new one launched with the current launch configuration
+type fakeAutoScaling struct {
+	group      *autoscaling.Group
+	launched   int
+	terminated []string
+}
+
+func newFakeAutoScaling(current string, instances map[string]string) *fakeAutoScaling {
+	g := &autoscaling.Group{
+		AutoScalingGroupName:    aws.String("workers"),
+		LaunchConfigurationName: aws.String(current),
+		DesiredCapacity:         aws.Int64(int64(len(instances))),
+	}
+	for id, lc := range instances {
+		g.Instances = append(g.Instances, &autoscaling.Instance{
+			InstanceId:              aws.String(id),
+			LaunchConfigurationName: aws.String(lc),
+			LifecycleState:          aws.String(autoscaling.LifecycleStateInService),
+		})
+	}
+	return &fakeAutoScaling{group: g}
+}
+
+func (s *fakeAutoScaling) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: []*autoscaling.Group{s.group}}, nil
+}
+
+func (s *fakeAutoScaling) TerminateInstanceInAutoScalingGroup(input *autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error) {
+	if aws.BoolValue(input.ShouldDecrementDesiredCapacity) {
+		return nil, fmt.Errorf("unexpected decrement of the desired capacity")
+	}
+	id := aws.StringValue(input.InstanceId)
+	s.terminated = append(s.terminated, id)
+	instances := []*autoscaling.Instance{}
+	for _, i := range s.group.Instances {
+		if aws.StringValue(i.InstanceId) != id {
+			instances = append(instances, i)
+		}
+	}
+	s.launched++
+	s.group.Instances = append(instances, &autoscaling.Instance{
+		InstanceId:              aws.String(fmt.Sprintf("i-new%d", s.launched)),
+		LaunchConfigurationName: s.group.LaunchConfigurationName,
+		LifecycleState:          aws.String(autoscaling.LifecycleStateInService),
+	})
+	return &autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil
+}
+
+// fakeEC2 names nodes after instance IDs
+type fakeEC2 struct{}
+
+func (s fakeEC2) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	instances := []*ec2.Instance{}
+	for _, id := range input.InstanceIds {
+		instances = append(instances, &ec2.Instance{
+			InstanceId:     id,
+			PrivateDnsName: aws.String("node-" + aws.StringValue(id)),
+		})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: instances}}}, nil
+}
+
+func testOptions() Options {
+	return Options{
+		DrainTimeout: time.Minute,
+		ReadyTimeout: time.Second,
+		PollInterval: time.Millisecond,
+	}
+}
+
+func TestRoll(t *testing.T) {
+	asg := newFakeAutoScaling("lc2", map[string]string{"i-1": "lc1", "i-2": "lc1", "i-3": "lc2"})
+	nodes := &kubenode.FakeClient{ReadyAfter: map[string]int{"node-i-new1": 2}}
+
+	if err := New(asg, fakeEC2{}, nodes, testOptions()).Roll("workers"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(asg.terminated, []string{"i-1", "i-2"}) {
+		t.Errorf("expected only outdated instances to be terminated one by one but was: %v", asg.terminated)
+	}
+
+	drained := []string{}
+	for _, c := range nodes.Calls {
+		if strings.HasPrefix(c, "drain ") {
+			drained = append(drained, c)
+		}
+	}
+	if !reflect.DeepEqual(drained, []string{"drain node-i-1", "drain node-i-2"}) {
+		t.Errorf("unexpected drains: %v", drained)
+	}
+
+	drainI2 := -1
+	lastReadyNew1 := -1
+	for i, c := range nodes.Calls {
+		if c == "drain node-i-2" {
+			drainI2 = i
+		}
+		if c == "ready node-i-new1" && drainI2 == -1 {
+			lastReadyNew1 = i
+		}
+	}
+	if lastReadyNew1 == -1 || lastReadyNew1 > drainI2 {
+		t.Errorf("expected the next batch to start after the replacement became ready: %v", nodes.Calls)
+	}
+}
+
+func TestRollMaxUnavailable(t *testing.T) {
+	asg := newFakeAutoScaling("lc2", map[string]string{"i-1": "lc1", "i-2": "lc1", "i-3": "lc1"})
+	nodes := &kubenode.FakeClient{}
+
+	opts := testOptions()
+	opts.MaxUnavailable = 2
+	if err := New(asg, fakeEC2{}, nodes, opts).Roll("workers"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"cordon node-i-1", "cordon node-i-2", "drain node-i-1", "drain node-i-2",
+	}
+	if !reflect.DeepEqual(nodes.Calls[:4], expected) {
+		t.Errorf("expected the first batch to contain two nodes: expected %v but was %v", expected, nodes.Calls)
+	}
+	if !reflect.DeepEqual(asg.terminated, []string{"i-1", "i-2", "i-3"}) {
+		t.Errorf("expected all the outdated instances to be terminated: %v", asg.terminated)
+	}
+}
+
+func TestRollDrainFailure(t *testing.T) {
+	asg := newFakeAutoScaling("lc2", map[string]string{"i-1": "lc1", "i-2": "lc1"})
+	nodes := &kubenode.FakeClient{FailDrain: map[string]bool{"node-i-1": true}}
+
+	err := New(asg, fakeEC2{}, nodes, testOptions()).Roll("workers")
+	if err == nil || !strings.Contains(err.Error(), "failed to drain node node-i-1") {
+		t.Errorf("expected a drain failure but was: %v", err)
+	}
+	if len(asg.terminated) != 0 {
+		t.Errorf("expected no instance to be terminated when draining failed: %v", asg.terminated)
+	}
+}
+
+func TestRollPauseAndResume(t *testing.T) {
+	asg := newFakeAutoScaling("lc2", map[string]string{"i-1": "lc1", "i-2": "lc1"})
+	nodes := &kubenode.FakeClient{}
+
+	pause := make(chan struct{})
+	close(pause)
+	opts := testOptions()
+	opts.Pause = pause
+	if err := New(asg, fakeEC2{}, nodes, opts).Roll("workers"); err != ErrPaused {
+		t.Fatalf("expected the roll to be paused but was: %v", err)
+	}
+	if len(asg.terminated) != 0 {
+		t.Errorf("expected no instance to be terminated while paused: %v", asg.terminated)
+	}
+
+	if err := New(asg, fakeEC2{}, nodes, testOptions()).Roll("workers"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(asg.terminated, []string{"i-1", "i-2"}) {
+		t.Errorf("expected the resumed roll to terminate all the outdated instances: %v", asg.terminated)
+	}
+}
+
+func TestRollUpToDate(t *testing.T) {
+	asg := newFakeAutoScaling("lc1", map[string]string{"i-1": "lc1"})
+	nodes := &kubenode.FakeClient{}
+
+	if err := New(asg, fakeEC2{}, nodes, testOptions()).Roll("workers"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes.Calls) != 0 || len(asg.terminated) != 0 {
+		t.Errorf("expected nothing to be done: %v %v", nodes.Calls, asg.terminated)
+	}
+}
@@ -1,8 +1,11 @@
 package clusterextension
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/kubernetes-incubator/kube-aws/cfnresource"
 	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/plugin/plugincontents"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
@@ -13,6 +16,8 @@ import (
 type ClusterExtension struct {
 	plugins []*pluginmodel.Plugin
 	configs model.PluginConfigs
+	// only limits contributions to the ones from the plugin named so, if not empty
+	only string
 }
 
 func NewExtrasFromPlugins(plugins []*pluginmodel.Plugin, configs model.PluginConfigs) ClusterExtension {
@@ -22,18 +27,94 @@ func NewExtrasFromPlugins(plugins []*pluginmodel.Plugin, configs model.PluginCon
 	}
 }
 
-type stack struct {
-	Resources map[string]interface{}
+// Only returns a ClusterExtension rendering contributions from the plugin named `name` only.
+// Other plugins are still taken into account as dependencies of the plugin
+func (e ClusterExtension) Only(name string) ClusterExtension {
+	e.only = name
+	return e
 }
 
-func (e ClusterExtension) RootStack() (*stack, error) {
+// enabled returns true and the config of the plugin when the plugin is enabled and its contributions are to be rendered
+func (e ClusterExtension) enabled(p *pluginmodel.Plugin) (bool, *model.PluginConfig) {
+	if e.only != "" && p.Name != e.only {
+		return false, nil
+	}
+	return p.EnabledIn(e.configs)
+}
+
+// valuesOf returns values of the enabled plugin and values of the plugins it requires keyed by their settings keys
+func (e ClusterExtension) valuesOf(p *pluginmodel.Plugin, pc *model.PluginConfig) (pluginmodel.Values, map[string]interface{}, error) {
+	values, err := pluginutil.ValuesFor(p, pc, "kubeAwsPlugins."+p.SettingKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deps := map[string]interface{}{}
+	for _, r := range p.Requires {
+		for _, d := range e.plugins {
+			if enabled, dc := d.EnabledIn(e.configs); enabled && r.MatchedBy(d) {
+				v, err := pluginutil.ValuesFor(d, dc, "kubeAwsPlugins."+d.SettingKey())
+				if err != nil {
+					return nil, nil, err
+				}
+				deps[d.SettingKey()] = v
+			}
+		}
+	}
+	return values, deps, nil
+}
+
+type Stack struct {
+	Resources map[string]interface{} `yaml:"resources,omitempty"`
+	Outputs   map[string]interface{} `yaml:"outputs,omitempty"`
+	// ResourcePatches are applied to the rendered stack template in order
+	ResourcePatches []cfnresource.Patch `yaml:"resourcePatches,omitempty"`
+}
+
+// patchFrom renders the patch declared by the plugin for resources of a stack
+func patchFrom(p *pluginmodel.Plugin, render *plugincontents.TemplateRenderer, patch pluginmodel.Patch) ([]cfnresource.Patch, error) {
+	if patch.Merge.Empty() && patch.JSONPatch.Empty() {
+		return []cfnresource.Patch{}, nil
+	}
+
+	r := cfnresource.Patch{
+		Source: fmt.Sprintf("plugin %s", p.Name),
+	}
+
+	if !patch.Merge.Empty() {
+		m, err := render.MapFromContents(patch.Merge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load merge patch: %v", err)
+		}
+		r.Merge = m
+	}
+
+	if !patch.JSONPatch.Empty() {
+		str, err := render.StringFrom(patch.JSONPatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load json patch: %v", err)
+		}
+		if err := json.Unmarshal([]byte(str), &r.JSONPatch); err != nil {
+			return nil, fmt.Errorf("failed to parse json patch %s: %v", str, err)
+		}
+	}
+
+	return []cfnresource.Patch{r}, nil
+}
+
+func (e ClusterExtension) RootStack() (*Stack, error) {
 	resources := map[string]interface{}{}
+	outputs := map[string]interface{}{}
+	patches := []cfnresource.Patch{}
 
 	for _, p := range e.plugins {
-		if enabled, pc := p.EnabledIn(e.configs); enabled {
-			values := pluginutil.MergeValues(p.Spec.Values, pc.Values)
+		if enabled, pc := e.enabled(p); enabled {
+			values, deps, err := e.valuesOf(p, pc)
+			if err != nil {
+				return nil, err
+			}
 
-			render := plugincontents.TemplateRendererFor(p, values)
+			render := plugincontents.TemplateRendererFor(p, values, deps)
 
 			{
 				m, err := render.MapFromContents(p.Spec.CloudFormation.Stacks.Root.Resources.Append.Contents)
@@ -44,44 +125,126 @@ func (e ClusterExtension) RootStack() (*stack, error) {
 					resources[k] = v
 				}
 			}
+
+			{
+				m, err := render.MapFromContents(p.Spec.CloudFormation.Stacks.Root.Outputs.Append.Contents)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load additional outputs for root stack: %v", err)
+				}
+				for k, v := range m {
+					outputs[k] = v
+				}
+			}
+
+			ps, err := patchFrom(p, render, p.Spec.CloudFormation.Stacks.Root.Resources.Patch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load resource patches for root stack: %v", err)
+			}
+			patches = append(patches, ps...)
 		}
 	}
 
-	return &stack{
-		Resources: resources,
+	return &Stack{
+		Resources:       resources,
+		Outputs:         outputs,
+		ResourcePatches: patches,
 	}, nil
 }
 
-type worker struct {
-	Files               []model.CustomFile
-	SystemdUnits        []model.CustomSystemdUnit
-	IAMPolicyStatements []model.IAMPolicyStatement
-	NodeLabels          model.NodeLabels
-	FeatureGates        model.FeatureGates
+type Worker struct {
+	Files               []model.CustomFile         `yaml:"files,omitempty"`
+	SystemdUnits        []model.CustomSystemdUnit  `yaml:"systemdUnits,omitempty"`
+	IAMPolicyStatements []model.IAMPolicyStatement `yaml:"iamPolicyStatements,omitempty"`
+	NodeLabels          model.NodeLabels           `yaml:"nodeLabels,omitempty"`
+	FeatureGates        model.FeatureGates         `yaml:"featureGates,omitempty"`
+	Kubelet             pluginmodel.Component      `yaml:"kubelet,omitempty"`
+	KubeProxy           pluginmodel.Component      `yaml:"kubeProxy,omitempty"`
 }
 
-type controller struct {
-	APIServerFlags      pluginmodel.APIServerFlags
-	APIServerVolumes    pluginmodel.APIServerVolumes
-	Files               []model.CustomFile
-	SystemdUnits        []model.CustomSystemdUnit
-	IAMPolicyStatements []model.IAMPolicyStatement
-	NodeLabels          model.NodeLabels
+type Controller struct {
+	APIServerFlags      pluginmodel.APIServerFlags   `yaml:"apiServerFlags,omitempty"`
+	APIServerVolumes    pluginmodel.APIServerVolumes `yaml:"apiServerVolumes,omitempty"`
+	APIServerEnv        pluginmodel.EnvVars          `yaml:"apiServerEnv,omitempty"`
+	ControllerManager   pluginmodel.Component        `yaml:"controllerManager,omitempty"`
+	Scheduler           pluginmodel.Component        `yaml:"scheduler,omitempty"`
+	KubeProxy           pluginmodel.Component        `yaml:"kubeProxy,omitempty"`
+	Kubelet             pluginmodel.Component        `yaml:"kubelet,omitempty"`
+	Files               []model.CustomFile           `yaml:"files,omitempty"`
+	SystemdUnits        []model.CustomSystemdUnit    `yaml:"systemdUnits,omitempty"`
+	IAMPolicyStatements []model.IAMPolicyStatement   `yaml:"iamPolicyStatements,omitempty"`
+	NodeLabels          model.NodeLabels             `yaml:"nodeLabels,omitempty"`
 }
 
-type etcd struct {
-	Files               []model.CustomFile
-	SystemdUnits        []model.CustomSystemdUnit
-	IAMPolicyStatements []model.IAMPolicyStatement
+type Etcd struct {
+	Files               []model.CustomFile         `yaml:"files,omitempty"`
+	SystemdUnits        []model.CustomSystemdUnit  `yaml:"systemdUnits,omitempty"`
+	IAMPolicyStatements []model.IAMPolicyStatement `yaml:"iamPolicyStatements,omitempty"`
+	Etcd                pluginmodel.Component      `yaml:"etcd,omitempty"`
 }
 
-func (e ClusterExtension) NodePoolStack() (*stack, error) {
+// appendEnv renders values of the environment variables declared by the plugin and appends them to `dst`
+func appendEnv(dst pluginmodel.EnvVars, render *pluginvalue.TemplateRenderer, src pluginmodel.EnvVars) (pluginmodel.EnvVars, error) {
+	for _, e := range src {
+		v, err := render.StringFrom(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load environment variable %s: %v", e.Name, err)
+		}
+		dst = append(dst, pluginmodel.EnvVar{
+			Name:  e.Name,
+			Value: v,
+		})
+	}
+	return dst, nil
+}
+
+// appendComponent renders values of flags and environment variables of the component declared by the plugin and appends them to `dst`
+func appendComponent(dst pluginmodel.Component, render *pluginvalue.TemplateRenderer, src pluginmodel.Component) (pluginmodel.Component, error) {
+	for _, f := range src.Flags {
+		v, err := render.StringFrom(f.Value)
+		if err != nil {
+			return dst, fmt.Errorf("failed to load flag %s: %v", f.Name, err)
+		}
+		dst.Flags = append(dst.Flags, pluginmodel.APIServerFlag{
+			Name:  f.Name,
+			Value: v,
+		})
+	}
+
+	dst.Volumes = append(dst.Volumes, src.Volumes...)
+
+	env, err := appendEnv(dst.Env, render, src.Env)
+	if err != nil {
+		return dst, err
+	}
+	dst.Env = env
+
+	return dst, nil
+}
+
+// etcdEnvFromFlags translates etcd flags into equivalent environment variables e.g. `--heartbeat-interval` into `ETCD_HEARTBEAT_INTERVAL`
+func etcdEnvFromFlags(flags pluginmodel.APIServerFlags) pluginmodel.EnvVars {
+	env := pluginmodel.EnvVars{}
+	for _, f := range flags {
+		env = append(env, pluginmodel.EnvVar{
+			Name:  "ETCD_" + strings.ToUpper(strings.Replace(strings.TrimLeft(f.Name, "-"), "-", "_", -1)),
+			Value: f.Value,
+		})
+	}
+	return env
+}
+
+func (e ClusterExtension) NodePoolStack() (*Stack, error) {
 	resources := map[string]interface{}{}
+	outputs := map[string]interface{}{}
+	patches := []cfnresource.Patch{}
 
 	for _, p := range e.plugins {
-		if enabled, pc := p.EnabledIn(e.configs); enabled {
-			values := pluginutil.MergeValues(p.Spec.Values, pc.Values)
-			render := plugincontents.TemplateRendererFor(p, values)
+		if enabled, pc := e.enabled(p); enabled {
+			values, deps, err := e.valuesOf(p, pc)
+			if err != nil {
+				return nil, err
+			}
+			render := plugincontents.TemplateRendererFor(p, values, deps)
 
 			m, err := render.MapFromContents(p.Spec.CloudFormation.Stacks.NodePool.Resources.Append.Contents)
 			if err != nil {
@@ -90,23 +253,55 @@ func (e ClusterExtension) NodePoolStack() (*stack, error) {
 			for k, v := range m {
 				resources[k] = v
 			}
+
+			o, err := render.MapFromContents(p.Spec.CloudFormation.Stacks.NodePool.Outputs.Append.Contents)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load additional outputs for worker node-pool stack: %v", err)
+			}
+			for k, v := range o {
+				outputs[k] = v
+			}
+
+			ps, err := patchFrom(p, render, p.Spec.CloudFormation.Stacks.NodePool.Resources.Patch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load resource patches for worker node-pool stack: %v", err)
+			}
+			patches = append(patches, ps...)
 		}
 	}
-	return &stack{
-		Resources: resources,
+	return &Stack{
+		Resources:       resources,
+		Outputs:         outputs,
+		ResourcePatches: patches,
 	}, nil
 }
 
-func (e ClusterExtension) Worker() (*worker, error) {
+func (e ClusterExtension) Worker() (*Worker, error) {
 	files := []model.CustomFile{}
 	systemdUnits := []model.CustomSystemdUnit{}
 	iamStatements := []model.IAMPolicyStatement{}
 	nodeLabels := model.NodeLabels{}
 	featureGates := model.FeatureGates{}
+	kubelet := pluginmodel.Component{}
+	kubeProxy := pluginmodel.Component{}
 
 	for _, p := range e.plugins {
-		if enabled, _ := p.EnabledIn(e.configs); enabled {
+		if enabled, pc := e.enabled(p); enabled {
+			values, deps, err := e.valuesOf(p, pc)
+			if err != nil {
+				return nil, err
+			}
+
 			load := plugincontents.LoaderFor(p)
+			render := pluginvalue.TemplateRendererFor(p, values, deps)
+
+			if kubelet, err = appendComponent(kubelet, render, p.Spec.Node.Roles.Worker.Kubelet.Component); err != nil {
+				return nil, fmt.Errorf("failed to load worker kubelet settings: %v", err)
+			}
+
+			if kubeProxy, err = appendComponent(kubeProxy, render, p.Spec.Kubernetes.KubeProxy); err != nil {
+				return nil, fmt.Errorf("failed to load kube-proxy settings: %v", err)
+			}
 
 			for _, d := range p.Spec.Node.Roles.Worker.Systemd.Units {
 				u := model.CustomSystemdUnit{
@@ -144,23 +339,30 @@ func (e ClusterExtension) Worker() (*worker, error) {
 		}
 	}
 
-	return &worker{
+	return &Worker{
 		Files:               files,
 		SystemdUnits:        systemdUnits,
 		IAMPolicyStatements: iamStatements,
 		NodeLabels:          nodeLabels,
 		FeatureGates:        featureGates,
+		Kubelet:             kubelet,
+		KubeProxy:           kubeProxy,
 	}, nil
 }
 
-func (e ClusterExtension) ControlPlaneStack() (*stack, error) {
+func (e ClusterExtension) ControlPlaneStack() (*Stack, error) {
 	resources := map[string]interface{}{}
+	outputs := map[string]interface{}{}
+	patches := []cfnresource.Patch{}
 
 	for _, p := range e.plugins {
-		if enabled, pc := p.EnabledIn(e.configs); enabled {
-			values := pluginutil.MergeValues(p.Spec.Values, pc.Values)
+		if enabled, pc := e.enabled(p); enabled {
+			values, deps, err := e.valuesOf(p, pc)
+			if err != nil {
+				return nil, err
+			}
 
-			render := plugincontents.TemplateRendererFor(p, values)
+			render := plugincontents.TemplateRendererFor(p, values, deps)
 
 			{
 				m, err := render.MapFromContents(p.Spec.CloudFormation.Stacks.ControlPlane.Resources.Append.Contents)
@@ -171,30 +373,56 @@ func (e ClusterExtension) ControlPlaneStack() (*stack, error) {
 					resources[k] = v
 				}
 			}
+
+			{
+				m, err := render.MapFromContents(p.Spec.CloudFormation.Stacks.ControlPlane.Outputs.Append.Contents)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load additional outputs for control-plane stack: %v", err)
+				}
+				for k, v := range m {
+					outputs[k] = v
+				}
+			}
+
+			ps, err := patchFrom(p, render, p.Spec.CloudFormation.Stacks.ControlPlane.Resources.Patch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load resource patches for control-plane stack: %v", err)
+			}
+			patches = append(patches, ps...)
 		}
 	}
 
-	return &stack{
-		Resources: resources,
+	return &Stack{
+		Resources:       resources,
+		Outputs:         outputs,
+		ResourcePatches: patches,
 	}, nil
 }
 
-func (e ClusterExtension) Controller() (*controller, error) {
+func (e ClusterExtension) Controller() (*Controller, error) {
 	apiServerFlags := pluginmodel.APIServerFlags{}
 	apiServerVolumes := pluginmodel.APIServerVolumes{}
+	apiServerEnv := pluginmodel.EnvVars{}
+	controllerManager := pluginmodel.Component{}
+	scheduler := pluginmodel.Component{}
+	kubeProxy := pluginmodel.Component{}
+	kubelet := pluginmodel.Component{}
 	systemdUnits := []model.CustomSystemdUnit{}
 	files := []model.CustomFile{}
 	iamStatements := model.IAMPolicyStatements{}
 	nodeLabels := model.NodeLabels{}
 
 	for _, p := range e.plugins {
-		if enabled, pc := p.EnabledIn(e.configs); enabled {
-			values := pluginutil.MergeValues(p.Spec.Values, pc.Values)
+		if enabled, pc := e.enabled(p); enabled {
+			values, deps, err := e.valuesOf(p, pc)
+			if err != nil {
+				return nil, err
+			}
 
 			load := plugincontents.LoaderFor(p)
 
 			{
-				render := pluginvalue.TemplateRendererFor(p, values)
+				render := pluginvalue.TemplateRendererFor(p, values, deps)
 				for _, f := range p.Spec.Kubernetes.APIServer.Flags {
 					v, err := render.StringFrom(f.Value)
 					if err != nil {
@@ -206,6 +434,22 @@ func (e ClusterExtension) Controller() (*controller, error) {
 					}
 					apiServerFlags = append(apiServerFlags, newFlag)
 				}
+
+				if apiServerEnv, err = appendEnv(apiServerEnv, render, p.Spec.Kubernetes.APIServer.Env); err != nil {
+					return nil, fmt.Errorf("failed to load apiserver env: %v", err)
+				}
+				if controllerManager, err = appendComponent(controllerManager, render, p.Spec.Kubernetes.ControllerManager); err != nil {
+					return nil, fmt.Errorf("failed to load controller-manager settings: %v", err)
+				}
+				if scheduler, err = appendComponent(scheduler, render, p.Spec.Kubernetes.Scheduler); err != nil {
+					return nil, fmt.Errorf("failed to load scheduler settings: %v", err)
+				}
+				if kubeProxy, err = appendComponent(kubeProxy, render, p.Spec.Kubernetes.KubeProxy); err != nil {
+					return nil, fmt.Errorf("failed to load kube-proxy settings: %v", err)
+				}
+				if kubelet, err = appendComponent(kubelet, render, p.Spec.Node.Roles.Controller.Kubelet.Component); err != nil {
+					return nil, fmt.Errorf("failed to load controller kubelet settings: %v", err)
+				}
 			}
 
 			apiServerVolumes = append(apiServerVolumes, p.Spec.Kubernetes.APIServer.Volumes...)
@@ -242,9 +486,14 @@ func (e ClusterExtension) Controller() (*controller, error) {
 		}
 	}
 
-	return &controller{
+	return &Controller{
 		APIServerFlags:      apiServerFlags,
 		APIServerVolumes:    apiServerVolumes,
+		APIServerEnv:        apiServerEnv,
+		ControllerManager:   controllerManager,
+		Scheduler:           scheduler,
+		KubeProxy:           kubeProxy,
+		Kubelet:             kubelet,
 		Files:               files,
 		SystemdUnits:        systemdUnits,
 		IAMPolicyStatements: iamStatements,
@@ -252,14 +501,25 @@ func (e ClusterExtension) Controller() (*controller, error) {
 	}, nil
 }
 
-func (e ClusterExtension) Etcd() (*etcd, error) {
+func (e ClusterExtension) Etcd() (*Etcd, error) {
 	systemdUnits := []model.CustomSystemdUnit{}
 	files := []model.CustomFile{}
 	iamStatements := model.IAMPolicyStatements{}
+	etcdComponent := pluginmodel.Component{}
 
 	for _, p := range e.plugins {
-		if enabled, _ := p.EnabledIn(e.configs); enabled {
+		if enabled, pc := e.enabled(p); enabled {
+			values, deps, err := e.valuesOf(p, pc)
+			if err != nil {
+				return nil, err
+			}
+
 			load := plugincontents.LoaderFor(p)
+			render := pluginvalue.TemplateRendererFor(p, values, deps)
+
+			if etcdComponent, err = appendComponent(etcdComponent, render, p.Spec.Node.Roles.Etcd.Component); err != nil {
+				return nil, fmt.Errorf("failed to load etcd settings: %v", err)
+			}
 
 			for _, d := range p.Spec.Node.Roles.Etcd.Systemd.Units {
 				u := model.CustomSystemdUnit{
@@ -289,9 +549,60 @@ func (e ClusterExtension) Etcd() (*etcd, error) {
 		}
 	}
 
-	return &etcd{
+	return &Etcd{
 		Files:               files,
 		SystemdUnits:        systemdUnits,
 		IAMPolicyStatements: iamStatements,
+		Etcd: pluginmodel.Component{
+			Volumes: etcdComponent.Volumes,
+			Env:     append(etcdEnvFromFlags(etcdComponent.Flags), etcdComponent.Env...),
+		},
 	}, nil
 }
+
+// KubernetesManifest is a kubernetes manifest contributed by a plugin
+type KubernetesManifest struct {
+	PluginName string                             `yaml:"pluginName,omitempty"`
+	Name       string                             `yaml:"name,omitempty"`
+	Contents   string                             `yaml:"contents,omitempty"`
+	Weight     int                                `yaml:"weight,omitempty"`
+	Wait       pluginmodel.KubernetesManifestWait `yaml:"wait,omitempty"`
+}
+
+func (e ClusterExtension) KubernetesManifests() ([]KubernetesManifest, error) {
+	manifests := []KubernetesManifest{}
+
+	for _, p := range e.plugins {
+		if enabled, _ := e.enabled(p); enabled {
+			load := plugincontents.LoaderFor(p)
+
+			for _, m := range p.Spec.Kubernetes.Manifests {
+				s, err := load.StringFrom(m.Contents)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load plugin kubernetes manifest %s: %v", m.Name, err)
+				}
+				manifests = append(manifests, KubernetesManifest{
+					PluginName: p.Name,
+					Name:       m.Name,
+					Contents:   s,
+					Weight:     m.Weight,
+					Wait:       m.Wait,
+				})
+			}
+		}
+	}
+
+	return manifests, nil
+}
+
+func (e ClusterExtension) HelmReleases() pluginmodel.HelmReleases {
+	releases := pluginmodel.HelmReleases{}
+
+	for _, p := range e.plugins {
+		if enabled, _ := e.enabled(p); enabled {
+			releases = append(releases, p.Spec.Helm.Releases...)
+		}
+	}
+
+	return releases
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kubernetes-incubator/kube-aws/model"
+)
+
+// DefaultCacheDir returns the directory plugins fetched from their sources are cached in
+func DefaultCacheDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".kube-aws", "cache", "plugins")
+}
+
+// InstalledPluginDir returns the directory containing plugin.yaml of the plugin fetched from the source.
+// Each source gets its own directory so that changing the ref or the url in cluster.yaml results in fetching the plugin again
+func InstalledPluginDir(cacheDir string, key string, source model.PluginSource) string {
+	id := sha256.Sum256([]byte(strings.Join([]string{source.Git, source.Ref, source.URL, source.SHA256}, "\n")))
+	return filepath.Join(cacheDir, key, hex.EncodeToString(id[:])[:16], source.Path)
+}
+
+// Installer fetches plugins from the sources declared in cluster.yaml and caches them locally
+type Installer struct {
+	CacheDir   string
+	HTTPClient *http.Client
+}
+
+func NewInstaller() *Installer {
+	return &Installer{
+		CacheDir:   DefaultCacheDir(),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// InstallAll installs every enabled plugin which has a source and returns the directories the plugins are installed to
+func (i *Installer) InstallAll(configs model.PluginConfigs) ([]string, error) {
+	keys := []string{}
+	for key, c := range configs {
+		if c.Enabled && c.Source.Remote() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	dirs := []string{}
+	for _, key := range keys {
+		dir, err := i.Install(key, configs[key].Source)
+		if err != nil {
+			return dirs, fmt.Errorf("failed to install plugin %s: %v", key, err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// Install fetches the plugin from the source unless it is already cached
+func (i *Installer) Install(key string, source model.PluginSource) (string, error) {
+	if err := source.Validate(); err != nil {
+		return "", fmt.Errorf("invalid source: %v", err)
+	}
+
+	pluginDir := InstalledPluginDir(i.CacheDir, key, source)
+	if _, err := os.Stat(pluginDir); err == nil {
+		return pluginDir, nil
+	}
+
+	sourceDir := InstalledPluginDir(i.CacheDir, key, model.PluginSource{Git: source.Git, Ref: source.Ref, URL: source.URL, SHA256: source.SHA256})
+	if err := os.MkdirAll(filepath.Dir(sourceDir), 0755); err != nil {
+		return "", err
+	}
+
+	// Fetches into a temporary directory first so that a failed fetch never leaves a partially installed plugin behind
+	tmpDir, err := ioutil.TempDir(filepath.Dir(sourceDir), ".fetch-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if source.Git != "" {
+		err = fetchGit(source.Git, source.Ref, tmpDir)
+	} else {
+		err = i.fetchTarball(source.URL, source.SHA256, tmpDir)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, source.Path, "plugin.yaml")); err != nil {
+		return "", fmt.Errorf("plugin.yaml not found in %s of the source: %v", source.Path, err)
+	}
+
+	if err := os.RemoveAll(sourceDir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, sourceDir); err != nil {
+		return "", err
+	}
+	return pluginDir, nil
+}
+
+func fetchGit(repo string, ref string, dir string) error {
+	commands := [][]string{
+		{"git", "clone", "--quiet", repo, dir},
+		{"git", "-C", dir, "checkout", "--quiet", ref},
+	}
+	for _, args := range commands {
+		cmd := exec.Command(args[0], args[1:]...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("`%s` failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	return nil
+}
+
+func (i *Installer) fetchTarball(url string, checksum string, dir string) error {
+	res, err := i.HTTPClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: unexpected status %s", url, res.Status)
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != strings.ToLower(checksum) {
+		return fmt.Errorf("sha256 checksum mismatch for %s: expected=%s, got=%s", url, checksum, actual)
+	}
+
+	return extractTarball(data, dir)
+}
+
+func extractTarball(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decompress tarball: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tarball: %v", err)
+		}
+
+		path := filepath.Join(dir, h.Name)
+		if path != filepath.Clean(dir) && !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("tarball contains the file %s outside of its root", h.Name)
+		}
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode)&0755|0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
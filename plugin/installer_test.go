@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/test/helper"
+)
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar contents: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInstallFromURL(t *testing.T) {
+	data := tarball(t, map[string]string{
+		"my-plugin/plugin.yaml": `metadata:
+  name: my-plugin
+  version: 0.0.1
+`,
+	})
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	helper.WithTempDir(func(dir string) {
+		installer := &Installer{CacheDir: dir, HTTPClient: server.Client()}
+
+		configs := model.PluginConfigs{
+			"myPlugin": model.PluginConfig{
+				Enabled: true,
+				Source:  model.PluginSource{URL: server.URL, SHA256: checksum, Path: "my-plugin"},
+			},
+		}
+
+		if _, err := loadInstalled(configs, dir); err == nil || !strings.Contains(err.Error(), "kube-aws plugin install") {
+			t.Errorf("expected loading a plugin not installed yet to fail but got: %v", err)
+		}
+
+		if _, err := installer.InstallAll(configs); err != nil {
+			t.Fatalf("failed to install plugins: %v", err)
+		}
+
+		plugins, err := loadInstalled(configs, dir)
+		if err != nil {
+			t.Fatalf("failed to load installed plugins: %v", err)
+		}
+		if len(plugins) != 1 || plugins[0].Name != "my-plugin" {
+			t.Errorf("expected my-plugin to be loaded but got %v", plugins)
+		}
+	})
+
+	helper.WithTempDir(func(dir string) {
+		installer := &Installer{CacheDir: dir, HTTPClient: server.Client()}
+
+		source := model.PluginSource{URL: server.URL, SHA256: strings.Repeat("0", 64), Path: "my-plugin"}
+		if _, err := installer.Install("myPlugin", source); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Errorf("expected installing a tarball with an unexpected checksum to fail but got: %v", err)
+		}
+	})
+}
+
+func TestInstallFromGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	helper.WithTempDir(func(dir string) {
+		repo := filepath.Join(dir, "plugins.git")
+		work := filepath.Join(dir, "work")
+		git := func(args ...string) {
+			args = append([]string{"-c", "user.name=kube-aws", "-c", "user.email=kube-aws@example.com"}, args...)
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("`git %s` failed: %v: %s", strings.Join(args, " "), err, out)
+			}
+		}
+		commit := func(version string) {
+			pluginYaml := fmt.Sprintf("metadata:\n  name: my-plugin\n  version: %s\n", version)
+			if err := ioutil.WriteFile(filepath.Join(work, "my-plugin", "plugin.yaml"), []byte(pluginYaml), 0644); err != nil {
+				t.Fatalf("failed to write plugin.yaml: %v", err)
+			}
+			git("-C", work, "add", "-A")
+			git("-C", work, "commit", "--quiet", "-m", version)
+		}
+
+		git("init", "--quiet", "--bare", repo)
+		git("clone", "--quiet", repo, work)
+		if err := os.MkdirAll(filepath.Join(work, "my-plugin"), 0755); err != nil {
+			t.Fatalf("failed to create the plugin dir: %v", err)
+		}
+		commit("0.0.1")
+		git("-C", work, "tag", "v0.0.1")
+		commit("0.0.2")
+		git("-C", work, "push", "--quiet", "--tags", "origin", "HEAD")
+
+		cacheDir := filepath.Join(dir, "cache")
+		installer := &Installer{CacheDir: cacheDir}
+		configs := model.PluginConfigs{
+			"myPlugin": model.PluginConfig{
+				Enabled: true,
+				Source:  model.PluginSource{Git: repo, Ref: "v0.0.1", Path: "my-plugin"},
+			},
+		}
+
+		if _, err := installer.InstallAll(configs); err != nil {
+			t.Fatalf("failed to install plugins: %v", err)
+		}
+
+		plugins, err := loadInstalled(configs, cacheDir)
+		if err != nil {
+			t.Fatalf("failed to load installed plugins: %v", err)
+		}
+		if len(plugins) != 1 || plugins[0].Name != "my-plugin" || plugins[0].Metadata.Version != "0.0.1" {
+			t.Errorf("expected my-plugin 0.0.1 checked out from the ref to be loaded but got %+v", plugins)
+		}
+
+		configs["myPlugin"] = model.PluginConfig{
+			Enabled: true,
+			Source:  model.PluginSource{Git: repo, Ref: "v0.0.2", Path: "my-plugin"},
+		}
+		if _, err := installer.InstallAll(configs); err == nil || !strings.Contains(err.Error(), "checkout") {
+			t.Errorf("expected installing from a missing ref to fail but got: %v", err)
+		}
+	})
+}
+
+func TestPluginSourceValidation(t *testing.T) {
+	invalidSources := []model.PluginSource{
+		{Git: "https://example.com/plugins.git"},
+		{URL: "https://example.com/plugin.tgz"},
+		{URL: "https://example.com/plugin.tgz", SHA256: "abc"},
+		{Git: "https://example.com/plugins.git", Ref: "v1", URL: "https://example.com/plugin.tgz"},
+		{Path: "my-plugin"},
+	}
+	for _, s := range invalidSources {
+		if err := s.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid but it wasn't", s)
+		}
+	}
+}
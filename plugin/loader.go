@@ -3,25 +3,42 @@ package plugin
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 
+	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 	"gopkg.in/yaml.v2"
 )
 
+// Loader loads plugins from subdirectories of a directory
 type Loader struct {
+	dir string
 }
 
+// NewLoader returns a loader for plugins in `plugins/` of the working directory
 func NewLoader() *Loader {
-	return &Loader{}
+	return NewLoaderForDir("plugins")
+}
+
+func NewLoaderForDir(dir string) *Loader {
+	return &Loader{
+		dir: dir,
+	}
+}
+
+// GlobalPluginsDir returns the directory containing plugins shared among all the clusters of the user
+func GlobalPluginsDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".kube-aws", "plugins")
 }
 
 func (l Loader) Load() ([]*pluginmodel.Plugin, error) {
 	plugins := []*pluginmodel.Plugin{}
-	fileInfos, _ := ioutil.ReadDir("plugins/")
+	fileInfos, _ := ioutil.ReadDir(l.dir)
 	for _, f := range fileInfos {
 		if f.IsDir() {
-			p, err := l.TryToLoadPluginFromDir(filepath.Join("plugins", f.Name()))
+			p, err := l.TryToLoadPluginFromDir(filepath.Join(l.dir, f.Name()))
 			if err != nil {
 				return []*pluginmodel.Plugin{}, fmt.Errorf("Failed to load plugin from the directory %s: %v", f.Name(), err)
 			}
@@ -36,6 +53,7 @@ func (l Loader) TryToLoadPluginFromDir(path string) (*pluginmodel.Plugin, error)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to load plugin from %s: %v", path, err)
 	}
+	p.Dir = path
 	return p, nil
 }
 
@@ -64,18 +82,94 @@ func PluginFromBytes(data []byte) (*pluginmodel.Plugin, error) {
 	return p, nil
 }
 
-func LoadAll() ([]*pluginmodel.Plugin, error) {
-	loaders := []*Loader{
-		NewLoader(),
+// LoadOptions specifies the directories plugins are loaded from
+type LoadOptions struct {
+	// Dir contains the plugins of the cluster
+	Dir string
+	// GlobalDir contains the plugins shared among all the clusters of the user
+	GlobalDir string
+	// CacheDir contains the plugins fetched from their sources by `kube-aws plugin install`
+	CacheDir string
+}
+
+// DefaultLoadOptions returns the options to load plugins in `plugins/` of the working directory, in `~/.kube-aws/plugins`
+// and in `~/.kube-aws/cache/plugins`
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{
+		Dir:       "plugins",
+		GlobalDir: GlobalPluginsDir(),
+		CacheDir:  DefaultCacheDir(),
 	}
+}
 
+// LoadAll loads plugins installed from the sources declared in `configs`, in `plugins/` of the working directory and
+// in `~/.kube-aws/plugins`, in that order of precedence. Plugins with the same name as preceding ones are ignored
+func LoadAll(configs model.PluginConfigs) ([]*pluginmodel.Plugin, error) {
+	return LoadAllWithOptions(configs, DefaultLoadOptions())
+}
+
+// LoadAllWithOptions is the same as LoadAll except that plugins are loaded from the directories specified in `opts`
+func LoadAllWithOptions(configs model.PluginConfigs, opts LoadOptions) ([]*pluginmodel.Plugin, error) {
 	plugins := []*pluginmodel.Plugin{}
+	loaded := map[string]bool{}
+	add := func(ps ...*pluginmodel.Plugin) {
+		for _, p := range ps {
+			if !loaded[p.Name] {
+				loaded[p.Name] = true
+				plugins = append(plugins, p)
+			}
+		}
+	}
+
+	ps, err := loadInstalled(configs, opts.CacheDir)
+	if err != nil {
+		return plugins, fmt.Errorf("Failed to load plugins: %v", err)
+	}
+	add(ps...)
+
+	loaders := []*Loader{
+		NewLoaderForDir(opts.Dir),
+		NewLoaderForDir(opts.GlobalDir),
+	}
+
 	for _, l := range loaders {
 		ps, err := l.Load()
 		if err != nil {
 			return plugins, fmt.Errorf("Failed to load plugins: %v", err)
 		}
-		plugins = append(plugins, ps...)
+		add(ps...)
+	}
+	return plugins, nil
+}
+
+// loadInstalled loads the enabled plugins fetched from their sources by `kube-aws plugin install`
+func loadInstalled(configs model.PluginConfigs, cacheDir string) ([]*pluginmodel.Plugin, error) {
+	keys := []string{}
+	for key, c := range configs {
+		if c.Enabled && c.Source.Remote() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	plugins := []*pluginmodel.Plugin{}
+	for _, key := range keys {
+		source := configs[key].Source
+		if err := source.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid source of plugin %s: %v", key, err)
+		}
+		dir := InstalledPluginDir(cacheDir, key, source)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, fmt.Errorf("plugin %s is not installed yet. Run `kube-aws plugin install` to fetch it from its source", key)
+		}
+		p, err := NewLoaderForDir(dir).TryToLoadPluginFromDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if p.SettingKey() != key {
+			return nil, fmt.Errorf("plugin %s installed from its source is configured via `kubeAwsPlugins.%s` rather than `kubeAwsPlugins.%s`", p.Name, p.SettingKey(), key)
+		}
+		plugins = append(plugins, p)
 	}
 	return plugins, nil
 }
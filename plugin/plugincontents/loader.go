@@ -24,7 +24,11 @@ func (l *Loader) StringFrom(contents pluginmodel.Contents) (string, error) {
 	}
 
 	if contents.Path != "" {
-		path := filepath.Join("plugins", l.p.Name, contents.Path)
+		dir := l.p.Dir
+		if dir == "" {
+			dir = filepath.Join("plugins", l.p.Name)
+		}
+		path := filepath.Join(dir, contents.Path)
 		raw, err := ioutil.ReadFile(path)
 		if err != nil {
 			return "", fmt.Errorf("failed to load %s: %v", path, err)
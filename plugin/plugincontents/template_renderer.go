@@ -9,16 +9,18 @@ import (
 )
 
 type TemplateRenderer struct {
-	p      *pluginmodel.Plugin
-	l      *Loader
-	values interface{}
+	p            *pluginmodel.Plugin
+	l            *Loader
+	values       interface{}
+	dependencies map[string]interface{}
 }
 
-func TemplateRendererFor(p *pluginmodel.Plugin, values interface{}) *TemplateRenderer {
+func TemplateRendererFor(p *pluginmodel.Plugin, values interface{}, dependencies map[string]interface{}) *TemplateRenderer {
 	return &TemplateRenderer{
-		p:      p,
-		l:      LoaderFor(p),
-		values: values,
+		p:            p,
+		l:            LoaderFor(p),
+		values:       values,
+		dependencies: dependencies,
 	}
 }
 
@@ -27,16 +29,20 @@ func (r *TemplateRenderer) StringFrom(contents pluginmodel.Contents) (string, er
 	if err != nil {
 		return "", fmt.Errorf("failed to render template: %v", err)
 	}
-	return pluginutil.RenderStringFromTemplateWithValues(str, r.values)
+	return pluginutil.RenderStringFromTemplateWithValues(str, r.values, r.dependencies)
 }
 
 func (r *TemplateRenderer) MapFromContents(contents pluginmodel.Contents) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if contents.Empty() {
+		return m, nil
+	}
+
 	str, err := r.StringFrom(contents)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute template: %v", err)
 	}
 
-	m := map[string]interface{}{}
 	if err := json.Unmarshal([]byte(str), &m); err != nil {
 		return nil, fmt.Errorf("failed to parse json %s: %v", str, err)
 	}
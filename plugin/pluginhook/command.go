@@ -0,0 +1,68 @@
+package pluginhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+)
+
+const (
+	// Command is the event of the document describing the cluster given to a plugin command
+	Command = "command"
+
+	// ClusterEnvVar is the name of the environment variable containing the JSON document describing the cluster, which is given to plugin commands
+	ClusterEnvVar = "KUBE_AWS_CLUSTER"
+	// ValuesEnvVar is the name of the environment variable containing the JSON representation of the plugin's values merged with ones in cluster.yaml
+	ValuesEnvVar = "KUBE_AWS_PLUGIN_VALUES"
+)
+
+// CommandRunner runs commands contributed by plugins as kube-aws subcommands.
+// Unlike hooks, commands are connected to the stdin of kube-aws so that they can interact with users
+type CommandRunner struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func NewCommandRunner() *CommandRunner {
+	return &CommandRunner{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run runs the command `c` of the plugin `p` with `args` appended to the args declared in the plugin.
+// `cluster` and `values` are given to the command via the environment variables KUBE_AWS_CLUSTER and KUBE_AWS_PLUGIN_VALUES in JSON
+func (r *CommandRunner) Run(p *pluginmodel.Plugin, c pluginmodel.PluginCommand, args []string, cluster Cluster, values pluginmodel.Values) error {
+	doc := cluster
+	doc.Event = Command
+	doc.Values = jsonCompatible(map[string]interface{}(values))
+	clusterJSON, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the cluster given to command %s of plugin %s: %v", c.Name, p.Name, err)
+	}
+	valuesJSON, err := json.Marshal(doc.Values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal values given to command %s of plugin %s: %v", c.Name, p.Name, err)
+	}
+
+	path := filepath.Join(p.Dir, c.Path)
+	cmd := exec.Command(path, append(append([]string{}, c.Args...), args...)...)
+	cmd.Stdin = r.Stdin
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	cmd.Env = append(os.Environ(),
+		ClusterEnvVar+"="+string(clusterJSON),
+		ValuesEnvVar+"="+string(valuesJSON),
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command %s of plugin %s failed: %v", c.Name, p.Name, err)
+	}
+	return nil
+}
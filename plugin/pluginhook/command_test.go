@@ -0,0 +1,67 @@
+package pluginhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/test/helper"
+)
+
+func TestCommandRunner(t *testing.T) {
+	helper.WithTempDir(func(dir string) {
+		script := "#!/bin/sh\necho \"$@\"\nprintf '%s' \"$KUBE_AWS_CLUSTER\" > " + filepath.Join(dir, "cluster.json") + "\nprintf '%s' \"$KUBE_AWS_PLUGIN_VALUES\" > " + filepath.Join(dir, "values.json") + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, "rotate.sh"), []byte(script), 0755); err != nil {
+			t.Fatalf("failed to write command: %v", err)
+		}
+
+		p := &pluginmodel.Plugin{
+			Metadata: pluginmodel.Metadata{Name: "my-plugin", Version: "0.0.1"},
+			Dir:      dir,
+		}
+		c := pluginmodel.PluginCommand{Name: "rotate-secret", Path: "rotate.sh", Args: []string{"--from-plugin"}}
+
+		stdout := &bytes.Buffer{}
+		runner := NewCommandRunner()
+		runner.Stdout = stdout
+		runner.Stderr = &bytes.Buffer{}
+
+		cluster := Cluster{ClusterName: "mycluster", StackName: "mycluster"}
+		if err := runner.Run(p, c, []string{"--force"}, cluster, pluginmodel.Values{"zone": "example.com"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if out := stdout.String(); out != "--from-plugin --force\n" {
+			t.Errorf("expected args of the command followed by args given to the subcommand but got: %s", out)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "cluster.json"))
+		if err != nil {
+			t.Fatalf("expected the command to be run but it wasn't: %v", err)
+		}
+		input := Cluster{}
+		if err := json.Unmarshal(data, &input); err != nil {
+			t.Fatalf("failed to parse the cluster given to the command: %v", err)
+		}
+		if input.Event != Command || input.ClusterName != "mycluster" {
+			t.Errorf("unexpected cluster given to the command: %+v", input)
+		}
+
+		data, err = ioutil.ReadFile(filepath.Join(dir, "values.json"))
+		if err != nil {
+			t.Fatalf("failed to read values given to the command: %v", err)
+		}
+		if string(data) != `{"zone":"example.com"}` {
+			t.Errorf("unexpected values given to the command: %s", data)
+		}
+
+		c.Path = "missing.sh"
+		if err := runner.Run(p, c, nil, cluster, pluginmodel.Values{}); err == nil || !strings.Contains(err.Error(), "command rotate-secret of plugin my-plugin failed") {
+			t.Errorf("expected the missing command to fail but got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package pluginhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginutil"
+)
+
+const (
+	PreUp      = "preUp"
+	PostUp     = "postUp"
+	PreUpdate  = "preUpdate"
+	PostUpdate = "postUpdate"
+	PreDestroy = "preDestroy"
+)
+
+// Cluster is the JSON document describing the cluster, which is given to each hook via stdin
+type Cluster struct {
+	Event          string `json:"event"`
+	ClusterName    string `json:"clusterName"`
+	Region         string `json:"region"`
+	StackName      string `json:"stackName"`
+	APIEndpointURL string `json:"apiEndpointURL,omitempty"`
+	KubeconfigPath string `json:"kubeconfigPath"`
+	// Outputs are outputs of the root stack, which are available only to hooks run after the stack is created or updated
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Values are values of the plugin the hook belongs to
+	Values interface{} `json:"values,omitempty"`
+}
+
+type Runner struct {
+	plugins []*pluginmodel.Plugin
+	configs model.PluginConfigs
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// NewRunner returns a runner of hooks of the enabled plugins.
+// `plugins` are expected to be ordered so that each plugin comes after the plugins it requires
+func NewRunner(plugins []*pluginmodel.Plugin, configs model.PluginConfigs) *Runner {
+	return &Runner{
+		plugins: plugins,
+		configs: configs,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+}
+
+// Run runs hooks for the event of every enabled plugin in order, stopping at the first failing hook.
+// Hooks before destroying the cluster are run in the reverse order so that dependent plugins are torn down first
+func (r *Runner) Run(event string, cluster Cluster) error {
+	plugins := r.plugins
+	if event == PreDestroy {
+		plugins = make([]*pluginmodel.Plugin, len(r.plugins))
+		for i, p := range r.plugins {
+			plugins[len(r.plugins)-1-i] = p
+		}
+	}
+
+	for _, p := range plugins {
+		enabled, pc := p.EnabledIn(r.configs)
+		if !enabled {
+			continue
+		}
+
+		commands, err := commandsFor(p.Spec.Hooks, event)
+		if err != nil {
+			return err
+		}
+		if len(commands) == 0 {
+			continue
+		}
+
+		values, err := pluginutil.ValuesFor(p, pc, "kubeAwsPlugins."+p.SettingKey())
+		if err != nil {
+			return err
+		}
+
+		doc := cluster
+		doc.Event = event
+		doc.Values = jsonCompatible(map[string]interface{}(values))
+		input, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to marshal input to %s hooks of plugin %s: %v", event, p.Name, err)
+		}
+
+		for _, c := range commands {
+			path := filepath.Join(p.Dir, c.Path)
+			cmd := exec.Command(path, c.Args...)
+			cmd.Stdin = bytes.NewReader(input)
+			cmd.Stdout = r.Stdout
+			cmd.Stderr = r.Stderr
+			cmd.Env = append(os.Environ(), "KUBE_AWS_HOOK_EVENT="+event)
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("%s hook %s of plugin %s failed: %v", event, c.Path, p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func commandsFor(hooks pluginmodel.Hooks, event string) (pluginmodel.HookCommands, error) {
+	switch event {
+	case PreUp:
+		return hooks.PreUp, nil
+	case PostUp:
+		return hooks.PostUp, nil
+	case PreUpdate:
+		return hooks.PreUpdate, nil
+	case PostUpdate:
+		return hooks.PostUpdate, nil
+	case PreDestroy:
+		return hooks.PreDestroy, nil
+	}
+	return nil, fmt.Errorf("unknown hook event: %s", event)
+}
+
+// jsonCompatible converts maps decoded from yaml, whose keys are of type interface{}, so that they can be marshalled into json
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		r := map[string]interface{}{}
+		for k, e := range v {
+			r[k] = jsonCompatible(e)
+		}
+		return r
+	case map[interface{}]interface{}:
+		r := map[string]interface{}{}
+		for k, e := range v {
+			r[fmt.Sprintf("%v", k)] = jsonCompatible(e)
+		}
+		return r
+	case []interface{}:
+		r := make([]interface{}, len(v))
+		for i, e := range v {
+			r[i] = jsonCompatible(e)
+		}
+		return r
+	}
+	return v
+}
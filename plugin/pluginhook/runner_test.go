@@ -0,0 +1,67 @@
+package pluginhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/test/helper"
+)
+
+func TestRun(t *testing.T) {
+	helper.WithTempDir(func(dir string) {
+		script := "#!/bin/sh\ncat > " + filepath.Join(dir, "input.json") + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, "record.sh"), []byte(script), 0755); err != nil {
+			t.Fatalf("failed to write hook: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "fail.sh"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+			t.Fatalf("failed to write hook: %v", err)
+		}
+
+		p := &pluginmodel.Plugin{
+			Metadata: pluginmodel.Metadata{Name: "my-plugin", Version: "0.0.1"},
+			Dir:      dir,
+		}
+		p.Spec.Values = pluginmodel.Values{"zone": "example.com"}
+		p.Spec.Hooks.PostUp = pluginmodel.HookCommands{{Path: "record.sh"}}
+		p.Spec.Hooks.PreDestroy = pluginmodel.HookCommands{{Path: "fail.sh"}}
+
+		configs := model.PluginConfigs{"myPlugin": model.PluginConfig{Enabled: true}}
+		runner := NewRunner([]*pluginmodel.Plugin{p}, configs)
+		runner.Stdout = &bytes.Buffer{}
+		runner.Stderr = &bytes.Buffer{}
+
+		cluster := Cluster{ClusterName: "mycluster", StackName: "mycluster", Outputs: map[string]string{"Foo": "bar"}}
+		if err := runner.Run(PostUp, cluster); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "input.json"))
+		if err != nil {
+			t.Fatalf("expected the hook to be run but it wasn't: %v", err)
+		}
+		input := Cluster{}
+		if err := json.Unmarshal(data, &input); err != nil {
+			t.Fatalf("failed to parse input to the hook: %v", err)
+		}
+		if input.Event != PostUp || input.ClusterName != "mycluster" || input.Outputs["Foo"] != "bar" {
+			t.Errorf("unexpected input to the hook: %+v", input)
+		}
+		if input.Values.(map[string]interface{})["zone"] != "example.com" {
+			t.Errorf("expected values of the plugin to be given to the hook but got: %v", input.Values)
+		}
+
+		if err := runner.Run(PreDestroy, cluster); err == nil || !strings.Contains(err.Error(), "preDestroy hook fail.sh of plugin my-plugin failed") {
+			t.Errorf("expected the failing hook to abort but got: %v", err)
+		}
+
+		if err := NewRunner([]*pluginmodel.Plugin{p}, model.PluginConfigs{}).Run(PreDestroy, cluster); err != nil {
+			t.Errorf("expected hooks of disabled plugins not to be run but got: %v", err)
+		}
+	})
+}
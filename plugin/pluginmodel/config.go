@@ -3,8 +3,10 @@ package pluginmodel
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/semver"
 	"github.com/kubernetes-incubator/kube-aws/model"
 )
 
@@ -12,6 +14,8 @@ import (
 type Plugin struct {
 	Metadata `yaml:"metadata,omitempty"`
 	Spec     `yaml:"spec,omitempty"`
+	// Dir is the directory the plugin is loaded from. Paths to contents of the plugin are relative to it
+	Dir string `yaml:"-"`
 }
 
 func (p Plugin) EnabledIn(plugins model.PluginConfigs) (bool, *model.PluginConfig) {
@@ -23,10 +27,40 @@ func (p Plugin) EnabledIn(plugins model.PluginConfigs) (bool, *model.PluginConfi
 	return false, nil
 }
 
+// escapesDir returns true when the relative path may point outside of the directory it is relative to
+func escapesDir(path string) bool {
+	return strings.HasPrefix(filepath.Clean(path), "..")
+}
+
 func (p Plugin) Validate() error {
 	if err := p.Metadata.Validate(); err != nil {
 		return fmt.Errorf("Invalid metadata: %v", err)
 	}
+	for _, c := range [][]HookCommand{p.Spec.Hooks.PreUp, p.Spec.Hooks.PostUp, p.Spec.Hooks.PreUpdate, p.Spec.Hooks.PostUpdate, p.Spec.Hooks.PreDestroy} {
+		for _, h := range c {
+			if h.Path == "" || filepath.IsAbs(h.Path) || escapesDir(h.Path) {
+				return fmt.Errorf("Invalid hook: `path` must be a path relative to the plugin directory but was \"%s\"", h.Path)
+			}
+		}
+	}
+	commandNames := map[string]bool{}
+	for _, c := range p.Spec.Commands {
+		if c.Name == "" {
+			return errors.New("Invalid command: `name` must not be empty")
+		}
+		if commandNames[c.Name] {
+			return fmt.Errorf("Invalid command: `name` must be unique but \"%s\" is duplicated", c.Name)
+		}
+		commandNames[c.Name] = true
+		if c.Path == "" || filepath.IsAbs(c.Path) || escapesDir(c.Path) {
+			return fmt.Errorf("Invalid command %s: `path` must be a path relative to the plugin directory but was \"%s\"", c.Name, c.Path)
+		}
+	}
+	if p.Spec.ValuesSchema != nil {
+		if err := p.Spec.ValuesSchema.Valid("valuesSchema"); err != nil {
+			return fmt.Errorf("Invalid values schema: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -50,6 +84,13 @@ type Metadata struct {
 	ClusterSettingsKey string `yaml:"clusterSettingsKey,omitempty"`
 	// NodePoolSettingsKey is the key in the root of a node pool settings in cluster.yaml used for configuring this plugin only for a node pool
 	NodePoolSettingsKey string `yaml:"nodePoolSettingKey,omitempty"`
+	// KubeAwsVersion is the semver constraint on versions of kube-aws the plugin is compatible with e.g. ">= 0.9.8, < 0.10"
+	KubeAwsVersion string `yaml:"kubeAwsVersion,omitempty"`
+	// Requires is the list of plugins which must be enabled along with this plugin.
+	// Required plugins are loaded before this plugin so that this plugin can reference values and resources of them
+	Requires PluginReferences `yaml:"requires,omitempty"`
+	// Conflicts is the list of plugins which must not be enabled along with this plugin
+	Conflicts PluginReferences `yaml:"conflicts,omitempty"`
 }
 
 func (m Metadata) Validate() error {
@@ -59,20 +100,121 @@ func (m Metadata) Validate() error {
 	if m.Version == "" {
 		return errors.New("`version` must not be empty")
 	}
+	if _, err := semver.NewVersion(m.Version); err != nil {
+		return fmt.Errorf("`version` must be a semantic version but was \"%s\": %v", m.Version, err)
+	}
+	if m.KubeAwsVersion != "" {
+		if _, err := semver.NewConstraint(m.KubeAwsVersion); err != nil {
+			return fmt.Errorf("invalid `kubeAwsVersion` \"%s\": %v", m.KubeAwsVersion, err)
+		}
+	}
+	for i, r := range m.Requires {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid `requires[%d]`: %v", i, err)
+		}
+	}
+	for i, c := range m.Conflicts {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("invalid `conflicts[%d]`: %v", i, err)
+		}
+	}
 	return nil
 }
 
+type PluginReferences []PluginReference
+
+// PluginReference refers to other plugins by their names and optionally by the semver constraint on their versions
+type PluginReference struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version,omitempty"`
+}
+
+func (r PluginReference) Validate() error {
+	if r.Name == "" {
+		return errors.New("`name` must not be empty")
+	}
+	if r.Version != "" {
+		if _, err := semver.NewConstraint(r.Version); err != nil {
+			return fmt.Errorf("invalid `version` \"%s\" of plugin %s: %v", r.Version, r.Name, err)
+		}
+	}
+	return nil
+}
+
+// MatchedBy returns true when the plugin has the referenced name and its version satisfies the constraint if any
+func (r PluginReference) MatchedBy(p *Plugin) bool {
+	if p.Name != r.Name {
+		return false
+	}
+	if r.Version == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(r.Version)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(p.Version)
+	if err != nil {
+		return false
+	}
+	return c.Check(v)
+}
+
+func (r PluginReference) String() string {
+	if r.Version == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s %s", r.Name, r.Version)
+}
+
 // Spec is the specification of a kube-aws plugin
-// A spec consists of two parts: Configuration and Command
+// A spec consists of three parts: Configuration, Hooks and Commands
 type Spec struct {
 	// Configuration is the configuration part of a plugin which is used to append arbitrary configs into various resources managed by kube-aws
 	Configuration `yaml:"configuration,omitempty"`
+	// Hooks are executables run by kube-aws before and after it creates, updates or destroys the cluster
+	Hooks `yaml:"hooks,omitempty"`
+	// Commands are executables run as kube-aws subcommands in the form of `kube-aws <plugin name> <command name>`
+	Commands PluginCommands `yaml:"commands,omitempty"`
+}
+
+type PluginCommands []PluginCommand
+
+// PluginCommand is an executable run by `kube-aws <plugin name> <command name>`.
+// Arguments given to the subcommand are passed through to the executable after `args`
+type PluginCommand struct {
+	Name string `yaml:"name"`
+	// Short is the one-line description of the command shown in `kube-aws <plugin name> --help`
+	Short string `yaml:"short,omitempty"`
+	// Path is the path to the executable relative to the plugin directory
+	Path string   `yaml:"path"`
+	Args []string `yaml:"args,omitempty"`
+}
+
+// Hooks are executables run locally by kube-aws, which are given a JSON document describing the cluster via stdin.
+// A hook exiting with non-zero status aborts the operation
+type Hooks struct {
+	PreUp      HookCommands `yaml:"preUp,omitempty"`
+	PostUp     HookCommands `yaml:"postUp,omitempty"`
+	PreUpdate  HookCommands `yaml:"preUpdate,omitempty"`
+	PostUpdate HookCommands `yaml:"postUpdate,omitempty"`
+	PreDestroy HookCommands `yaml:"preDestroy,omitempty"`
+}
+
+type HookCommands []HookCommand
+
+type HookCommand struct {
+	// Path is the path to the executable relative to the plugin directory
+	Path string   `yaml:"path"`
+	Args []string `yaml:"args,omitempty"`
 }
 
 // Configuration is the configuration part of a plugin which is used to append arbitrary configs into various resources managed by kube-aws
 type Configuration struct {
 	// Values represents the values available in templates
 	Values `yaml:"values,omitempty"`
+	// ValuesSchema is the schema values merged with the ones in cluster.yaml are validated against
+	ValuesSchema *ValuesSchema `yaml:"valuesSchema,omitempty"`
 	// CloudFormation represents customizations to CloudFormation-related settings and configurations
 	CloudFormation `yaml:"cloudformation,omitempty"`
 	// Helm represents what are injected into the resulting K8S cluster via Helm - a package manager for K8S
@@ -104,6 +246,16 @@ type Stack struct {
 
 type Resources struct {
 	Append `yaml:"append,omitempty"`
+	// Patch modifies resources rendered by kube-aws. Patches targeting logical IDs missing in the stack template result in an error
+	Patch `yaml:"patch,omitempty"`
+}
+
+type Patch struct {
+	// Merge is a JSON Merge Patch(RFC 7386) keyed by logical IDs of resources to be modified e.g. `{"Controllers": {"Properties": {"Cooldown": "300"}}}`
+	Merge Contents `yaml:"merge,omitempty"`
+	// JSONPatch is a JSON Patch(RFC 6902) whose paths begin with logical IDs of resources to be modified e.g.
+	// `[{"op": "add", "path": "/SecurityGroupWorker/Properties/SecurityGroupIngress/-", "value": {...}}]`
+	JSONPatch Contents `yaml:"jsonPatch,omitempty"`
 }
 
 type Outputs struct {
@@ -143,9 +295,13 @@ type HelmRelease struct {
 }
 
 type Kubernetes struct {
-	APIServer KubernetesAPIServer `yaml:"apiserver,omitempty"`
+	APIServer         KubernetesAPIServer `yaml:"apiserver,omitempty"`
+	ControllerManager Component           `yaml:"controllerManager,omitempty"`
+	Scheduler         Component           `yaml:"scheduler,omitempty"`
+	// KubeProxy is applied to kube-proxy running on both controller and worker nodes
+	KubeProxy Component `yaml:"kubeProxy,omitempty"`
 	// Manifests is a list of manifests to be installed to the cluster.
-	// Note that the list is sorted by their names by kube-aws so that it won't result in unnecessarily node replacements.
+	// Note that manifests are sorted by their weights, plugin names and names by kube-aws so that it won't result in unnecessarily node replacements.
 	Manifests KubernetesManifests `yaml:"manifests,omitempty"`
 }
 
@@ -165,6 +321,25 @@ func (k *Kubernetes) UnmarshalYAML(unmarshal func(interface{}) error) error {
 type KubernetesAPIServer struct {
 	Flags   APIServerFlags   `yaml:"flags,omitempty"`
 	Volumes APIServerVolumes `yaml:"volumes,omitempty"`
+	Env     EnvVars          `yaml:"env,omitempty"`
+}
+
+// Component represents a set of customizations to a process run on nodes e.g. kube-controller-manager, kubelet and etcd
+type Component struct {
+	// Flags are appended to the command-line flags of the process. Values are golang text templates like ones of apiserver flags
+	Flags APIServerFlags `yaml:"flags,omitempty"`
+	// Volumes are host paths mounted into the container running the process
+	Volumes APIServerVolumes `yaml:"volumes,omitempty"`
+	// Env is a list of environment variables given to the process
+	Env EnvVars `yaml:"env,omitempty"`
+}
+
+type EnvVars []EnvVar
+
+type EnvVar struct {
+	Name string `yaml:"name,omitempty"`
+	// Value is a golang text template resulting to the value of the environment variable
+	Value string `yaml:"value,omitempty"`
 }
 
 type APIServerFlags []APIServerFlag
@@ -177,6 +352,11 @@ type APIServerFlag struct {
 	Value string `yaml:"value,omitempty"`
 }
 
+// SystemdEscapedValue returns the value escaped to be embedded in a systemd unit, in which `%` starts a specifier
+func (f APIServerFlag) SystemdEscapedValue() string {
+	return strings.Replace(f.Value, "%", "%%", -1)
+}
+
 type APIServerVolumes []APIServerVolume
 
 type APIServerVolume struct {
@@ -192,6 +372,17 @@ type KubernetesManifests []KubernetesManifest
 type KubernetesManifest struct {
 	Name     string `yaml:"name,omitempty"`
 	Contents `yaml:"contents,omitempty"`
+	// Weight determines the phase in which the manifest is applied.
+	// Manifests are applied in ascending order of their weights, and manifests of the same weight are applied in the same phase
+	Weight int `yaml:"weight,omitempty"`
+	// Wait makes kube-aws to wait for the Deployments and DaemonSets in the manifest to become ready before proceeding to the next phase
+	Wait KubernetesManifestWait `yaml:"wait,omitempty"`
+}
+
+type KubernetesManifestWait struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// TimeoutSeconds defaults to 300
+	TimeoutSeconds int `yaml:"timeoutSeconds,omitempty"`
 }
 
 type Contents struct {
@@ -201,6 +392,11 @@ type Contents struct {
 	UnknownKeys map[string]interface{} `yaml:",inline"`
 }
 
+// Empty returns true when neither `inline` nor `path` is specified
+func (c Contents) Empty() bool {
+	return c.Inline == "" && c.Source.Path == ""
+}
+
 type Source struct {
 	Path string `yaml:"path,omitempty"`
 }
@@ -222,6 +418,9 @@ type Controller struct {
 
 type Etcd struct {
 	CommonNodeConfig `yaml:",inline"`
+	// Component customizes etcd. Flags are given to etcd as equivalent `ETCD_*` environment variables so that they work for both etcd2 and etcd3.
+	// Volumes are supported only by etcd3, which runs in a rkt container
+	Component `yaml:",inline"`
 }
 
 type Worker struct {
@@ -271,6 +470,7 @@ type SystemdUnit struct {
 type Kubelet struct {
 	FeatureGates FeatureGates `yaml:"featureGates,omitempty"`
 	NodeLabels   NodeLabels   `yaml:"nodeLabels,omitempty"`
+	Component    `yaml:",inline"`
 }
 
 type FeatureGates map[string]string
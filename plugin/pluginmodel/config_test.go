@@ -0,0 +1,48 @@
+package pluginmodel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateHookPaths(t *testing.T) {
+	plugin := func(path string) Plugin {
+		p := Plugin{Metadata: Metadata{Name: "my-plugin", Version: "0.0.1"}}
+		p.Spec.Hooks.PreDestroy = HookCommands{{Path: path}}
+		return p
+	}
+
+	for _, path := range []string{"", "/usr/bin/hook", "../hook", "hooks/../../hook", "./../hook"} {
+		err := plugin(path).Validate()
+		if err == nil || !strings.Contains(err.Error(), "must be a path relative to the plugin directory") {
+			t.Errorf("expected the hook path \"%s\" to be invalid but got: %v", path, err)
+		}
+	}
+
+	for _, path := range []string{"hook.sh", "./hooks/pre-destroy", "hooks/../hook.sh"} {
+		if err := plugin(path).Validate(); err != nil {
+			t.Errorf("expected the hook path \"%s\" to be valid but got: %v", path, err)
+		}
+	}
+}
+
+func TestValidateCommandPaths(t *testing.T) {
+	plugin := func(path string) Plugin {
+		p := Plugin{Metadata: Metadata{Name: "my-plugin", Version: "0.0.1"}}
+		p.Spec.Commands = PluginCommands{{Name: "my-command", Path: path}}
+		return p
+	}
+
+	for _, path := range []string{"", "/usr/bin/anything", "../../bin/anything", "bin/../../anything"} {
+		err := plugin(path).Validate()
+		if err == nil || !strings.Contains(err.Error(), "must be a path relative to the plugin directory") {
+			t.Errorf("expected the command path \"%s\" to be invalid but got: %v", path, err)
+		}
+	}
+
+	for _, path := range []string{"bin/my-command", "./my-command"} {
+		if err := plugin(path).Validate(); err != nil {
+			t.Errorf("expected the command path \"%s\" to be valid but got: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package pluginmodel
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	ValuesTypeObject  = "object"
+	ValuesTypeArray   = "array"
+	ValuesTypeString  = "string"
+	ValuesTypeInteger = "integer"
+	ValuesTypeNumber  = "number"
+	ValuesTypeBoolean = "boolean"
+)
+
+// ValuesSchema is a subset of JSON Schema describing values of a plugin.
+// Unlike JSON Schema, keys not declared in `properties` of an object are rejected unless `additionalProperties` is true,
+// so that typos in cluster.yaml are reported rather than silently ignored
+type ValuesSchema struct {
+	Type                 string                   `yaml:"type,omitempty"`
+	Description          string                   `yaml:"description,omitempty"`
+	Properties           map[string]*ValuesSchema `yaml:"properties,omitempty"`
+	Required             []string                 `yaml:"required,omitempty"`
+	AdditionalProperties bool                     `yaml:"additionalProperties,omitempty"`
+	Items                *ValuesSchema            `yaml:"items,omitempty"`
+	Enum                 []interface{}            `yaml:"enum,omitempty"`
+	Default              interface{}              `yaml:"default,omitempty"`
+}
+
+// Valid returns an error when the schema itself is malformed
+func (s *ValuesSchema) Valid(path string) error {
+	switch s.Type {
+	case "", ValuesTypeObject, ValuesTypeArray, ValuesTypeString, ValuesTypeInteger, ValuesTypeNumber, ValuesTypeBoolean:
+	default:
+		return fmt.Errorf("%s: unknown type \"%s\"", path, s.Type)
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		if err := s.Properties[name].Valid(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.Valid(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithDefaults returns the value with missing properties filled with defaults declared in the schema
+func (s *ValuesSchema) WithDefaults(v interface{}) interface{} {
+	if v == nil {
+		v = s.Default
+	}
+	if m, ok := toStringMap(v); ok && len(s.Properties) > 0 {
+		r := map[string]interface{}{}
+		for k, e := range m {
+			r[k] = e
+		}
+		for name, p := range s.Properties {
+			if d := p.WithDefaults(r[name]); d != nil {
+				r[name] = d
+			}
+		}
+		return r
+	}
+	if s.Items != nil {
+		if a, ok := v.([]interface{}); ok {
+			r := make([]interface{}, len(a))
+			for i, e := range a {
+				r[i] = s.Items.WithDefaults(e)
+			}
+			return r
+		}
+	}
+	return v
+}
+
+// Validate returns an error describing the key path of the first value violating the schema
+func (s *ValuesSchema) Validate(path string, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	if s.Type != "" && !hasType(v, s.Type) {
+		return fmt.Errorf("%s: expected %s but was %v", path, s.Type, v)
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(normalizeNumber(e), normalizeNumber(v)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: expected one of %v but was %v", path, s.Enum, v)
+		}
+	}
+
+	if m, ok := toStringMap(v); ok {
+		for _, name := range s.Required {
+			if _, ok := m[name]; !ok {
+				return fmt.Errorf("%s.%s: required but missing", path, name)
+			}
+		}
+		for _, name := range sortedKeys(m) {
+			p, declared := s.Properties[name]
+			if !declared {
+				if len(s.Properties) > 0 && !s.AdditionalProperties {
+					return fmt.Errorf("%s.%s: unknown key. Expected one of: %s", path, name, strings.Join(sortedKeys(s.Properties), ", "))
+				}
+				continue
+			}
+			if err := p.Validate(path+"."+name, m[name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a, ok := v.([]interface{}); ok && s.Items != nil {
+		for i, e := range a {
+			if err := s.Items.Validate(fmt.Sprintf("%s[%d]", path, i), e); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case ValuesTypeObject:
+		_, ok := toStringMap(v)
+		return ok
+	case ValuesTypeArray:
+		_, ok := v.([]interface{})
+		return ok
+	case ValuesTypeString:
+		_, ok := v.(string)
+		return ok
+	case ValuesTypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case ValuesTypeInteger:
+		switch n := normalizeNumber(v).(type) {
+		case int64:
+			return true
+		case float64:
+			return n == float64(int64(n))
+		}
+		return false
+	case ValuesTypeNumber:
+		switch normalizeNumber(v).(type) {
+		case int64, float64:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// normalizeNumber converts the various numeric types yaml and json decoders produce so that they can be compared
+func normalizeNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case uint64:
+		return int64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// toStringMap converts maps decoded from yaml, whose keys are of type interface{}, to maps keyed by strings
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case Values:
+		return map[string]interface{}(m), true
+	case map[interface{}]interface{}:
+		r := map[string]interface{}{}
+		for k, e := range m {
+			r[fmt.Sprintf("%v", k)] = e
+		}
+		return r, true
+	}
+	return nil, false
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package pluginrender
+
+import (
+	"fmt"
+
+	controlplane "github.com/kubernetes-incubator/kube-aws/core/controlplane/cluster"
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/plugin"
+	"github.com/kubernetes-incubator/kube-aws/plugin/clusterextension"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+)
+
+// Output is everything a plugin contributes to a cluster, as computed by ClusterExtension
+type Output struct {
+	Plugin              string                                `yaml:"plugin"`
+	RootStack           *clusterextension.Stack               `yaml:"rootStack"`
+	ControlPlaneStack   *clusterextension.Stack               `yaml:"controlPlaneStack"`
+	Controller          *clusterextension.Controller          `yaml:"controller"`
+	Etcd                *clusterextension.Etcd                `yaml:"etcd"`
+	NodePools           map[string]*NodePool                  `yaml:"nodePools,omitempty"`
+	KubernetesManifests []clusterextension.KubernetesManifest `yaml:"kubernetesManifests,omitempty"`
+	HelmReleases        pluginmodel.HelmReleases              `yaml:"helmReleases,omitempty"`
+}
+
+// NodePool is what a plugin contributes to a node pool
+type NodePool struct {
+	Stack  *clusterextension.Stack  `yaml:"stack"`
+	Worker *clusterextension.Worker `yaml:"worker"`
+}
+
+// Render renders contributions of the plugin named `name` to the cluster configured by `cfg`.
+// The plugin is rendered as if it were enabled cluster-wide and in every node pool so that it can be tried before being enabled.
+// Values of the plugin in cluster.yaml are still respected
+func Render(name string, cfg *config.Config) (*Output, error) {
+	found := false
+	for _, p := range cfg.Plugins {
+		if p.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("plugin %s not found. Installed plugins are loaded from ./plugins, ~/.kube-aws/plugins and sources declared in cluster.yaml", name)
+	}
+
+	configs := enable(cfg.PluginConfigs, cfg.Plugins, name)
+
+	plugins, err := plugin.Resolve(cfg.Plugins, configs, controlplane.VERSION)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plugins: %v", err)
+	}
+
+	out := &Output{
+		Plugin:    name,
+		NodePools: map[string]*NodePool{},
+	}
+
+	extras := clusterextension.NewExtrasFromPlugins(plugins, configs).Only(name)
+
+	if out.RootStack, err = extras.RootStack(); err != nil {
+		return nil, err
+	}
+	if out.ControlPlaneStack, err = extras.ControlPlaneStack(); err != nil {
+		return nil, err
+	}
+	if out.Controller, err = extras.Controller(); err != nil {
+		return nil, err
+	}
+	if out.Etcd, err = extras.Etcd(); err != nil {
+		return nil, err
+	}
+	if out.KubernetesManifests, err = extras.KubernetesManifests(); err != nil {
+		return nil, err
+	}
+	out.HelmReleases = extras.HelmReleases()
+
+	for _, np := range cfg.NodePools {
+		npExtras := clusterextension.NewExtrasFromPlugins(plugins, enable(np.Plugins, cfg.Plugins, name)).Only(name)
+
+		r := &NodePool{}
+		if r.Stack, err = npExtras.NodePoolStack(); err != nil {
+			return nil, fmt.Errorf("node pool %s: %v", np.NodePoolName, err)
+		}
+		if r.Worker, err = npExtras.Worker(); err != nil {
+			return nil, fmt.Errorf("node pool %s: %v", np.NodePoolName, err)
+		}
+		out.NodePools[np.NodePoolName] = r
+	}
+
+	return out, nil
+}
+
+// enable returns a copy of the plugin configs in which the plugin named `name` is enabled
+func enable(configs model.PluginConfigs, plugins []*pluginmodel.Plugin, name string) model.PluginConfigs {
+	r := model.PluginConfigs{}
+	for k, c := range configs {
+		r[k] = c
+	}
+	for _, p := range plugins {
+		if p.Name == name {
+			c := r[p.SettingKey()]
+			c.Enabled = true
+			r[p.SettingKey()] = c
+		}
+	}
+	return r
+}
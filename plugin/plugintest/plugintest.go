@@ -0,0 +1,106 @@
+// Package plugintest helps plugin authors to test what their plugins contribute to clusters without deploying them.
+//
+// A test in a plugin repository would look like:
+//
+//	func TestMyPlugin(t *testing.T) {
+//		plugintest.Run(t, ".", []plugintest.Case{
+//			{
+//				Context: "WithQueue",
+//				ClusterYaml: plugintest.ClusterYaml + `
+//	kubeAwsPlugins:
+//	  myPlugin:
+//	    queue:
+//	      name: foo
+//	`,
+//				Assert: func(t *testing.T, out *pluginrender.Output) {
+//					if _, ok := out.ControlPlaneStack.Resources["MyQueue"]; !ok {
+//						t.Errorf("missing MyQueue: %v", out.ControlPlaneStack.Resources)
+//					}
+//				},
+//			},
+//		})
+//	}
+package plugintest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/plugin"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginrender"
+)
+
+// ClusterYaml is a minimal cluster.yaml which requires neither AWS credentials nor network access to be loaded.
+// Append `kubeAwsPlugins` and `worker.nodePools` to it to configure the plugin under test
+const ClusterYaml = `clusterName: test-cluster
+externalDNSName: test-cluster.example.com
+keyName: test-key-name
+kmsKeyArn: "arn:aws:kms:us-west-1:xxxxxxxxx:key/xxxxxxxxxxxxxxxxxxx"
+region: us-west-1
+availabilityZone: us-west-1c
+amiId: ami-12345678
+`
+
+// Case is a test case rendering the plugin with a cluster.yaml
+type Case struct {
+	Context     string
+	ClusterYaml string
+	// Assert is called with the rendered output when rendering succeeded
+	Assert func(t *testing.T, out *pluginrender.Output)
+	// ExpectedError is a part of the error message expected when rendering should fail e.g. because of invalid values
+	ExpectedError string
+}
+
+// Render loads the plugin from `pluginDir` and plugins it depends on from `dependencyDirs`,
+// and renders what the plugin contributes to the cluster configured by `clusterYaml`
+func Render(pluginDir string, clusterYaml string, dependencyDirs ...string) (*pluginrender.Output, error) {
+	loader := plugin.NewLoader()
+
+	p, err := loader.TryToLoadPluginFromDir(pluginDir)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := []*pluginmodel.Plugin{p}
+	for _, d := range dependencyDirs {
+		dep, err := loader.TryToLoadPluginFromDir(d)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, dep)
+	}
+
+	cfg, err := config.ConfigFromBytes([]byte(clusterYaml), plugins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster.yaml: %v", err)
+	}
+
+	return pluginrender.Render(p.Name, cfg)
+}
+
+// Run renders the plugin in `pluginDir` for each case in a subtest named after the case
+func Run(t *testing.T, pluginDir string, cases []Case, dependencyDirs ...string) {
+	for _, c := range cases {
+		t.Run(c.Context, func(t *testing.T) {
+			out, err := Render(pluginDir, c.ClusterYaml, dependencyDirs...)
+
+			if c.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), c.ExpectedError) {
+					t.Errorf("expected error containing \"%s\" but got: %v", c.ExpectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to render plugin: %v", err)
+			}
+
+			if c.Assert != nil {
+				c.Assert(t, out)
+			}
+		})
+	}
+}
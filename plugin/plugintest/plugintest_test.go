@@ -0,0 +1,126 @@
+package plugintest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginrender"
+)
+
+const testPluginYaml = `
+metadata:
+  name: my-plugin
+  version: 0.1.0
+spec:
+  configuration:
+    values:
+      queue:
+        name: default-queue
+    valuesSchema:
+      type: object
+      properties:
+        queue:
+          type: object
+          properties:
+            name:
+              type: string
+    cloudformation:
+      stacks:
+        controlPlane:
+          resources:
+            append:
+              inline: |
+                {
+                  "QueueFromMyPlugin": {
+                    "Type": "AWS::SQS::Queue",
+                    "Properties": {
+                      "QueueName": {{quote .Values.queue.name}}
+                    }
+                  }
+                }
+    kubernetes:
+      manifests:
+      - name: configmap.yaml
+        contents:
+          source:
+            path: manifests/configmap.yaml
+    node:
+      roles:
+        worker:
+          kubelet:
+            nodeLabels:
+              queue: enabled
+`
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugintest")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "plugin.yaml"), []byte(testPluginYaml), 0644); err != nil {
+		t.Fatalf("failed to write plugin.yaml: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "manifests"), 0755); err != nil {
+		t.Fatalf("failed to create manifests directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "manifests", "configmap.yaml"), []byte("kind: ConfigMap\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	Run(t, dir, []Case{
+		{
+			Context:     "NotEnabledInClusterYaml",
+			ClusterYaml: ClusterYaml,
+			Assert: func(t *testing.T, out *pluginrender.Output) {
+				queue := out.ControlPlaneStack.Resources["QueueFromMyPlugin"].(map[string]interface{})
+				if name := queue["Properties"].(map[string]interface{})["QueueName"]; name != "default-queue" {
+					t.Errorf("expected the default queue name but was %v", name)
+				}
+				if len(out.KubernetesManifests) != 1 || out.KubernetesManifests[0].Contents != "kind: ConfigMap\n" {
+					t.Errorf("unexpected kubernetes manifests: %+v", out.KubernetesManifests)
+				}
+			},
+		},
+		{
+			Context: "WithValuesAndNodePools",
+			ClusterYaml: ClusterYaml + `
+kubeAwsPlugins:
+  myPlugin:
+    enabled: true
+    queue:
+      name: my-queue
+worker:
+  nodePools:
+  - name: pool1
+`,
+			Assert: func(t *testing.T, out *pluginrender.Output) {
+				queue := out.ControlPlaneStack.Resources["QueueFromMyPlugin"].(map[string]interface{})
+				if name := queue["Properties"].(map[string]interface{})["QueueName"]; name != "my-queue" {
+					t.Errorf("expected the queue name from cluster.yaml but was %v", name)
+				}
+				pool, ok := out.NodePools["pool1"]
+				if !ok {
+					t.Fatalf("missing node pool pool1: %+v", out.NodePools)
+				}
+				if pool.Worker.NodeLabels["queue"] != "enabled" {
+					t.Errorf("missing node label queue=enabled: %+v", pool.Worker.NodeLabels)
+				}
+			},
+		},
+		{
+			Context: "InvalidValues",
+			ClusterYaml: ClusterYaml + `
+kubeAwsPlugins:
+  myPlugin:
+    enabled: true
+    queue:
+      name: 1
+`,
+			ExpectedError: "kubeAwsPlugins.myPlugin.queue.name: expected string but was 1",
+		},
+	})
+}
@@ -3,9 +3,40 @@ package pluginutil
 import (
 	"fmt"
 
+	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 )
 
+// ValuesFor returns values of the plugin merged with the ones configured in cluster.yaml, with defaults declared in
+// the plugin's values schema filled in. The result is validated against the schema and errors are reported with
+// the key path prefixed by `path`
+func ValuesFor(p *pluginmodel.Plugin, c *model.PluginConfig, path string) (pluginmodel.Values, error) {
+	values := MergeValues(copyValues(p.Spec.Values), copyValues(c.Values))
+
+	schema := p.Spec.ValuesSchema
+	if schema == nil {
+		return values, nil
+	}
+
+	if m, ok := schema.WithDefaults(map[string]interface{}(values)).(map[string]interface{}); ok {
+		values = pluginmodel.Values(m)
+	}
+
+	if err := schema.Validate(path, map[string]interface{}(values)); err != nil {
+		return nil, fmt.Errorf("invalid values for plugin %s: %v", p.Name, err)
+	}
+	return values, nil
+}
+
+// copyValues makes a shallow copy of values so that merging values never modifies the plugin's defaults shared among node pools
+func copyValues(v map[string]interface{}) map[string]interface{} {
+	r := map[string]interface{}{}
+	for k, e := range v {
+		r[k] = e
+	}
+	return r
+}
+
 func MergeValues(v pluginmodel.Values, o map[string]interface{}) pluginmodel.Values {
 	r := merge(map[string]interface{}(v), map[string]interface{}(o))
 	switch r := r.(type) {
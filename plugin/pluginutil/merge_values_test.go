@@ -0,0 +1,126 @@
+package pluginutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"gopkg.in/yaml.v2"
+)
+
+const pluginWithValuesSchema = `
+metadata:
+  name: my-plugin
+  version: 0.0.1
+spec:
+  configuration:
+    values:
+      queue:
+        name: bar
+    valuesSchema:
+      type: object
+      properties:
+        queue:
+          type: object
+          required:
+          - name
+          properties:
+            name:
+              type: string
+            visibilityTimeout:
+              type: integer
+              default: 30
+        tier:
+          type: string
+          enum:
+          - standard
+          - premium
+          default: standard
+`
+
+func TestValuesFor(t *testing.T) {
+	p := &pluginmodel.Plugin{}
+	if err := yaml.Unmarshal([]byte(pluginWithValuesSchema), p); err != nil {
+		t.Fatalf("failed to parse plugin: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("unexpected error in plugin: %v", err)
+	}
+
+	configFromYaml := func(data string) *model.PluginConfig {
+		c := &model.PluginConfig{}
+		if err := yaml.Unmarshal([]byte(data), c); err != nil {
+			t.Fatalf("failed to parse plugin config: %v", err)
+		}
+		return c
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		values, err := ValuesFor(p, configFromYaml(`
+enabled: true
+queue:
+  name: baz
+`), "kubeAwsPlugins.myPlugin")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if values["tier"] != "standard" {
+			t.Errorf("expected tier to default to standard but was %v", values["tier"])
+		}
+		queue := values["queue"].(map[string]interface{})
+		if queue["name"] != "baz" || queue["visibilityTimeout"] != 30 {
+			t.Errorf("unexpected queue values: %v", queue)
+		}
+		if p.Spec.Values["queue"].(map[interface{}]interface{})["name"] != "bar" {
+			t.Errorf("expected defaults of the plugin not to be modified but they were: %v", p.Spec.Values)
+		}
+	})
+
+	errorCases := []struct {
+		context  string
+		config   string
+		expected string
+	}{
+		{
+			context: "WrongType",
+			config: `
+queue:
+  name: baz
+  visibilityTimeout: thirty
+`,
+			expected: "invalid values for plugin my-plugin: kubeAwsPlugins.myPlugin.queue.visibilityTimeout: expected integer but was thirty",
+		},
+		{
+			context: "UnknownKey",
+			config: `
+queue:
+  nmae: baz
+`,
+			expected: "kubeAwsPlugins.myPlugin.queue.name: required but missing",
+		},
+		{
+			context: "Typo",
+			config: `
+teir: premium
+`,
+			expected: "kubeAwsPlugins.myPlugin.teir: unknown key",
+		},
+		{
+			context: "NotInEnum",
+			config: `
+tier: gold
+`,
+			expected: "kubeAwsPlugins.myPlugin.tier: expected one of [standard premium] but was gold",
+		},
+	}
+
+	for _, c := range errorCases {
+		t.Run(c.context, func(t *testing.T) {
+			_, err := ValuesFor(p, configFromYaml(c.config), "kubeAwsPlugins.myPlugin")
+			if err == nil || !strings.Contains(err.Error(), c.expected) {
+				t.Errorf("expected error containing \"%s\" but got: %v", c.expected, err)
+			}
+		})
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/filereader/texttemplate"
 )
 
-func RenderStringFromTemplateWithValues(expr string, values interface{}) (string, error) {
+// RenderStringFromTemplateWithValues renders the template with the plugin's values available as `.Values` and
+// values of the plugins it requires as `.Dependencies.<settings key of the required plugin>`
+func RenderStringFromTemplateWithValues(expr string, values interface{}, dependencies map[string]interface{}) (string, error) {
 	t, err := texttemplate.Parse("template", expr, template.FuncMap{})
 	data := map[string]interface{}{
-		"Values": values,
+		"Values":       values,
+		"Dependencies": dependencies,
 	}
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %v", err)
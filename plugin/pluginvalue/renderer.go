@@ -6,17 +6,19 @@ import (
 )
 
 type TemplateRenderer struct {
-	p      *pluginmodel.Plugin
-	values interface{}
+	p            *pluginmodel.Plugin
+	values       interface{}
+	dependencies map[string]interface{}
 }
 
-func TemplateRendererFor(p *pluginmodel.Plugin, values interface{}) *TemplateRenderer {
+func TemplateRendererFor(p *pluginmodel.Plugin, values interface{}, dependencies map[string]interface{}) *TemplateRenderer {
 	return &TemplateRenderer{
-		p:      p,
-		values: values,
+		p:            p,
+		values:       values,
+		dependencies: dependencies,
 	}
 }
 
 func (r *TemplateRenderer) StringFrom(expr string) (string, error) {
-	return pluginutil.RenderStringFromTemplateWithValues(expr, r.values)
+	return pluginutil.RenderStringFromTemplateWithValues(expr, r.values, r.dependencies)
 }
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+)
+
+// Resolve checks that the plugins enabled in `configs` are compatible with the kube-aws version and with each other,
+// and returns all the plugins ordered so that every enabled plugin comes after the plugins it requires.
+// Disabled plugins follow the enabled ones in their original order
+func Resolve(plugins []*pluginmodel.Plugin, configs model.PluginConfigs, kubeAwsVersion string) ([]*pluginmodel.Plugin, error) {
+	enabled := []*pluginmodel.Plugin{}
+	disabled := []*pluginmodel.Plugin{}
+	for _, p := range plugins {
+		if ok, _ := p.EnabledIn(configs); ok {
+			enabled = append(enabled, p)
+		} else {
+			disabled = append(disabled, p)
+		}
+	}
+
+	// Development builds don't have semantic versions, which are compatible with any plugin
+	version, versionErr := semver.NewVersion(kubeAwsVersion)
+
+	for _, p := range enabled {
+		if p.KubeAwsVersion != "" && versionErr == nil {
+			c, err := semver.NewConstraint(p.KubeAwsVersion)
+			if err != nil {
+				return nil, fmt.Errorf("plugin %s has an invalid kubeAwsVersion \"%s\": %v", p.Name, p.KubeAwsVersion, err)
+			}
+			if !c.Check(version) {
+				return nil, fmt.Errorf("plugin %s requires kube-aws %s but the version of kube-aws is %s", p.Name, p.KubeAwsVersion, kubeAwsVersion)
+			}
+		}
+
+		for _, r := range p.Requires {
+			if findPlugin(enabled, r) == nil {
+				return nil, fmt.Errorf("plugin %s requires plugin %s to be enabled but it isn't", p.Name, r)
+			}
+		}
+
+		for _, c := range p.Conflicts {
+			if other := findPlugin(enabled, c); other != nil {
+				return nil, fmt.Errorf("plugin %s conflicts with plugin %s %s. Disable either of them", p.Name, other.Name, other.Version)
+			}
+		}
+	}
+
+	sorted, err := sortByDependencies(enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sorted, disabled...), nil
+}
+
+func findPlugin(plugins []*pluginmodel.Plugin, ref pluginmodel.PluginReference) *pluginmodel.Plugin {
+	for _, p := range plugins {
+		if ref.MatchedBy(p) {
+			return p
+		}
+	}
+	return nil
+}
+
+// sortByDependencies topologically sorts the plugins while preserving the original order among independent ones
+func sortByDependencies(plugins []*pluginmodel.Plugin) ([]*pluginmodel.Plugin, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := map[string]int{}
+	sorted := []*pluginmodel.Plugin{}
+
+	var visit func(p *pluginmodel.Plugin, path []string) error
+	visit = func(p *pluginmodel.Plugin, path []string) error {
+		path = append(path, p.Name)
+		switch states[p.Name] {
+		case visiting:
+			return fmt.Errorf("plugins have a circular dependency: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		states[p.Name] = visiting
+		for _, r := range p.Requires {
+			if err := visit(findPlugin(plugins, r), path); err != nil {
+				return err
+			}
+		}
+		states[p.Name] = visited
+		sorted = append(sorted, p)
+		return nil
+	}
+
+	for _, p := range plugins {
+		if err := visit(p, []string{}); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubernetes-incubator/kube-aws/model"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+)
+
+func testPlugin(name string, version string, requires ...pluginmodel.PluginReference) *pluginmodel.Plugin {
+	return &pluginmodel.Plugin{
+		Metadata: pluginmodel.Metadata{
+			Name:     name,
+			Version:  version,
+			Requires: requires,
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	enableAll := func(plugins ...*pluginmodel.Plugin) model.PluginConfigs {
+		configs := model.PluginConfigs{}
+		for _, p := range plugins {
+			configs[p.SettingKey()] = model.PluginConfig{Enabled: true}
+		}
+		return configs
+	}
+
+	t.Run("DependenciesFirst", func(t *testing.T) {
+		app := testPlugin("app", "1.0.0", pluginmodel.PluginReference{Name: "queue", Version: "^2.0"})
+		queue := testPlugin("queue", "2.1.0", pluginmodel.PluginReference{Name: "storage"})
+		storage := testPlugin("storage", "0.1.0")
+		unused := testPlugin("unused", "0.1.0")
+
+		resolved, err := Resolve([]*pluginmodel.Plugin{unused, app, queue, storage}, enableAll(app, queue, storage), "v0.9.8")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		names := []string{}
+		for _, p := range resolved {
+			names = append(names, p.Name)
+		}
+		if strings.Join(names, ",") != "storage,queue,app,unused" {
+			t.Errorf("unexpected order of plugins: %v", names)
+		}
+	})
+
+	errorCases := []struct {
+		context  string
+		plugins  []*pluginmodel.Plugin
+		expected string
+	}{
+		{
+			context: "MissingDependency",
+			plugins: []*pluginmodel.Plugin{
+				testPlugin("app", "1.0.0", pluginmodel.PluginReference{Name: "queue"}),
+			},
+			expected: "requires plugin queue to be enabled",
+		},
+		{
+			context: "UnsatisfiedDependencyVersion",
+			plugins: []*pluginmodel.Plugin{
+				testPlugin("app", "1.0.0", pluginmodel.PluginReference{Name: "queue", Version: ">= 3.0"}),
+				testPlugin("queue", "2.1.0"),
+			},
+			expected: "requires plugin queue >= 3.0",
+		},
+		{
+			context: "CircularDependency",
+			plugins: []*pluginmodel.Plugin{
+				testPlugin("app", "1.0.0", pluginmodel.PluginReference{Name: "queue"}),
+				testPlugin("queue", "2.1.0", pluginmodel.PluginReference{Name: "app"}),
+			},
+			expected: "circular dependency: app -> queue -> app",
+		},
+	}
+
+	for _, c := range errorCases {
+		t.Run(c.context, func(t *testing.T) {
+			_, err := Resolve(c.plugins, enableAll(c.plugins...), "v0.9.8")
+			if err == nil || !strings.Contains(err.Error(), c.expected) {
+				t.Errorf("expected error containing \"%s\" but got: %v", c.expected, err)
+			}
+		})
+	}
+
+	t.Run("Conflict", func(t *testing.T) {
+		app := testPlugin("app", "1.0.0")
+		app.Conflicts = pluginmodel.PluginReferences{{Name: "legacy-app", Version: "< 2"}}
+		legacy := testPlugin("legacy-app", "1.5.0")
+
+		_, err := Resolve([]*pluginmodel.Plugin{app, legacy}, enableAll(app, legacy), "v0.9.8")
+		if err == nil || !strings.Contains(err.Error(), "conflicts with plugin legacy-app") {
+			t.Errorf("expected a conflict but got: %v", err)
+		}
+
+		if _, err := Resolve([]*pluginmodel.Plugin{app, legacy}, enableAll(app), "v0.9.8"); err != nil {
+			t.Errorf("expected no conflict with a disabled plugin but got: %v", err)
+		}
+	})
+
+	t.Run("KubeAwsVersion", func(t *testing.T) {
+		app := testPlugin("app", "1.0.0")
+		app.KubeAwsVersion = ">= 0.10"
+
+		_, err := Resolve([]*pluginmodel.Plugin{app}, enableAll(app), "v0.9.8")
+		if err == nil || !strings.Contains(err.Error(), "requires kube-aws >= 0.10") {
+			t.Errorf("expected an incompatible kube-aws version to be rejected but got: %v", err)
+		}
+
+		if _, err := Resolve([]*pluginmodel.Plugin{app}, enableAll(app), "UNKNOWN"); err != nil {
+			t.Errorf("expected development builds to be compatible with any plugin but got: %v", err)
+		}
+	})
+}
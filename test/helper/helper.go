@@ -30,7 +30,7 @@ func WithDummyCredentials(fn func(dir string)) {
 	// config/temp, nodepool/config/temp, test/integration/temp
 	defer os.RemoveAll(dir)
 
-	for _, pairName := range []string{"ca", "apiserver", "worker", "admin", "etcd", "etcd-client"} {
+	for _, pairName := range []string{"ca", "apiserver", "worker", "admin", "etcd", "etcd-client", "etcd-node-0", "etcd-node-1", "etcd-node-2"} {
 		certFile := fmt.Sprintf("%s/%s.pem", dir, pairName)
 		if err := ioutil.WriteFile(certFile, []byte("dummycert"), 0644); err != nil {
 			panic(err)
@@ -44,5 +44,16 @@ func WithDummyCredentials(fn func(dir string)) {
 		defer os.Remove(keyFile)
 	}
 
+	// Credentials dedicated to a node pool named `pool1`, used when `kubeletIdentity` is `nodePool`
+	nodePoolDir := fmt.Sprintf("%s/nodepools/pool1", dir)
+	if err := os.MkdirAll(nodePoolDir, 0700); err != nil {
+		panic(err)
+	}
+	for _, name := range []string{"worker.pem", "worker-key.pem"} {
+		if err := ioutil.WriteFile(fmt.Sprintf("%s/%s", nodePoolDir, name), []byte("dummy"), 0644); err != nil {
+			panic(err)
+		}
+	}
+
 	fn(dir)
 }
@@ -1,9 +1,11 @@
 package integration
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -761,6 +763,549 @@ etcd:
 				hasDefaultCluster,
 			},
 		},
+		{
+			context: "WithEtcdPerNodeCertificates",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  count: 3
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  perNodeCertificates: true
+`,
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					s3Part := c.ControlPlane().UserDataEtcd.Parts[model.USERDATA_S3]
+					if len(s3Part.PerInstanceAssets) != 3 {
+						t.Errorf("expected etcd userdata to be rendered for each of 3 etcd nodes but was rendered for %d", len(s3Part.PerInstanceAssets))
+					}
+				},
+				func(c root.Cluster, t *testing.T) {
+					template, err := c.ControlPlane().RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the stack template: %v", err)
+					}
+					var stack struct {
+						Resources map[string]struct {
+							Properties struct {
+								Policies []struct {
+									PolicyDocument struct {
+										Statement []struct {
+											Resource interface{}
+										}
+									}
+								}
+							}
+						}
+					}
+					if err := json.Unmarshal([]byte(template), &stack); err != nil {
+						t.Fatalf("failed to parse the stack template: %v", err)
+					}
+					if _, ok := stack.Resources["IAMRoleEtcd"]; ok {
+						t.Errorf("expected no iam role to be shared among etcd nodes")
+					}
+
+					s3Part := c.ControlPlane().UserDataEtcd.Parts[model.USERDATA_S3]
+					for n := 0; n < 3; n++ {
+						role, ok := stack.Resources[fmt.Sprintf("IAMRoleEtcd%d", n)]
+						if !ok {
+							t.Fatalf("expected the iam role for the etcd node %d to exist but it did not", n)
+						}
+						resources := []string{}
+						for _, p := range role.Properties.Policies {
+							for _, s := range p.PolicyDocument.Statement {
+								resources = append(resources, iamResources(s.Resource)...)
+							}
+						}
+						for m := 0; m < 3; m++ {
+							asset := s3Part.AssetFor(m)
+							arn := fmt.Sprintf("arn:aws:s3:::%s/%s", asset.Bucket, asset.Key)
+							if covered := iamResourcesMatch(resources, arn); covered != (n == m) {
+								t.Errorf("expected the policy of the etcd node %d to cover the userdata of the etcd node %d to be %v, but was %v: %v", n, m, n == m, covered, resources)
+							}
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithKubeletIdentityPerNodePool",
+			configYaml: minimalValidConfigYaml + `
+kubeletIdentity: nodePool
+experimental:
+  plugins:
+    rbac:
+      enabled: true
+worker:
+  nodePools:
+  - name: pool1
+`,
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					controllerUserdata := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdata, "name: kube-worker:pool1") {
+						t.Errorf("expected the node pool's kubelet user to be bound to node roles but it wasn't: %s", controllerUserdata)
+					}
+					if strings.Contains(controllerUserdata, "name: kube-worker\n") {
+						t.Errorf("expected the shared kubelet user not to be bound to any role but it was: %s", controllerUserdata)
+					}
+					np := c.NodePools()[0]
+					if np.AssetsConfig.WorkerNodePoolCerts["pool1"] == "" {
+						t.Errorf("expected the node pool to be provisioned with its own certificate but it wasn't")
+					}
+				},
+			},
+		},
+		{
+			context: "WithNodePoolScaledFromZero",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+worker:
+  nodePools:
+  - name: gpu
+    autoScalingGroup:
+      minSize: 0
+      maxSize: 3
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+    nodeLabels:
+      kube-aws.coreos.com/role: gpu
+    taints:
+    - key: dedicated
+      value: gpu
+      effect: NoSchedule
+`,
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					template, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the node pool stack template: %v", err)
+					}
+					for _, tag := range []string{
+						`{"Key":"k8s.io/cluster-autoscaler/node-template/label/kube-aws.coreos.com/role","PropagateAtLaunch":"false","Value":"gpu"}`,
+						`{"Key":"k8s.io/cluster-autoscaler/node-template/taint/dedicated","PropagateAtLaunch":"false","Value":"gpu:NoSchedule"}`,
+					} {
+						if !strings.Contains(template, tag) {
+							t.Errorf("expected the autoscaling group to have the tag %s but it didn't: %s", tag, template)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithScalingPolicies",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: dev
+    autoScalingGroup:
+      minSize: 0
+      maxSize: 3
+    scaling:
+      scheduledActions:
+      - name: ScaleDownAtNight
+        recurrence: "0 20 * * 1-5"
+        minSize: 0
+        desiredCapacity: 0
+      - name: ScaleUpInTheMorning
+        recurrence: "0 8 * * 1-5"
+        minSize: 1
+        desiredCapacity: 2
+      targetTrackingPolicies:
+      - name: CPU
+        predefinedMetric: ASGAverageCPUUtilization
+        targetValue: 50
+        estimatedInstanceWarmup: 300
+`,
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					template, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the node pool stack template: %v", err)
+					}
+					for _, resource := range []string{
+						`"WorkersScheduledActionScaleDownAtNight":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"DesiredCapacity":"0","MinSize":"0","Recurrence":"0 20 * * 1-5"},"Type":"AWS::AutoScaling::ScheduledAction"}`,
+						`"WorkersScheduledActionScaleUpInTheMorning":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"DesiredCapacity":"2","MinSize":"1","Recurrence":"0 8 * * 1-5"},"Type":"AWS::AutoScaling::ScheduledAction"}`,
+						`"WorkersTargetTrackingPolicyCPU":{"Properties":{"AutoScalingGroupName":{"Ref":"Workers"},"EstimatedInstanceWarmup":"300","PolicyType":"TargetTrackingScaling","TargetTrackingConfiguration":{"DisableScaleIn":"false","PredefinedMetricSpecification":{"PredefinedMetricType":"ASGAverageCPUUtilization"},"TargetValue":"50"}},"Type":"AWS::AutoScaling::ScalingPolicy"}`,
+						`"AutoScalingScheduledAction":{"IgnoreUnmodifiedGroupSizeProperties":"true"}`,
+					} {
+						if !strings.Contains(template, resource) {
+							t.Errorf("expected the node pool stack template to contain %s but it didn't: %s", resource, template)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithSystemNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: system
+    role: system
+  - name: tenant
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					system := c.NodePools[0]
+					if system.NodeLabels()["kube-aws.coreos.com/role"] != "system" {
+						t.Errorf("expected the system node pool to be labeled, but was %v", system.NodeLabels())
+					}
+					expectedTaints := model.Taints{{Key: "kube-aws.coreos.com/role", Value: "system", Effect: "NoSchedule"}}
+					if !reflect.DeepEqual(expectedTaints, system.Taints) {
+						t.Errorf("unexpected taints of the system node pool: expected=%v actual=%v", expectedTaints, system.Taints)
+					}
+					tenant := c.NodePools[1]
+					if len(tenant.NodeLabels()) != 0 || len(tenant.Taints) != 0 {
+						t.Errorf("expected the tenant node pool to have neither labels nor taints, but was %v and %v", tenant.NodeLabels(), tenant.Taints)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					content := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"              nodeSelector:\n                kube-aws.coreos.com/role: system\n              tolerations:",
+						"              - key: \"kube-aws.coreos.com/role\"\n                operator: \"Equal\"\n                value: \"system\"\n                effect: \"NoSchedule\"",
+					} {
+						if !strings.Contains(content, expected) {
+							t.Errorf("expected the controller userdata to contain %q but it didn't", expected)
+						}
+					}
+					worker := c.NodePools()[0].UserDataWorker.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(worker, "kube-aws.coreos.com/role=system:NoSchedule") {
+						t.Errorf("expected nodes in the system node pool to be registered with the taint, but they weren't: %s", worker)
+					}
+				},
+			},
+		},
+		{
+			context: "WithArm64NodePool",
+			configYaml: minimalValidConfigYaml + `
+hyperkubeImage:
+  architectures:
+    arm64:
+      repo: example.com/hyperkube-arm64
+awsCliImage:
+  repo: quay.io/coreos/awscli
+  tag: master
+  architectures:
+    arm64:
+      repo: example.com/awscli-arm64
+pauseImage:
+  repo: gcr.io/google_containers/pause-amd64
+  tag: 3.0
+  architectures:
+    arm64:
+      repo: gcr.io/google_containers/pause-arm64
+flannelImage:
+  repo: quay.io/coreos/flannel
+  tag: v0.7.1
+  architectures:
+    arm64:
+      tag: v0.7.1-arm64
+experimental:
+  nodeDrainer:
+    enabled: true
+worker:
+  nodePools:
+  - name: arm
+    instanceType: m6g.large
+    amiId: ami-arm64
+  - name: x86
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					arm := c.NodePools[0]
+					if arm.Arch() != "arm64" {
+						t.Errorf("expected the architecture of the node pool to be derived from its instance type, but was %s", arm.Arch())
+					}
+					x86 := c.NodePools[1]
+					if x86.Arch() != "amd64" || x86.HyperkubeImage.Repo != "quay.io/coreos/hyperkube" {
+						t.Errorf("expected the amd64 node pool to keep the default images, but was %s", x86.HyperkubeImage.RepoWithTag())
+					}
+					if arm.HyperkubeImage.RepoWithTag() != "example.com/hyperkube-arm64:"+x86.HyperkubeImage.Tag {
+						t.Errorf("unexpected hyperkube image for the arm64 node pool: %s", arm.HyperkubeImage.RepoWithTag())
+					}
+					if arm.AWSCliImage.RepoWithTag() != "example.com/awscli-arm64:master" {
+						t.Errorf("unexpected aws cli image for the arm64 node pool: %s", arm.AWSCliImage.RepoWithTag())
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					content := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"          name: kube-node-drainer-ds\n",
+						"          name: kube-node-drainer-ds-arm64\n",
+						"              nodeSelector:\n                beta.kubernetes.io/arch: arm64\n",
+						"image: example.com/hyperkube-arm64:",
+						"kubectl() { /lib/ld-musl-aarch64.so.1 /opt/bin/hyperkube kubectl",
+						"              nodeSelector:\n                beta.kubernetes.io/arch: amd64\n",
+					} {
+						if !strings.Contains(content, expected) {
+							t.Errorf("expected the controller userdata to contain %q but it didn't", expected)
+						}
+					}
+					stack, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the stack template of the arm64 node pool: %v", err)
+					}
+					if !strings.Contains(stack, `"ImageId":"ami-arm64"`) {
+						t.Errorf("expected the arm64 node pool to be launched from the specified AMI, but it wasn't: %s", stack)
+					}
+				},
+			},
+		},
+		{
+			context: "WithArm64NodePoolWithItsOwnImages",
+			configYaml: minimalValidConfigYaml + `
+hyperkubeImage:
+  architectures:
+    arm64:
+      repo: example.com/hyperkube-arm64
+worker:
+  nodePools:
+  - name: arm
+    instanceType: m6g.large
+    amiId: ami-arm64
+    hyperkubeImage:
+      repo: example.com/hyperkube-custom
+      tag: v1.8-custom
+    awsCliImage:
+      repo: example.com/awscli-custom
+      tag: v1
+    pauseImage:
+      repo: example.com/pause-custom
+      tag: v1
+    flannelImage:
+      repo: example.com/flannel-custom
+      tag: v1
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					arm := c.NodePools[0]
+					if arm.HyperkubeImage.Repo != "example.com/hyperkube-custom" {
+						t.Errorf("expected the hyperkube image specified in the node pool not to be replaced with the one for arm64 of the cluster, but was %s", arm.HyperkubeImage.RepoWithTag())
+					}
+					if arm.AWSCliImage.RepoWithTag() != "example.com/awscli-custom:v1" {
+						t.Errorf("expected the aws cli image specified in the node pool to be used as is, but was %s", arm.AWSCliImage.RepoWithTag())
+					}
+				},
+			},
+		},
+		{
+			context: "WithOSUpdates",
+			configYaml: minimalValidConfigYaml + `
+osUpdates:
+  strategy: rebootWindow
+  rebootWindow:
+    start: Thu 04:00
+    length: 1h
+worker:
+  nodePools:
+  - name: coordinated
+    osUpdates:
+      strategy: kubernetes
+  - name: inherited
+  - name: frozen
+    osUpdates:
+      strategy: disabled
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if !c.UpdateOperatorEnabled() {
+						t.Errorf("expected container-linux-update-operator to be enabled for the node pool with OS updates coordinated by Kubernetes")
+					}
+					if _, ok := c.NodeLabels()["kube-aws.coreos.com/os-updates"]; ok {
+						t.Errorf("expected controller nodes not to run the update-agent, but they were labeled: %v", c.NodeLabels())
+					}
+					if c.NodePools[0].NodeLabels()["kube-aws.coreos.com/os-updates"] != "kubernetes" {
+						t.Errorf("expected nodes in the coordinated node pool to be labeled to run the update-agent, but was %v", c.NodePools[0].NodeLabels())
+					}
+					if c.NodePools[1].OSUpdates.StrategyOrDefault() != "rebootWindow" {
+						t.Errorf("expected the node pool to inherit the cluster-wide osUpdates, but was %+v", c.NodePools[1].OSUpdates)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					reboot := "  update:\n    reboot-strategy: \"reboot\"\n  locksmith:\n    window_start: \"Thu 04:00\"\n    window_length: \"1h\"\n"
+					controller := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					if strings.Contains(controller, "reboot-strategy: \"reboot\"") {
+						t.Errorf("expected controller nodes not to reboot without taking the lock, but they did: %s", controller)
+					}
+					for _, expected := range []string{
+						"  update:\n    reboot-strategy: \"etcd-lock\"\n  locksmith:\n    group: controllers\n    etcd_cafile: /etc/kubernetes/ssl/ca.pem\n    etcd_certfile: /etc/kubernetes/ssl/etcd-client.pem\n    etcd_keyfile: /etc/kubernetes/ssl/etcd-client-key.pem\n    window_start: \"Thu 04:00\"\n    window_length: \"1h\"\n",
+						"            EnvironmentFile=-/run/locksmithd/etcd-endpoints.opts\n",
+						"            ExecStartPre=/bin/sh -ec \"echo ETCD_ENDPOINT_FLAGS=--endpoint=${ETCD_ENDPOINTS} | sed 's/,/ --endpoint=/g' >/run/locksmithd/etcd-endpoints.opts\"\n",
+						"            ExecStart=\n            ExecStart=/usr/lib/locksmith/locksmithd $ETCD_ENDPOINT_FLAGS\n",
+						"kubectl apply -f \"${mfdir}/container-linux-update-operator.yaml\"",
+						"            nodeSelector:\n              kube-aws.coreos.com/os-updates: kubernetes\n",
+					} {
+						if !strings.Contains(controller, expected) {
+							t.Errorf("expected the controller userdata to contain %q but it didn't", expected)
+						}
+					}
+
+					etcd := c.ControlPlane().UserDataEtcd.Parts[model.USERDATA_S3].Asset.Content
+					for _, expected := range []string{
+						"    reboot-strategy: \"etcd-lock\"\n  locksmith:\n    etcd_cafile: /etc/ssl/certs/ca.pem\n",
+						"    window_start: \"Thu 04:00\"\n",
+						"ExecStart=/usr/lib/locksmith/locksmithd --endpoint=${ETCDCTL_ENDPOINT}",
+					} {
+						if !strings.Contains(etcd, expected) {
+							t.Errorf("expected the etcd userdata to contain %q but it didn't", expected)
+						}
+					}
+
+					coordinated := c.NodePools()[0].UserDataWorker.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(coordinated, "  update:\n    reboot-strategy: \"off\"\n  flannel:") {
+						t.Errorf("expected locksmith not to reboot nodes coordinated by Kubernetes, but it did: %s", coordinated)
+					}
+					inherited := c.NodePools()[1].UserDataWorker.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(inherited, reboot) {
+						t.Errorf("expected the node pool to reboot within the cluster-wide reboot window, but it didn't: %s", inherited)
+					}
+					frozen := c.NodePools()[2].UserDataWorker.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(frozen, "    - name: update-engine.service\n      command: stop\n      mask: true\n") {
+						t.Errorf("expected update-engine to be masked in the node pool with OS updates disabled, but it wasn't: %s", frozen)
+					}
+				},
+			},
+		},
+		{
+			context: "WithOSUpdatesCoordinatedOnlyInAmd64NodePool",
+			configYaml: minimalValidConfigYaml + `
+hyperkubeImage:
+  architectures:
+    arm64:
+      repo: example.com/hyperkube-arm64
+awsCliImage:
+  repo: quay.io/coreos/awscli
+  tag: master
+  architectures:
+    arm64:
+      repo: example.com/awscli-arm64
+pauseImage:
+  repo: gcr.io/google_containers/pause-amd64
+  tag: 3.0
+  architectures:
+    arm64:
+      repo: gcr.io/google_containers/pause-arm64
+flannelImage:
+  repo: quay.io/coreos/flannel
+  tag: v0.7.1
+  architectures:
+    arm64:
+      tag: v0.7.1-arm64
+worker:
+  nodePools:
+  - name: arm
+    instanceType: m6g.large
+    amiId: ami-arm64
+  - name: x86
+    osUpdates:
+      strategy: kubernetes
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					if archs := c.UpdateAgentArchitectures(); !reflect.DeepEqual(archs, []string{"amd64"}) {
+						t.Errorf("expected the update-agent to be run only on amd64 nodes, but was %v", archs)
+					}
+				},
+			},
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					controller := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(controller, "        name: container-linux-update-agent\n") {
+						t.Errorf("expected the update-agent for amd64 to be rendered, but it wasn't")
+					}
+					if strings.Contains(controller, "container-linux-update-agent-arm64") {
+						t.Errorf("expected no update-agent for arm64 nodes without OS updates coordinated by Kubernetes, but it was rendered")
+					}
+				},
+			},
+		},
+		{
+			context: "WithoutPluginsAndWithPodSecurityPolicy",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  admission:
+    podSecurityPolicy:
+      enabled: true
+`,
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					controller := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					if strings.Contains(controller, "create configmap kube-aws-plugins") {
+						t.Errorf("expected nothing to be recorded as applied by plugins without kubernetes manifests, but it was")
+					}
+					for _, expected := range []string{
+						"if ! kubectl -n kube-system get configmap kube-aws-plugins; then\n",
+						" networkpolicies podsecuritypolicies customresourcedefinitions; do\n",
+					} {
+						if !strings.Contains(controller, expected) {
+							t.Errorf("expected the controller userdata to contain %q but it didn't", expected)
+						}
+					}
+				},
+			},
+		},
+		{
+			context: "WithKubeletConfig",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  kubelet:
+    systemReserved:
+      memory: 256Mi
+worker:
+  nodePools:
+  - name: pool1
+    featureGates:
+      CPUManager: "true"
+    kubelet:
+      systemReserved:
+        cpu: 100m
+        memory: 256Mi
+      kubeReserved:
+        cpu: 200m
+      evictionHard:
+        memory.available: 100Mi
+        nodefs.available: 10%
+      maxPods: 50
+      cpuManagerPolicy: static
+  - name: pool2
+`,
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					controllerUserdata := c.ControlPlane().UserDataController.Parts[model.USERDATA_S3].Asset.Content
+					if !strings.Contains(controllerUserdata, "--system-reserved=memory=256Mi \\") {
+						t.Errorf("expected the controller kubelet to reserve resources but it didn't: %s", controllerUserdata)
+					}
+
+					pool1Userdata := c.NodePools()[0].UserDataWorker.Parts[model.USERDATA_S3].Asset.Content
+					for _, flag := range []string{
+						"--system-reserved=cpu=100m,memory=256Mi \\",
+						"--kube-reserved=cpu=200m \\",
+						"--eviction-hard=memory.available<100Mi,nodefs.available<10%% \\",
+						"--max-pods=50 \\",
+						"--cpu-manager-policy=static \\",
+					} {
+						if !strings.Contains(pool1Userdata, flag) {
+							t.Errorf("expected the kubelet in pool1 to be run with %s but it wasn't: %s", flag, pool1Userdata)
+						}
+					}
+
+					pool2Userdata := c.NodePools()[1].UserDataWorker.Parts[model.USERDATA_S3].Asset.Content
+					if strings.Contains(pool2Userdata, "--system-reserved") {
+						t.Errorf("expected the kubelet config of pool1 not to affect pool2 but it did: %s", pool2Userdata)
+					}
+				},
+			},
+		},
 		{
 			context: "WithEtcdMemberIdentityProviderENIWithCustomDomain",
 			configYaml: minimalValidConfigYaml + `
@@ -1316,7 +1861,7 @@ worker:
         - arn:aws:elasticloadbalancing:eu-west-1:xxxxxxxxxxxx:targetgroup/manuallymanagedetg/xxxxxxxxxxxxxxxx
       securityGroupIds:
         - sg-12345678
-    # Ignored, uses global setting
+    # "enabled" is ignored and inherited from the global setting
     nodeDrainer:
       enabled: true
       drainTimeout: 5
@@ -1573,6 +2118,12 @@ worker:
     autoScalingGroup:
       minSize: 0
       maxSize: 10
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+addons:
+  clusterAutoscaler:
+    enabled: true
 `,
 			assertConfig: []ConfigTester{
 				hasDefaultEtcdSettings,
@@ -2719,6 +3270,48 @@ worker:
 `,
 			assertConfig: []ConfigTester{},
 		},
+		{
+			context: "WithNodeDrainerOverriddenPerNodePool",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 5
+    gracePeriod: 30
+worker:
+  nodePools:
+  - name: pool1
+    nodeDrainer:
+      drainTimeout: 10
+      ignoreDaemonSets: false
+  - name: pool2
+`,
+			assertConfig: []ConfigTester{
+				func(c *config.Config, t *testing.T) {
+					ignore := false
+					expected := model.NodeDrainer{
+						Enabled:          true,
+						DrainTimeout:     10,
+						GracePeriod:      30,
+						IgnoreDaemonSets: &ignore,
+					}
+					actual := c.NodePools[0].Experimental.NodeDrainer
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf("node drainer settings for node pool didn't match : expected=%+v actual=%+v", expected, actual)
+					}
+
+					expected = model.NodeDrainer{
+						Enabled:      true,
+						DrainTimeout: 5,
+						GracePeriod:  30,
+					}
+					actual = c.NodePools[1].Experimental.NodeDrainer
+					if !reflect.DeepEqual(expected, actual) {
+						t.Errorf("node drainer settings for node pool didn't match : expected=%+v actual=%+v", expected, actual)
+					}
+				},
+			},
+		},
 		{
 			context: "WithSpotFleetEnabled",
 			configYaml: minimalValidConfigYaml + `
@@ -2845,6 +3438,47 @@ worker:
 				},
 			},
 		},
+		{
+			context: "WithSpotFleetWithOnDemandCapacity",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      onDemandTargetCapacity: 4
+      allocationStrategy: lowestPrice
+      instancePoolsToUseCount: 2
+      replaceUnhealthyInstances: true
+      capacityRebalance: true
+`,
+			assertConfig: []ConfigTester{
+				hasDefaultLaunchSpecifications,
+				spotFleetBasedNodePoolHasWaitSignalDisabled,
+			},
+			assertCluster: []ClusterTester{
+				func(c root.Cluster, t *testing.T) {
+					template, err := c.NodePools()[0].RenderStackTemplateAsString()
+					if err != nil {
+						t.Fatalf("failed to render the node pool stack template: %v", err)
+					}
+					for _, fragment := range []string{
+						`"WorkersLaunchTemplate0":{"Type":"AWS::EC2::LaunchTemplate"`,
+						`"WorkersLaunchTemplate1":{"Type":"AWS::EC2::LaunchTemplate"`,
+						`"AllocationStrategy":"lowestPrice","InstancePoolsToUseCount":2,"ReplaceUnhealthyInstances":true,"SpotMaintenanceStrategies":{"CapacityRebalance":{"ReplacementStrategy":"launch"}},"TargetCapacity":10`,
+						`"OnDemandTargetCapacity":4,"LaunchTemplateConfigs":[{"LaunchTemplateSpecification":{"LaunchTemplateId":{"Ref":"WorkersLaunchTemplate0"}`,
+						`"InstanceType":"c4.xlarge","SpotPrice":"0.12"`,
+					} {
+						if !strings.Contains(template, fragment) {
+							t.Errorf("expected the node pool stack template to contain %s but it didn't: %s", fragment, template)
+						}
+					}
+					if strings.Contains(template, `"LaunchSpecifications"`) {
+						t.Errorf("expected launch specifications to be replaced with launch templates but they weren't: %s", template)
+					}
+				},
+			},
+		},
 		{
 			context: "WithSpotFleetWithCustomIo1RootVolumeSettings",
 			configYaml: minimalValidConfigYaml + `
@@ -3555,6 +4189,46 @@ etcd:
 `,
 			expectedErrorMessage: "`etcd.disasterRecovery.automated` is set to true for enabling automated disaster recovery. However the feature is available only for etcd version 3",
 		},
+		{
+			context: "WithEtcdPerNodeCertificatesAndInstanceProfile",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eni
+  internalDomainName: internal.example.com
+  perNodeCertificates: true
+  iam:
+    instanceProfile:
+      arn: arn:aws:iam::123456789012:instance-profile/etcd
+`,
+			expectedErrorMessage: "`etcd.perNodeCertificates` can't be used with `etcd.iam.instanceProfile.arn`",
+		},
+		{
+			context: "WithEtcdPerNodeCertificatesRequiresKnownFQDNs",
+			configYaml: minimalValidConfigYaml + `
+etcd:
+  memberIdentityProvider: eip
+  perNodeCertificates: true
+`,
+			expectedErrorMessage: "`etcd.perNodeCertificates` requires `etcd.memberIdentityProvider` to be \"eni\" and `etcd.internalDomainName` to be set so that the FQDN of each etcd node is known before it is created",
+		},
+		{
+			context: "WithKubeletIdentityPerNodePoolWithoutRBAC",
+			configYaml: minimalValidConfigYaml + `
+kubeletIdentity: nodePool
+`,
+			expectedErrorMessage: "kubeletIdentity \"nodePool\" requires experimental.plugins.rbac.enabled to be true so that kubelets are authorized only via their own identities",
+		},
+		{
+			context: "WithKubeletIdentityTLSBootstrapWithoutTLSBootstrap",
+			configYaml: minimalValidConfigYaml + `
+kubeletIdentity: tlsBootstrap
+experimental:
+  plugins:
+    rbac:
+      enabled: true
+`,
+			expectedErrorMessage: "kubeletIdentity \"tlsBootstrap\" requires experimental.tlsBootstrap.enabled to be true",
+		},
 		{
 			context: "WithInvalidNodeDrainTimeout",
 			configYaml: minimalValidConfigYaml + `
@@ -3565,6 +4239,190 @@ experimental:
 `,
 			expectedErrorMessage: "Drain timeout must be an integer between 1 and 60, but was 100",
 		},
+		{
+			context: "WithInvalidNodeDrainGracePeriodInNodePool",
+			configYaml: minimalValidConfigYaml + `
+experimental:
+  nodeDrainer:
+    enabled: true
+    drainTimeout: 1
+worker:
+  nodePools:
+  - name: pool1
+    nodeDrainer:
+      gracePeriod: 60
+`,
+			expectedErrorMessage: "Grace period must be an integer between 0 and 59, which is shorter than the drain timeout, but was 60",
+		},
+		{
+			context: "WithNodePoolScaledFromZeroWithoutClusterAutoscaler",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    autoScalingGroup:
+      minSize: 0
+      maxSize: 3
+`,
+			expectedErrorMessage: "`autoScalingGroup.minSize` is 0 but nothing scales out the node pool from zero nodes",
+		},
+		{
+			context: "WithScalingPoliciesAndClusterAutoscaler",
+			configYaml: minimalValidConfigYaml + `
+addons:
+  clusterAutoscaler:
+    enabled: true
+worker:
+  nodePools:
+  - name: pool1
+    autoscaling:
+      clusterAutoscaler:
+        enabled: true
+    scaling:
+      targetTrackingPolicies:
+      - name: CPU
+        predefinedMetric: ASGAverageCPUUtilization
+        targetValue: 50
+`,
+			expectedErrorMessage: "`scaling` conflicts with `autoscaling.clusterAutoscaler.enabled`",
+		},
+		{
+			context: "WithOSUpdatesCoordinatedInArm64NodePoolWithoutImageForArchitecture",
+			configYaml: minimalValidConfigYaml + `
+hyperkubeImage:
+  architectures:
+    arm64:
+      repo: example.com/hyperkube-arm64
+awsCliImage:
+  repo: quay.io/coreos/awscli
+  tag: master
+  architectures:
+    arm64:
+      repo: example.com/awscli-arm64
+pauseImage:
+  repo: gcr.io/google_containers/pause-amd64
+  tag: 3.0
+  architectures:
+    arm64:
+      repo: gcr.io/google_containers/pause-arm64
+flannelImage:
+  repo: quay.io/coreos/flannel
+  tag: v0.7.1
+  architectures:
+    arm64:
+      tag: v0.7.1-arm64
+osUpdates:
+  strategy: kubernetes
+worker:
+  nodePools:
+  - name: arm
+    instanceType: m6g.large
+    amiId: ami-arm64
+`,
+			expectedErrorMessage: "containerLinuxUpdateOperatorImage has no image for the architecture arm64",
+		},
+		{
+			context: "WithUnknownKeyInScheduledAction",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    scaling:
+      scheduledActions:
+      - name: ScaleDown
+        recurrence: "0 20 * * *"
+        desiredCapacity: 0
+        timezone: Asia/Tokyo
+`,
+			expectedErrorMessage: "unknown keys found in worker.nodePools[0].scaling.scheduledActions[0]: timezone",
+		},
+		{
+			context: "WithInvalidNodePoolRole",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    role: master
+`,
+			expectedErrorMessage: "role must be either empty or \"system\", but was \"master\"",
+		},
+		{
+			context: "WithArm64NodePoolWithoutImageForArchitecture",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: arm
+    instanceType: m6g.large
+    amiId: ami-arm64
+`,
+			expectedErrorMessage: "awsCliImage has no image for the architecture arm64. Specify one in `awsCliImage.architectures.arm64`",
+		},
+		{
+			context: "WithArchitectureMismatchingInstanceType",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: arm
+    architecture: arm64
+    instanceType: c4.large
+`,
+			expectedErrorMessage: "instance type c4.large is for amd64 but the architecture of the node pool is arm64",
+		},
+		{
+			context: "WithInvalidOSUpdatesStrategy",
+			configYaml: minimalValidConfigYaml + `
+osUpdates:
+  strategy: always
+`,
+			expectedErrorMessage: "invalid osUpdates: strategy must be one of off, disabled, rebootWindow, kubernetes, but was \"always\"",
+		},
+		{
+			context: "WithOSUpdatesRebootWindowWithoutStart",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    osUpdates:
+      strategy: rebootWindow
+      rebootWindow:
+        length: 1h
+`,
+			expectedErrorMessage: "invalid osUpdates: invalid rebootWindow: start must be a time in UTC",
+		},
+		{
+			context: "WithUnknownKeyInOSUpdates",
+			configYaml: minimalValidConfigYaml + `
+osUpdates:
+  strategy: rebootWindow
+  rebootWindow:
+    start: Thu 04:00
+    length: 1h
+    timezone: UTC
+`,
+			expectedErrorMessage: "unknown keys found in osUpdates.rebootWindow: timezone",
+		},
+		{
+			context: "WithInvalidKubeletConfigInNodePool",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    kubelet:
+      evictionSoft:
+        memory.available: 100Mi
+`,
+			expectedErrorMessage: "invalid kubelet: invalid evictionSoft: eviction signal memory.available requires a grace period in evictionSoftGracePeriod",
+		},
+		{
+			context: "WithUnknownKeyInKubeletConfig",
+			configYaml: minimalValidConfigYaml + `
+controller:
+  kubelet:
+    systemReserved:
+      gpu: 1
+`,
+			expectedErrorMessage: "unknown keys found in controller.kubelet.systemReserved: gpu",
+		},
 		{
 			context: "WithInvalidTaint",
 			configYaml: minimalValidConfigYaml + `
@@ -4158,6 +5016,30 @@ worker:
 `,
 			expectedErrorMessage: "unknown keys found in worker.nodePools[0].autoScalingGroup: foo",
 		},
+		{
+			context: "WithSpotFleetWithTooLargeOnDemandCapacity",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      onDemandTargetCapacity: 12
+`,
+			expectedErrorMessage: "onDemandTargetCapacity must be an integer between 0 and targetCapacity(10), but was 12",
+		},
+		{
+			context: "WithSpotFleetWithInstancePoolsAndDiversifiedAllocation",
+			configYaml: minimalValidConfigYaml + `
+worker:
+  nodePools:
+  - name: pool1
+    spotFleet:
+      targetCapacity: 10
+      instancePoolsToUseCount: 2
+`,
+			expectedErrorMessage: "instancePoolsToUseCount can only be specified with the \"lowestPrice\" allocationStrategy",
+		},
 		{
 			context: "WithUnknownKeyInWorkerNodePoolSpotFleet",
 			configYaml: minimalValidConfigYaml + `
@@ -4323,3 +5205,33 @@ worker:
 		})
 	}
 }
+
+// iamResources returns the resources of an iam policy statement which is either a string or a list of strings
+func iamResources(resource interface{}) []string {
+	switch r := resource.(type) {
+	case string:
+		return []string{r}
+	case []interface{}:
+		resources := []string{}
+		for _, v := range r {
+			if s, ok := v.(string); ok {
+				resources = append(resources, s)
+			}
+		}
+		return resources
+	}
+	return []string{}
+}
+
+// iamResourcesMatch returns true when any of the resources containing iam wildcards matches the arn
+func iamResourcesMatch(resources []string, arn string) bool {
+	for _, r := range resources {
+		pattern := regexp.QuoteMeta(r)
+		pattern = strings.Replace(pattern, `\*`, ".*", -1)
+		pattern = strings.Replace(pattern, `\?`, ".", -1)
+		if regexp.MustCompile("^" + pattern + "$").MatchString(arn) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	cfg "github.com/kubernetes-incubator/kube-aws/core/controlplane/config"
 	"github.com/kubernetes-incubator/kube-aws/core/nodepool/config"
+	"github.com/kubernetes-incubator/kube-aws/core/root"
+	rootcfg "github.com/kubernetes-incubator/kube-aws/core/root/config"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
+	"github.com/kubernetes-incubator/kube-aws/test/helper"
 	"strings"
 	"testing"
 )
@@ -210,3 +214,99 @@ region: ap-northeast-1"
 		})
 	}
 }
+
+func TestRolledNodePools(t *testing.T) {
+	kubeAwsSettings := newKubeAwsSettingsFromEnv(t)
+	configYaml := kubeAwsSettings.mainClusterYaml() + `
+availabilityZone: us-west-1c
+worker:
+  nodePools:
+  - name: pool1
+  - name: pool2
+  - name: spotfleet
+    spotFleet:
+      targetCapacity: 1
+`
+	providedConfig, err := rootcfg.ConfigFromBytesWithEncryptService([]byte(configYaml), []*pluginmodel.Plugin{}, helper.DummyEncryptService{})
+	if err != nil {
+		t.Fatalf("failed to parse config %s: %v", configYaml, err)
+	}
+
+	helper.WithDummyCredentials(func(dummyAssetsDir string) {
+		newCluster := func(rolled ...string) (root.Cluster, error) {
+			opts := root.NewOptions("s3://examplebucket/exampledir", false, false)
+			opts.AssetsDir = dummyAssetsDir
+			opts.ControllerTmplFile = "../../core/controlplane/config/templates/cloud-config-controller"
+			opts.WorkerTmplFile = "../../core/controlplane/config/templates/cloud-config-worker"
+			opts.EtcdTmplFile = "../../core/controlplane/config/templates/cloud-config-etcd"
+			opts.RootStackTemplateTmplFile = "../../core/root/config/templates/stack-template.json"
+			opts.NodePoolStackTemplateTmplFile = "../../core/nodepool/config/templates/stack-template.json"
+			opts.ControlPlaneStackTemplateTmplFile = "../../core/controlplane/config/templates/stack-template.json"
+			opts.RolledNodePools = rolled
+			return root.ClusterFromConfig(providedConfig, opts, false)
+		}
+
+		cluster, err := newCluster("pool1")
+		if err != nil {
+			t.Fatalf("failed to create cluster driver: %v", err)
+		}
+		for _, np := range cluster.NodePools() {
+			if np.NodePoolName == "spotfleet" {
+				continue
+			}
+			template, err := np.RenderStackTemplateAsString()
+			if err != nil {
+				t.Fatalf("failed to render the stack template of node pool %s: %v", np.NodePoolName, err)
+			}
+			hasUpdatePolicy := strings.Contains(template, "AutoScalingRollingUpdate")
+			if np.NodePoolName == "pool1" && hasUpdatePolicy {
+				t.Errorf("expected the rolled node pool to have no update policy: %s", template)
+			}
+			if np.NodePoolName == "pool2" && !hasUpdatePolicy {
+				t.Errorf("expected the node pool not rolled to have an update policy: %s", template)
+			}
+		}
+
+		if _, err := newCluster("pool3"); err == nil || !strings.Contains(err.Error(), "node pool pool3 not found") {
+			t.Errorf("expected an error for a missing node pool but was: %v", err)
+		}
+		if _, err := newCluster("spotfleet"); err == nil || !strings.Contains(err.Error(), "powered by Spot Fleet") {
+			t.Errorf("expected an error for a spot fleet node pool but was: %v", err)
+		}
+	})
+}
+
+func TestReplacedNodePoolWithKubeletIdentityPerNodePool(t *testing.T) {
+	kubeAwsSettings := newKubeAwsSettingsFromEnv(t)
+	configYaml := kubeAwsSettings.mainClusterYaml() + `
+availabilityZone: us-west-1c
+kubeletIdentity: nodePool
+experimental:
+  plugins:
+    rbac:
+      enabled: true
+worker:
+  nodePools:
+  - name: pool1
+`
+	providedConfig, err := rootcfg.ConfigFromBytesWithEncryptService([]byte(configYaml), []*pluginmodel.Plugin{}, helper.DummyEncryptService{})
+	if err != nil {
+		t.Fatalf("failed to parse config %s: %v", configYaml, err)
+	}
+
+	helper.WithDummyCredentials(func(dummyAssetsDir string) {
+		opts := root.NewOptions("s3://examplebucket/exampledir", false, false)
+		opts.AssetsDir = dummyAssetsDir
+		opts.ControllerTmplFile = "../../core/controlplane/config/templates/cloud-config-controller"
+		opts.WorkerTmplFile = "../../core/controlplane/config/templates/cloud-config-worker"
+		opts.EtcdTmplFile = "../../core/controlplane/config/templates/cloud-config-etcd"
+		opts.RootStackTemplateTmplFile = "../../core/root/config/templates/stack-template.json"
+		opts.NodePoolStackTemplateTmplFile = "../../core/nodepool/config/templates/stack-template.json"
+		opts.ControlPlaneStackTemplateTmplFile = "../../core/controlplane/config/templates/stack-template.json"
+
+		// The replacement presents the identity of pool1, for which the only certificate is issued and kube-worker:pool1 is authorized
+		if _, err := root.NodePoolReplacerFromConfig(providedConfig, "pool1", opts, root.NodePoolReplaceOptions{}, false); err != nil {
+			t.Errorf("expected node pool pool1 to be replaceable with its own kubelet identity but was: %v", err)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/kubernetes-incubator/kube-aws/core/root/config"
 	"github.com/kubernetes-incubator/kube-aws/model"
 	"github.com/kubernetes-incubator/kube-aws/plugin"
+	"github.com/kubernetes-incubator/kube-aws/plugin/pluginmodel"
 	"github.com/kubernetes-incubator/kube-aws/test/helper"
 )
 
@@ -96,6 +98,24 @@ spec:
                     }
                   }
                 }
+            patch:
+              jsonPatch:
+                inline: |
+                  [
+                    {
+                      "op": "add",
+                      "path": "/SecurityGroupWorker/Properties/SecurityGroupIngress/-",
+                      "value": {"IpProtocol": "tcp", "FromPort": 9100, "ToPort": 9100, "CidrIp": "10.0.0.0/8"}
+                    }
+                  ]
+          outputs:
+            append:
+              inline: |
+                {
+                  "QueueArnFromMyPlugin": {
+                    "Value": {"Fn::GetAtt": ["QueueFromMyPlugin", "Arn"]}
+                  }
+                }
         nodePool:
           resources:
             append:
@@ -108,6 +128,16 @@ spec:
                     }
                   }
                 }
+            patch:
+              merge:
+                inline: |
+                  {
+                    "IAMRoleWorker": {
+                      "Properties": {
+                        "MaxSessionDuration": 7200
+                      }
+                    }
+                  }
         root:
           resources:
             append:
@@ -128,6 +158,54 @@ spec:
         volumes:
         - name: "mycreds"
           path: "/etc/my/creds"
+        env:
+        - name: "QUEUE_NAME"
+          value: "{{ .Values.queue.name }}"
+      controllerManager:
+        flags:
+        - name: "horizontal-pod-autoscaler-sync-period"
+          value: "10s"
+        volumes:
+        - name: "mycreds"
+          path: "/etc/my/creds"
+          readOnly: true
+      scheduler:
+        flags:
+        - name: "policy-config-file"
+          value: "/etc/my/scheduler/policy.json"
+        volumes:
+        - name: "scheduler-policy"
+          path: "/etc/my/scheduler"
+          readOnly: true
+        env:
+        - name: "QUEUE_NAME"
+          value: "{{ .Values.queue.name }}"
+      kubeProxy:
+        flags:
+        - name: "masquerade-all"
+          value: "true"
+      manifests:
+      - name: deployment.yaml
+        weight: 10
+        wait:
+          enabled: true
+        contents:
+          inline: |
+            apiVersion: extensions/v1beta1
+            kind: Deployment
+            metadata:
+              name: queue-consumer
+              namespace: kube-system
+      - name: configmap.yaml
+        contents:
+          inline: |
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: queue
+              namespace: kube-system
+            data:
+              name: "{{ .Values.queue.name }}"
     node:
       roles:
         controller:
@@ -142,6 +220,9 @@ spec:
           kubelet:
             nodeLabels:
               role: controller
+            flags:
+            - name: "image-gc-high-threshold"
+              value: "70"
           systemd:
             units:
             - name: save-queue-name.service
@@ -160,6 +241,17 @@ spec:
                 source:
                   path: assets/controller/baz.txt
         etcd:
+          flags:
+          - name: "heartbeat-interval"
+            value: "200"
+          env:
+          - name: "ETCD_ELECTION_TIMEOUT"
+            value: "2000"
+          - name: "ETCD_DATA_DIR"
+            value: "/var/lib/etcd2/data"
+          volumes:
+          - name: "etcd-backup"
+            path: "/var/backups/etcd"
           iam:
             policy:
               statements:
@@ -199,6 +291,20 @@ spec:
               role: worker
             featureGates:
               Accelerators: "true"
+            flags:
+            - name: "image-gc-high-threshold"
+              value: "80"
+            - name: "eviction-hard"
+              value: "nodefs.available<10%"
+            env:
+            - name: "QUEUE_NAME"
+              value: "{{ .Values.queue.name }}"
+            - name: "QUEUE_OPTS"
+              value: "--priority high --ratio 50%"
+            volumes:
+            - name: "mycreds"
+              path: "/etc/my/creds"
+              readOnly: true
           systemd:
             units:
             - name: save-queue-name.service
@@ -396,10 +502,23 @@ spec:
 						t.Errorf("Invalid control-plane stack template: missing iam policy statement ec2:Describe*: %v", controlPlaneStackTemplate)
 					}
 
+					// A kube-aws plugin can patch cfn stack resources rendered by kube-aws
+					if !strings.Contains(controlPlaneStackTemplate, `"CidrIp":"10.0.0.0/8","FromPort":9100,"IpProtocol":"tcp","ToPort":9100`) {
+						t.Errorf("Invalid control-plane stack template: missing the ingress rule patched by the plugin: %v", controlPlaneStackTemplate)
+					}
+
+					// A kube-aws plugin can add outputs to the control-plane stack, which are passed to node pool stacks
+					if !strings.Contains(controlPlaneStackTemplate, `"QueueArnFromMyPlugin":{"Value":{"Fn::GetAtt":["QueueFromMyPlugin","Arn"]}}`) {
+						t.Errorf("Invalid control-plane stack template: missing output QueueArnFromMyPlugin: %v", controlPlaneStackTemplate)
+					}
+
 					rootStackTemplate, err := c.RenderStackTemplateAsString()
 					if err != nil {
 						t.Errorf("failed to render root stack template: %v", err)
 					}
+					if !strings.Contains(rootStackTemplate, `"QueueArnFromMyPlugin":{"Fn::GetAtt":["Controlplane","Outputs.QueueArnFromMyPlugin"]}`) {
+						t.Errorf("Invalid root stack template: missing parameter QueueArnFromMyPlugin passed to the node pool stack: %v", rootStackTemplate)
+					}
 					if !strings.Contains(rootStackTemplate, "QueueFromMyPlugin") {
 						t.Errorf("Invalid root stack template: missing resource QueueFromMyPlugin: %v", rootStackTemplate)
 					}
@@ -423,6 +542,15 @@ spec:
 					if !strings.Contains(nodePoolStackTemplate, `"Action":["ec2:*"]`) {
 						t.Errorf("Invalid worker node pool stack template: missing iam policy statement ec2:*: %v", nodePoolStackTemplate)
 					}
+					if !strings.Contains(nodePoolStackTemplate, `"MaxSessionDuration":7200`) {
+						t.Errorf("Invalid worker node pool stack template: missing the property patched by the plugin: %v", nodePoolStackTemplate)
+					}
+					if !strings.Contains(nodePoolStackTemplate, `"QueueArnFromMyPlugin":{"Description":"The output QueueArnFromMyPlugin of the control-plane stack contributed by a plugin","Type":"String"}`) {
+						t.Errorf("Invalid worker node pool stack template: missing parameter QueueArnFromMyPlugin: %v", nodePoolStackTemplate)
+					}
+					if !strings.Contains(nodePoolStackTemplate, `"echo 'QueueArnFromMyPlugin=",{"Ref":"QueueArnFromMyPlugin"}`) {
+						t.Errorf("Invalid worker node pool stack template: missing QueueArnFromMyPlugin in worker userdata: %v", nodePoolStackTemplate)
+					}
 
 					// A kube-aws plugin can inject node labels
 					if !strings.Contains(controllerUserdataS3Part, "role=controller") {
@@ -452,6 +580,67 @@ spec:
 					if !strings.Contains(controllerUserdataS3Part, `--oidc-issuer-url=https://login.example.com/`) {
 						t.Errorf("missing apiserver flag: --oidc-issuer-url=https://login.example.com/")
 					}
+
+					// A kube-aws plugin can add flags, volumes and env to other components
+					controllerExpectations := []string{
+						`name: "QUEUE_NAME"
+            value: "baz1"`,
+						`- --horizontal-pod-autoscaler-sync-period=10s`,
+						`- --policy-config-file=/etc/my/scheduler/policy.json`,
+						`path: "/etc/my/scheduler"`,
+						`- --masquerade-all=true`,
+						`--image-gc-high-threshold=70 \`,
+						"# phase 0\n      kubectl apply -f /srv/kube-aws/plugins/my-plugin/configmap.yaml\n",
+						"# phase 10\n      kubectl apply -f /srv/kube-aws/plugins/my-plugin/deployment.yaml\n      wait_for_rollout 300 kube-system deployment/queue-consumer\n",
+						`-l 'kube-aws.coreos.com/plugin,kube-aws.coreos.com/plugin notin (my-plugin)'`,
+						"kubectl -n kube-system get configmap kube-aws-plugins || \\\n        kubectl -n kube-system create configmap kube-aws-plugins\n",
+						"for kind in deployments daemonsets statefulsets jobs services ingresses configmaps secrets serviceaccounts roles rolebindings clusterroles clusterrolebindings networkpolicies customresourcedefinitions; do\n",
+						"path: /srv/kube-aws/plugins/my-plugin/deployment.yaml",
+					}
+					for _, e := range controllerExpectations {
+						if !strings.Contains(controllerUserdataS3Part, e) {
+							t.Errorf("missing %s in controller userdata: %s", e, controllerUserdataS3Part)
+						}
+					}
+
+					workerExpectations := []string{
+						`--image-gc-high-threshold=80 \`,
+						`--eviction-hard=nodefs.available<10%% \`,
+						`--set-env-file=/etc/kubelet-plugins-environment \`,
+						"path: /etc/kubelet-plugins-environment\n    owner: root:root\n    permissions: 0644\n    content: |\n      QUEUE_NAME=baz2\n      QUEUE_OPTS=--priority high --ratio 50%\n",
+						`--volume mycreds,kind=host,source=/etc/my/creds,readOnly=true \`,
+						`- --masquerade-all=true`,
+					}
+					for _, e := range workerExpectations {
+						if !strings.Contains(workerUserdataS3Part, e) {
+							t.Errorf("missing %s in worker userdata: %s", e, workerUserdataS3Part)
+						}
+					}
+					// Environment variables aren't embedded into the systemd unit in which spaces split them and `%` starts a specifier
+					if strings.Contains(workerUserdataS3Part, "--set-env=QUEUE_") {
+						t.Errorf("unexpected plugin env in kubelet RKT_RUN_ARGS: %s", workerUserdataS3Part)
+					}
+
+					etcdExpectations := []string{
+						`ETCD_HEARTBEAT_INTERVAL=200`,
+						`ETCD_ELECTION_TIMEOUT=2000`,
+						`ETCD_DATA_DIR=/var/lib/etcd2/data`,
+						`RKT_RUN_ARGS=$${RKT_RUN_ARGS} --volume etcd-backup,kind=host,source=/var/backups/etcd,readOnly=false --mount volume=etcd-backup,target=/var/backups/etcd`,
+					}
+					for _, e := range etcdExpectations {
+						if !strings.Contains(etcdUserdataS3Part, e) {
+							t.Errorf("missing %s in etcd userdata: %s", e, etcdUserdataS3Part)
+						}
+					}
+					// Plugins override ETCD_DATA_DIR written into /etc/etcd-environment by kube-aws only when their environment file is read after it
+					if strings.Contains(etcdUserdataS3Part, `Environment="ETCD_DATA_DIR`) {
+						t.Errorf("expected ETCD_* of plugins not to be set with Environment= which is overridden by /etc/etcd-environment: %s", etcdUserdataS3Part)
+					}
+					etcdEnvFile := strings.Index(etcdUserdataS3Part, `EnvironmentFile=-/etc/etcd-environment`)
+					pluginsEnvFile := strings.Index(etcdUserdataS3Part, `EnvironmentFile=/etc/etcd-plugins-environment`)
+					if etcdEnvFile < 0 || pluginsEnvFile < etcdEnvFile {
+						t.Errorf("expected the environment file of plugins to be read after /etc/etcd-environment: %s", etcdUserdataS3Part)
+					}
 				},
 			},
 		},
@@ -460,7 +649,16 @@ spec:
 	for _, validCase := range validCases {
 		t.Run(validCase.context, func(t *testing.T) {
 			helper.WithPlugins(validCase.plugins, func() {
-				plugins, err := plugin.LoadAll()
+				var plugins []*pluginmodel.Plugin
+				var err error
+				// Plugins and the cache in the home directory of the user running tests must not affect results
+				helper.WithTempDir(func(dir string) {
+					plugins, err = plugin.LoadAllWithOptions(nil, plugin.LoadOptions{
+						Dir:       "plugins",
+						GlobalDir: filepath.Join(dir, "plugins"),
+						CacheDir:  filepath.Join(dir, "cache", "plugins"),
+					})
+				})
 				if err != nil {
 					t.Errorf("failed to load plugins: %v", err)
 					t.FailNow()
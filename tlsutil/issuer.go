@@ -0,0 +1,74 @@
+package tlsutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net"
+)
+
+// Issuer issues leaf certificates for private keys generated by kube-aws
+type Issuer interface {
+	IssueServerCertificate(cfg ServerCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error)
+	IssueClientCertificate(cfg ClientCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error)
+	// CACertificate returns the certificate of the CA which nodes should trust to verify issued certificates
+	CACertificate() (*x509.Certificate, error)
+}
+
+// LocalIssuer signs certificates with a CA key available on the local machine
+type LocalIssuer struct {
+	CAKey  *rsa.PrivateKey
+	CACert *x509.Certificate
+}
+
+func NewLocalIssuer(caKey *rsa.PrivateKey, caCert *x509.Certificate) *LocalIssuer {
+	return &LocalIssuer{
+		CAKey:  caKey,
+		CACert: caCert,
+	}
+}
+
+func (i *LocalIssuer) IssueServerCertificate(cfg ServerCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	return NewSignedServerCertificate(cfg, key, i.CACert, i.CAKey)
+}
+
+func (i *LocalIssuer) IssueClientCertificate(cfg ClientCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	return NewSignedClientCertificate(cfg, key, i.CACert, i.CAKey)
+}
+
+func (i *LocalIssuer) CACertificate() (*x509.Certificate, error) {
+	return i.CACert, nil
+}
+
+// NewCertificateRequestPEM returns a PEM encoded CSR for the key, to be signed by an external PKI
+func NewCertificateRequestPEM(commonName string, organization []string, dnsNames []string, ipAddresses []string, key *rsa.PrivateKey) ([]byte, error) {
+	ips := make([]net.IP, len(ipAddresses))
+	for i, ipStr := range ipAddresses {
+		ips[i] = net.ParseIP(ipStr)
+	}
+
+	tmpl := x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: organization,
+		},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}
+	csrDERBytes, err := x509.CreateCertificateRequest(rand.Reader, &tmpl, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDERBytes}), nil
+}
+
+func decodeIssuedCertificatePEM(data string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,167 @@
+package tlsutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signCSR signs a PEM encoded CSR with the CA like an external PKI would do
+func signCSR(t *testing.T, csrPEM string, caKey *rsa.PrivateKey, caCert *x509.Certificate) []byte {
+	block, _ := pem.Decode([]byte(csrPEM))
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse csr: %v", err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+		NotBefore:    caCert.NotBefore,
+		NotAfter:     caCert.NotAfter,
+	}
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &tmpl, caCert, csr.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to sign csr: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDERBytes})
+}
+
+func newTestCA(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	caKey, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate ca key: %v", err)
+	}
+	caCert, err := NewSelfSignedCACertificate(CACertConfig{CommonName: "external-ca", Organization: "external", Duration: time.Hour}, caKey)
+	if err != nil {
+		t.Fatalf("failed to generate ca cert: %v", err)
+	}
+	return caKey, caCert
+}
+
+func TestVaultPKIIssuer(t *testing.T) {
+	caKey, caCert := newTestCA(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/pki/ca/pem":
+			w.Write(EncodeCertificatePEM(caCert))
+		case "/v1/pki/sign-verbatim/kube-aws":
+			if r.Header.Get("X-Vault-Token") != "mytoken" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var body struct {
+				CSR         string   `json:"csr"`
+				ExtKeyUsage []string `json:"ext_key_usage"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.ExtKeyUsage) != 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{
+					"certificate": string(signCSR(t, body.CSR, caKey, caCert)),
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	issuer := &VaultPKIIssuer{
+		Address:    server.URL,
+		Mount:      "pki",
+		Role:       "kube-aws",
+		Token:      "mytoken",
+		HTTPClient: server.Client(),
+	}
+
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	cert, err := issuer.IssueClientCertificate(ClientCertConfig{CommonName: "kube-admin", Organization: []string{"system:masters"}, Duration: time.Hour}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cert.Subject.CommonName != "kube-admin" || cert.Subject.Organization[0] != "system:masters" {
+		t.Errorf("expected the subject requested in the csr to be kept but was %+v", cert.Subject)
+	}
+	if !cert.PublicKey.(*rsa.PublicKey).Equal(key.Public()) {
+		t.Errorf("expected the certificate to be issued for the key")
+	}
+	if err := cert.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("expected the certificate to be signed by the external ca: %v", err)
+	}
+
+	ca, err := issuer.CACertificate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ca.Equal(caCert) {
+		t.Errorf("expected the ca certificate to be fetched from vault")
+	}
+
+	issuer.Token = "wrongtoken"
+	if _, err := issuer.IssueServerCertificate(ServerCertConfig{CommonName: "kube-apiserver", Duration: time.Hour}, key); err == nil {
+		t.Errorf("expected an error when vault denies the request but there was none")
+	}
+}
+
+func TestWebhookIssuer(t *testing.T) {
+	caKey, caCert := newTestCA(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req webhookSigningRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Usage != "server" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(webhookSigningResponse{
+			Certificate: string(signCSR(t, req.CSR, caKey, caCert)),
+			CA:          string(EncodeCertificatePEM(caCert)),
+		})
+	}))
+	defer server.Close()
+
+	issuer := &WebhookIssuer{
+		URL:        server.URL,
+		HTTPClient: server.Client(),
+	}
+
+	if _, err := issuer.CACertificate(); err == nil {
+		t.Errorf("expected an error before any certificate is issued but there was none")
+	}
+
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	cert, err := issuer.IssueServerCertificate(ServerCertConfig{CommonName: "kube-etcd", DNSNames: []string{"etcd0.internal"}, Duration: time.Hour}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != "etcd0.internal" {
+		t.Errorf("expected SANs requested in the csr to be kept but was %v", cert.DNSNames)
+	}
+
+	ca, err := issuer.CACertificate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ca.Equal(caCert) {
+		t.Errorf("expected the ca certificate returned from the webhook")
+	}
+}
@@ -0,0 +1,98 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultPKIIssuer issues certificates with the `sign-verbatim` endpoint of a Vault PKI secrets engine
+// so that the subject and SANs requested by kube-aws are kept as-is
+type VaultPKIIssuer struct {
+	Address    string
+	Mount      string
+	Role       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func (i *VaultPKIIssuer) IssueServerCertificate(cfg ServerCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	csr, err := NewCertificateRequestPEM(cfg.CommonName, nil, cfg.DNSNames, cfg.IPAddresses, key)
+	if err != nil {
+		return nil, err
+	}
+	return i.sign(csr, "ServerAuth", cfg.Duration)
+}
+
+func (i *VaultPKIIssuer) IssueClientCertificate(cfg ClientCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	csr, err := NewCertificateRequestPEM(cfg.CommonName, cfg.Organization, cfg.DNSNames, cfg.IPAddresses, key)
+	if err != nil {
+		return nil, err
+	}
+	return i.sign(csr, "ClientAuth", cfg.Duration)
+}
+
+func (i *VaultPKIIssuer) CACertificate() (*x509.Certificate, error) {
+	res, err := i.HTTPClient.Get(i.url("ca/pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ca certificate from vault: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch ca certificate from vault: unexpected status %s", res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIssuedCertificatePEM(string(body))
+}
+
+func (i *VaultPKIIssuer) sign(csr []byte, extKeyUsage string, duration time.Duration) (*x509.Certificate, error) {
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"csr":           string(csr),
+		"ttl":           fmt.Sprintf("%dh", int64(duration/time.Hour)),
+		"ext_key_usage": []string{extKeyUsage},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", i.url(fmt.Sprintf("sign-verbatim/%s", i.Role)), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", i.Token)
+
+	res, err := i.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate with vault: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to sign certificate with vault: unexpected status %s", res.Status)
+	}
+
+	var resBody struct {
+		Data struct {
+			Certificate string `json:"certificate"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, fmt.Errorf("failed to parse response from vault: %v", err)
+	}
+	return decodeIssuedCertificatePEM(resBody.Data.Certificate)
+}
+
+func (i *VaultPKIIssuer) url(path string) string {
+	return fmt.Sprintf("%s/v1/%s/%s", strings.TrimSuffix(i.Address, "/"), i.Mount, path)
+}
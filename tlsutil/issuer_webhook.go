@@ -0,0 +1,93 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookIssuer issues certificates by POSTing CSRs to a CSR-signing webhook.
+//
+// The webhook receives `{"csr": "<PEM>", "usage": "server|client", "duration": "<Go duration>"}` and
+// is expected to respond with `{"certificate": "<PEM>", "ca": "<PEM>"}`
+type WebhookIssuer struct {
+	URL        string
+	HTTPClient *http.Client
+
+	caCert *x509.Certificate
+}
+
+type webhookSigningRequest struct {
+	CSR      string `json:"csr"`
+	Usage    string `json:"usage"`
+	Duration string `json:"duration"`
+}
+
+type webhookSigningResponse struct {
+	Certificate string `json:"certificate"`
+	CA          string `json:"ca"`
+}
+
+func (i *WebhookIssuer) IssueServerCertificate(cfg ServerCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	csr, err := NewCertificateRequestPEM(cfg.CommonName, nil, cfg.DNSNames, cfg.IPAddresses, key)
+	if err != nil {
+		return nil, err
+	}
+	return i.sign(csr, "server", cfg.Duration)
+}
+
+func (i *WebhookIssuer) IssueClientCertificate(cfg ClientCertConfig, key *rsa.PrivateKey) (*x509.Certificate, error) {
+	csr, err := NewCertificateRequestPEM(cfg.CommonName, cfg.Organization, cfg.DNSNames, cfg.IPAddresses, key)
+	if err != nil {
+		return nil, err
+	}
+	return i.sign(csr, "client", cfg.Duration)
+}
+
+// CACertificate returns the CA certificate the webhook responded with on the latest signing request
+func (i *WebhookIssuer) CACertificate() (*x509.Certificate, error) {
+	if i.caCert == nil {
+		return nil, errors.New("the CA certificate is unknown until the webhook signs at least one certificate")
+	}
+	return i.caCert, nil
+}
+
+func (i *WebhookIssuer) sign(csr []byte, usage string, duration time.Duration) (*x509.Certificate, error) {
+	reqBody, err := json.Marshal(webhookSigningRequest{
+		CSR:      string(csr),
+		Usage:    usage,
+		Duration: duration.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := i.HTTPClient.Post(i.URL, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate with webhook: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to sign certificate with webhook: unexpected status %s", res.Status)
+	}
+
+	var resBody webhookSigningResponse
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, fmt.Errorf("failed to parse response from webhook: %v", err)
+	}
+
+	cert, err := decodeIssuedCertificatePEM(resBody.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate returned from webhook: %v", err)
+	}
+	if i.caCert, err = decodeIssuedCertificatePEM(resBody.CA); err != nil {
+		return nil, fmt.Errorf("invalid ca certificate returned from webhook: %v", err)
+	}
+	return cert, nil
+}